@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/Jcho114/go-git/ignore"
+	"github.com/Jcho114/go-git/index"
+	"github.com/Jcho114/go-git/obj"
+	"github.com/Jcho114/go-git/repo"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(addCmd)
+}
+
+var addCmd = &cobra.Command{
+	Use:   "add",
+	Short: "a very attempt at adding files to the staging area",
+	Long:  "a very very bad attempt at adding files to the staging area from scratch",
+	Args:  cobra.MatchAll(cobra.MinimumNArgs(1), cobra.OnlyValidArgs),
+	RunE:  runAdd,
+}
+
+func runAdd(cmd *cobra.Command, args []string) error {
+	repository, err := repo.FindRepository(".", true)
+	if err != nil {
+		return err
+	}
+
+	ind, err := index.IndexRead(repository)
+	if err != nil {
+		return err
+	}
+
+	rules, err := ignore.IgnoreRead(repository)
+	if err != nil {
+		return err
+	}
+
+	entries := make(map[string]index.IndexEntry)
+	unmerged := make(map[string][]index.IndexEntry)
+	for _, entry := range ind.Entries {
+		if entry.Flagstage != 0 {
+			unmerged[entry.Name] = append(unmerged[entry.Name], entry)
+			continue
+		}
+		entries[entry.Name] = entry
+	}
+
+	for _, path := range args {
+		name, err := worktreeRelative(repository, path)
+		if err != nil {
+			return err
+		}
+
+		matched := false
+		tracked := []string{}
+		for entryname := range entries {
+			tracked = append(tracked, entryname)
+		}
+		for entryname := range unmerged {
+			tracked = append(tracked, entryname)
+		}
+		for _, entryname := range tracked {
+			if !pathWithin(entryname, name) {
+				continue
+			}
+			matched = true
+			_, err := os.Lstat(worktreeAbsolute(repository, entryname))
+			if errors.Is(err, os.ErrNotExist) {
+				delete(entries, entryname)
+				delete(unmerged, entryname)
+			}
+		}
+
+		info, err := os.Lstat(worktreeAbsolute(repository, name))
+		if errors.Is(err, os.ErrNotExist) {
+			if !matched {
+				return fmt.Errorf("pathspec '%s' did not match any files", path)
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if !info.IsDir() {
+			res, err := ignore.IgnoreCheck(rules, name, false)
+			if err != nil {
+				return err
+			}
+			// Ignore rules only keep untracked files out of the index.
+			if res && !addTracked(entries, unmerged, name) {
+				return fmt.Errorf("path %s is ignored by one of your .gitignore files", path)
+			}
+			err = addFile(repository, entries, name, info)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		err = filepath.WalkDir(worktreeAbsolute(repository, name), func(walkpath string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() && d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+
+			filename, err := worktreeRelative(repository, walkpath)
+			if err != nil {
+				return err
+			}
+			if filename == "" {
+				return nil
+			}
+			res, err := ignore.IgnoreCheck(rules, filename, d.IsDir())
+			if err != nil {
+				return err
+			}
+			if res && !addTracked(entries, unmerged, filename) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() {
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			return addFile(repository, entries, filename, info)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Adding a conflicted path marks it as resolved.
+	ind.Entries = []index.IndexEntry{}
+	for _, entry := range entries {
+		delete(unmerged, entry.Name)
+		ind.Entries = append(ind.Entries, entry)
+	}
+	for _, stages := range unmerged {
+		ind.Entries = append(ind.Entries, stages...)
+	}
+	sort.Slice(ind.Entries, func(i, j int) bool {
+		return ind.Entries[i].Name < ind.Entries[j].Name
+	})
+	ind.InvalidateCaches()
+
+	return index.IndexWrite(repository, ind)
+}
+
+func addFile(repository *repo.Repository, entries map[string]index.IndexEntry, name string, info os.FileInfo) error {
+	if !info.Mode().IsRegular() && info.Mode()&os.ModeSymlink == 0 {
+		return nil
+	}
+
+	data, err := worktreeRead(repository, name, info)
+	if err != nil {
+		return err
+	}
+
+	sha, err := obj.ObjectWrite(repository, obj.NewBlob(data))
+	if err != nil {
+		return err
+	}
+
+	entry := index.NewIndexEntry(name, sha, info)
+	if existing, ok := entries[name]; ok && !repository.Config.Core.FileMode && entry.Modetype == existing.Modetype {
+		entry.Modeperms = existing.Modeperms
+	}
+	entries[name] = entry
+
+	return nil
+}
+
+// addTracked reports whether the index has an entry at or below name.
+func addTracked(entries map[string]index.IndexEntry, unmerged map[string][]index.IndexEntry, name string) bool {
+	for entryname := range entries {
+		if pathWithin(entryname, name) {
+			return true
+		}
+	}
+	for entryname := range unmerged {
+		if pathWithin(entryname, name) {
+			return true
+		}
+	}
+	return false
+}
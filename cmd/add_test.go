@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Jcho114/go-git/index"
+	"github.com/Jcho114/go-git/obj"
+)
+
+func TestAddTrackedIgnoredFile(t *testing.T) {
+	repository := testRepository(t)
+	testCommit(t, repository, "master", true, map[string]string{".gitignore": "*.log\nbuild/\n", "app.log": "one\n", "build/out.txt": "one\n"}, "first")
+	testCheckoutMaster(t, repository)
+
+	for _, name := range []string{"app.log", "build/out.txt", "new.log", "build/new.txt"} {
+		err := os.WriteFile(filepath.Join(repository.Worktree, name), []byte("two\n"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := runAdd(nil, []string{"app.log"})
+	if err != nil {
+		t.Fatalf("adding a tracked ignored file failed: %v", err)
+	}
+	err = runAdd(nil, []string{"build"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = runAdd(nil, []string{"new.log"})
+	if err == nil {
+		t.Error("adding an untracked ignored file succeeded")
+	}
+
+	two, err := obj.ObjectWrite(nil, obj.NewBlob([]byte("two\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ind, err := index.IndexRead(repository)
+	if err != nil {
+		t.Fatal(err)
+	}
+	staged := make(map[string]string)
+	for _, entry := range ind.Entries {
+		staged[entry.Name] = entry.Sha
+	}
+	for name, want := range map[string]bool{"app.log": true, "build/out.txt": true, "new.log": false, "build/new.txt": false} {
+		sha, ok := staged[name]
+		if ok != want {
+			t.Errorf("%s in the index is %v, want %v", name, ok, want)
+			continue
+		}
+		if ok && sha != two {
+			t.Errorf("%s was not updated in the index", name)
+		}
+	}
+}
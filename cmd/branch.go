@@ -0,0 +1,254 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Jcho114/go-git/graph"
+	"github.com/Jcho114/go-git/obj"
+	"github.com/Jcho114/go-git/ref"
+	"github.com/Jcho114/go-git/repo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	branchdelete      bool
+	branchforcedelete bool
+	branchmove        bool
+	branchforcemove   bool
+	branchforce       bool
+)
+
+func init() {
+	branchCmd.Flags().BoolVarP(&branchdelete, "delete", "d", false, "delete a fully merged branch")
+	branchCmd.Flags().BoolVarP(&branchforcedelete, "force-delete", "D", false, "delete a branch irrespective of its merged status")
+	branchCmd.Flags().BoolVarP(&branchmove, "move", "m", false, "move or rename a branch")
+	branchCmd.Flags().BoolVarP(&branchforcemove, "force-move", "M", false, "move or rename a branch even if the target exists")
+	branchCmd.Flags().BoolVarP(&branchforce, "force", "f", false, "reset the branch to the start point even if it exists")
+	rootCmd.AddCommand(branchCmd)
+}
+
+var branchCmd = &cobra.Command{
+	Use:   "branch",
+	Short: "a very attempt at listing, creating, or deleting branches",
+	Long:  "a very very bad attempt at listing, creating, or deleting branches from scratch",
+	Args:  cobra.MatchAll(cobra.MaximumNArgs(2), cobra.OnlyValidArgs),
+	RunE:  runBranch,
+}
+
+func runBranch(cmd *cobra.Command, args []string) error {
+	repository, err := repo.FindRepository(".", true)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case branchdelete || branchforcedelete:
+		if len(args) == 0 {
+			return fmt.Errorf("branch name required")
+		}
+		for _, name := range args {
+			err := branchDelete(repository, name, branchforcedelete)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case branchmove || branchforcemove:
+		if len(args) == 0 {
+			return fmt.Errorf("branch name required")
+		}
+		oldname, newname := "", args[0]
+		if len(args) == 2 {
+			oldname, newname = args[0], args[1]
+		}
+		return branchRename(repository, oldname, newname, branchforcemove)
+	case len(args) == 0:
+		return branchList(repository)
+	default:
+		start := "HEAD"
+		if len(args) == 2 {
+			start = args[1]
+		}
+		return branchCreate(repository, args[0], start, branchforce)
+	}
+}
+
+func branchCurrent(repository *repo.Repository) (string, error) {
+	target, err := ref.RefSymbolicRead(repository, "HEAD")
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(target, "refs/heads/") {
+		return "", nil
+	}
+	return strings.TrimPrefix(target, "refs/heads/"), nil
+}
+
+func branchNames(repository *repo.Repository) (map[string]string, error) {
+	refmap, err := ref.RefList(repository, filepath.Join(repository.Gitdir, "refs", "heads"))
+	if errors.Is(err, os.ErrNotExist) {
+		return make(map[string]string), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ref.RefFlatten(refmap, ""), nil
+}
+
+func branchList(repository *repo.Repository) error {
+	current, err := branchCurrent(repository)
+	if err != nil {
+		return err
+	}
+
+	branches, err := branchNames(repository)
+	if err != nil {
+		return err
+	}
+
+	if current == "" {
+		head, err := ref.RefResolve(repository, "HEAD")
+		if err != nil {
+			return err
+		}
+		fmt.Printf("* (HEAD detached at %s)\n", head[:7])
+	}
+
+	names := []string{}
+	for name := range branches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if name == current {
+			fmt.Printf("* %s\n", name)
+		} else {
+			fmt.Printf("  %s\n", name)
+		}
+	}
+	return nil
+}
+
+func branchCreate(repository *repo.Repository, name string, start string, force bool) error {
+	if !ref.RefCheckFormat(name) {
+		return fmt.Errorf("'%s' is not a valid branch name", name)
+	}
+
+	branchref := "refs/heads/" + name
+	_, err := ref.RefResolve(repository, branchref)
+	if err == nil && !force {
+		return fmt.Errorf("a branch named '%s' already exists", name)
+	}
+
+	current, err := branchCurrent(repository)
+	if err != nil {
+		return err
+	}
+	if force && current == name {
+		return fmt.Errorf("cannot force update the current branch")
+	}
+
+	sha, err := obj.ObjectFind(repository, start, "commit", true)
+	if err != nil {
+		return fmt.Errorf("not a valid object name: '%s'", start)
+	}
+
+	return ref.RefWrite(repository, branchref, sha)
+}
+
+func branchDelete(repository *repo.Repository, name string, force bool) error {
+	branchref := "refs/heads/" + name
+	sha, err := ref.RefResolve(repository, branchref)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("branch '%s' not found", name)
+	}
+	if err != nil {
+		return err
+	}
+
+	current, err := branchCurrent(repository)
+	if err != nil {
+		return err
+	}
+	if current == name {
+		return fmt.Errorf("cannot delete branch '%s' checked out", name)
+	}
+
+	if !force {
+		head, err := ref.RefResolve(repository, "HEAD")
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		merged := false
+		if head != "" {
+			merged, err = graph.IsAncestor(repository, sha, head)
+			if err != nil {
+				return err
+			}
+		}
+		if !merged {
+			return fmt.Errorf("the branch '%s' is not fully merged, use -D to delete it anyway", name)
+		}
+	}
+
+	err = ref.RefDelete(repository, branchref)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Deleted branch %s (was %s).\n", name, sha[:7])
+	return nil
+}
+
+func branchRename(repository *repo.Repository, oldname string, newname string, force bool) error {
+	current, err := branchCurrent(repository)
+	if err != nil {
+		return err
+	}
+	if oldname == "" {
+		if current == "" {
+			return fmt.Errorf("cannot rename the current branch while not on any")
+		}
+		oldname = current
+	}
+
+	if !ref.RefCheckFormat(newname) {
+		return fmt.Errorf("'%s' is not a valid branch name", newname)
+	}
+
+	oldref, newref := "refs/heads/"+oldname, "refs/heads/"+newname
+	sha, err := ref.RefResolve(repository, oldref)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("no branch named '%s'", oldname)
+	}
+	if err != nil {
+		return err
+	}
+
+	if oldname != newname {
+		_, err = ref.RefResolve(repository, newref)
+		if err == nil && !force {
+			return fmt.Errorf("a branch named '%s' already exists", newname)
+		}
+
+		err = ref.RefDelete(repository, oldref)
+		if err != nil {
+			return err
+		}
+		err = ref.RefWrite(repository, newref, sha)
+		if err != nil {
+			return err
+		}
+	}
+
+	if current == oldname {
+		return ref.RefSymbolicWrite(repository, "HEAD", newref)
+	}
+	return nil
+}
@@ -3,126 +3,207 @@ package cmd
 import (
 	"errors"
 	"fmt"
-	"io"
 	"os"
-	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/Jcho114/go-git/obj"
+	"github.com/Jcho114/go-git/ref"
 	"github.com/Jcho114/go-git/repo"
 	"github.com/spf13/cobra"
 )
 
+var (
+	checkoutnewbranch string
+	checkoutforce     bool
+)
+
 func init() {
+	checkoutCmd.Flags().StringVarP(&checkoutnewbranch, "branch", "b", "", "create a new branch and switch to it")
+	checkoutCmd.Flags().BoolVarP(&checkoutforce, "force", "f", false, "throw away local modifications when switching")
 	rootCmd.AddCommand(checkoutCmd)
 }
 
 var checkoutCmd = &cobra.Command{
-	Use:   "checkout",
-	Short: "a very attempt at instantiating a commit",
-	Long:  "a very very bad attempt at instantiating a commit from scratch",
-	Args:  cobra.MatchAll(cobra.ExactArgs(2), cobra.OnlyValidArgs),
-	RunE:  runCheckout,
+	Use:     "checkout",
+	Aliases: []string{"switch"},
+	Short:   "a very attempt at switching branches",
+	Long:    "a very very bad attempt at switching branches from scratch",
+	Args:    cobra.MatchAll(cobra.MaximumNArgs(1), cobra.OnlyValidArgs),
+	RunE:    runCheckout,
 }
 
 func runCheckout(cmd *cobra.Command, args []string) error {
-	commit, path := args[0], args[1]
-	path, err := filepath.Abs(path)
-	if err != nil {
-		return err
-	}
-
 	repository, err := repo.FindRepository(".", true)
 	if err != nil {
 		return err
 	}
 
-	commitname, err := obj.ObjectFind(repository, commit, "commit", true)
-	if err != nil {
+	head, err := ref.RefResolve(repository, "HEAD")
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
 		return err
 	}
-	object, err := obj.ObjectRead(repository, commitname)
+	current, err := ref.RefSymbolicRead(repository, "HEAD")
 	if err != nil {
 		return err
 	}
-	commitobject, ok := object.(*obj.Commit)
-	if !ok {
-		return fmt.Errorf("object is not a commit: %s", commitname)
+
+	if checkoutnewbranch != "" {
+		start := "HEAD"
+		if len(args) == 1 {
+			start = args[0]
+		}
+		if head == "" && len(args) == 0 {
+			if !ref.RefCheckFormat(checkoutnewbranch) {
+				return fmt.Errorf("'%s' is not a valid branch name", checkoutnewbranch)
+			}
+			err := ref.RefSymbolicWrite(repository, "HEAD", "refs/heads/"+checkoutnewbranch)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Switched to a new branch '%s'\n", checkoutnewbranch)
+			return nil
+		}
+
+		startname, err := obj.ObjectFind(repository, start, "commit", true)
+		if err != nil {
+			return err
+		}
+		err = checkoutSwitch(repository, head, startname, "", checkoutforce)
+		if err != nil {
+			return err
+		}
+		err = branchCreate(repository, checkoutnewbranch, startname, false)
+		if err != nil {
+			return err
+		}
+		err = ref.RefSymbolicWrite(repository, "HEAD", "refs/heads/"+checkoutnewbranch)
+		if err != nil {
+			return err
+		}
+		err = checkoutRecord(repository, current, head, startname, checkoutnewbranch)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Switched to a new branch '%s'\n", checkoutnewbranch)
+		return nil
 	}
 
-	trees, ok := commitobject.Kvlm["tree"]
-	if !ok {
-		return fmt.Errorf("commit object does not have a tree value")
+	if len(args) == 0 {
+		return fmt.Errorf("missing branch or commit argument")
 	}
-	if len(trees) != 1 {
-		return fmt.Errorf("commit object does not only have 1 tree value")
+	target := args[0]
+
+	// Like git, "-" and "@{-N}" switch back to what was checked out before.
+	if target == "-" {
+		target = "@{-1}"
 	}
-	treename := commitobject.Kvlm["tree"][0]
-	object, err = obj.ObjectRead(repository, treename)
-	if err != nil {
-		return err
+	if match := checkoutPrevious.FindStringSubmatch(target); match != nil {
+		n, _ := strconv.Atoi(match[1])
+		target, err = ref.RefPrevious(repository, n)
+		if err != nil {
+			return err
+		}
 	}
 
-	treeobject, ok := object.(*obj.Tree)
-	if !ok {
-		return fmt.Errorf("object is not a tree: %s", treename)
+	branchref := "refs/heads/" + target
+	targetname, err := ref.RefResolve(repository, branchref)
+	isbranch := err == nil
+	if !isbranch {
+		targetname, err = obj.ObjectFind(repository, target, "commit", true)
+		if err != nil {
+			return fmt.Errorf("pathspec '%s' did not match any branch or commit", target)
+		}
 	}
 
-	info, err := os.Stat(path)
-	pathexists := !errors.Is(err, os.ErrNotExist)
-	if !pathexists {
-		err := os.MkdirAll(path, 0750)
+	if isbranch && current == branchref {
+		fmt.Printf("Already on '%s'\n", target)
+		return nil
+	}
+
+	err = checkoutSwitch(repository, head, targetname, "", checkoutforce)
+	if err != nil {
+		return err
+	}
+
+	if isbranch {
+		err = ref.RefSymbolicWrite(repository, "HEAD", branchref)
 		if err != nil {
 			return err
 		}
-	} else {
-		if !info.Mode().IsDir() {
-			return fmt.Errorf("path %s is not a directory", path)
-		}
-		file, err := os.Open(path)
+		err = checkoutRecord(repository, current, head, targetname, target)
 		if err != nil {
-			return nil
-		}
-		defer file.Close()
-
-		_, err = file.Readdirnames(1)
-		if err != io.EOF {
-			return fmt.Errorf("path %s is not empty", path)
+			return err
 		}
+		fmt.Printf("Switched to branch '%s'\n", target)
+		return nil
 	}
 
-	err = checkoutTree(repository, treeobject, path)
+	err = ref.RefWrite(repository, "HEAD", targetname)
 	if err != nil {
 		return err
 	}
-
+	err = checkoutRecord(repository, current, head, targetname, target)
+	if err != nil {
+		return err
+	}
+	summary, err := commitSummary(repository, targetname)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("HEAD is now at %s %s\n", targetname[:7], summary)
 	return nil
 }
 
-func checkoutTree(repository *repo.Repository, tree *obj.Tree, path string) error {
-	for _, item := range tree.Items {
-		object, err := obj.ObjectRead(repository, item.Sha)
-		if err != nil {
-			return err
-		}
+var checkoutPrevious = regexp.MustCompile(`^@\{-(\d+)\}$`)
 
-		destpath := filepath.Join(path, item.Path)
+// checkoutRecord notes the switch in the reflog of HEAD, which is where the
+// branch checked out before is found again for "-" and "@{-N}". Nothing is
+// recorded when there is no identity to record it under.
+func checkoutRecord(repository *repo.Repository, current string, from string, to string, target string) error {
+	ident, err := commitIdentity(repository, "COMMITTER")
+	if err != nil {
+		return nil
+	}
+	source := strings.TrimPrefix(current, "refs/heads/")
+	if current == "" {
+		source = from
+	}
+	message := fmt.Sprintf("checkout: moving from %s to %s", source, target)
+	return ref.RefLogAppend(repository, "HEAD", from, to, ident, message)
+}
 
-		if tree, ok := object.(*obj.Tree); ok {
-			err := os.MkdirAll(destpath, 0750)
-			if err != nil {
-				return err
-			}
+func checkoutSwitch(repository *repo.Repository, from string, to string, operation string, force bool) error {
+	if operation == "" {
+		operation = "checkout"
+	}
 
-			err = checkoutTree(repository, tree, destpath)
-			if err != nil {
-				return err
-			}
-		} else if blob, ok := object.(*obj.Blob); ok {
-			err := os.WriteFile(destpath, blob.Data, 0644)
-			if err != nil {
-				return err
-			}
-		}
+	current, err := commitTreeEntries(repository, from)
+	if err != nil {
+		return err
 	}
-	return nil
+	target, err := commitTreeEntries(repository, to)
+	if err != nil {
+		return err
+	}
+
+	return treeSwitch(repository, current, target, force, operation)
+}
+
+func commitSummary(repository *repo.Repository, sha string) (string, error) {
+	object, err := obj.ObjectRead(repository, sha)
+	if err != nil {
+		return "", err
+	}
+	commit, ok := object.(*obj.Commit)
+	if !ok {
+		return "", fmt.Errorf("object %s is not a commit", sha)
+	}
+
+	message := ""
+	if len(commit.Kvlm[""]) > 0 {
+		message = strings.TrimSpace(commit.Kvlm[""][0])
+	}
+	return strings.SplitN(message, "\n", 2)[0], nil
 }
@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Jcho114/go-git/index"
+	"github.com/Jcho114/go-git/ref"
+	"github.com/Jcho114/go-git/repo"
+)
+
+func testCheckout(t *testing.T, repository *repo.Repository, from string, to string) error {
+	t.Helper()
+	return checkoutSwitch(repository, testResolve(t, repository, from), testResolve(t, repository, to), "checkout", false)
+}
+
+func TestCheckoutKeepsUntrackedFile(t *testing.T) {
+	repository := testRepository(t)
+	testCommit(t, repository, "master", true, map[string]string{"a.txt": "a\n", "only.txt": "only\n"}, "with only")
+	testCommit(t, repository, "nofile", false, map[string]string{"a.txt": "a\n"}, "without only")
+	err := checkoutSwitch(repository, "", testResolve(t, repository, "master"), "checkout", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// git rm --cached only.txt, then change it on disk.
+	ind, err := index.IndexRead(repository)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := []index.IndexEntry{}
+	for _, entry := range ind.Entries {
+		if entry.Name != "only.txt" {
+			entries = append(entries, entry)
+		}
+	}
+	ind.Entries = entries
+	err = index.IndexWrite(repository, ind)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(repository.Worktree, "only.txt")
+	err = os.WriteFile(path, []byte("precious\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = testCheckout(t, repository, "master", "nofile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil || string(content) != "precious\n" {
+		t.Errorf("untracked only.txt became %q, %v", content, err)
+	}
+}
+
+func TestCheckoutUntrackedDirectoryInTheWay(t *testing.T) {
+	repository := testRepository(t)
+	testCommit(t, repository, "master", true, map[string]string{"a.txt": "a\n"}, "first")
+	testCommit(t, repository, "other", false, map[string]string{"a.txt": "changed\n", "dir": "file\n"}, "dir is a file")
+	err := checkoutSwitch(repository, "", testResolve(t, repository, "master"), "checkout", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.MkdirAll(filepath.Join(repository.Worktree, "dir"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(repository.Worktree, "dir", "notes.txt"), []byte("notes\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = testCheckout(t, repository, "master", "other")
+	if err == nil {
+		t.Fatal("checkout over an untracked directory succeeded")
+	}
+	// Nothing was applied before the switch was refused.
+	content, _ := os.ReadFile(filepath.Join(repository.Worktree, "a.txt"))
+	if string(content) != "a\n" {
+		t.Errorf("a.txt was changed to %q by a refused checkout", content)
+	}
+	if _, err := os.Stat(filepath.Join(repository.Worktree, "dir", "notes.txt")); err != nil {
+		t.Errorf("the untracked file is gone: %v", err)
+	}
+}
+
+// testCheckoutMaster checks master out into an empty worktree and runs the
+// rest of the test from there.
+func testCheckoutMaster(t *testing.T, repository *repo.Repository) {
+	t.Helper()
+	err := checkoutSwitch(repository, "", testResolve(t, repository, "master"), "checkout", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testChdir(t, repository.Worktree)
+}
+
+func testResolve(t *testing.T, repository *repo.Repository, branch string) string {
+	t.Helper()
+	sha, err := ref.RefResolve(repository, "refs/heads/"+branch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sha
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/Jcho114/go-git/repo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cherrypickcontinue bool
+	cherrypickskip     bool
+	cherrypickabort    bool
+)
+
+func init() {
+	cherryPickCmd.Flags().BoolVar(&cherrypickcontinue, "continue", false, "continue the operation in progress after resolving conflicts")
+	cherryPickCmd.Flags().BoolVar(&cherrypickskip, "skip", false, "skip the current commit and continue with the rest of the sequence")
+	cherryPickCmd.Flags().BoolVar(&cherrypickabort, "abort", false, "cancel the operation and return to the pre-sequence state")
+	rootCmd.AddCommand(cherryPickCmd)
+}
+
+var cherryPickCmd = &cobra.Command{
+	Use:   "cherry-pick",
+	Short: "a very attempt at applying the changes introduced by some existing commits",
+	Long:  "a very very bad attempt at applying the changes introduced by some existing commits from scratch",
+	Args:  cobra.MatchAll(cobra.ArbitraryArgs, cobra.OnlyValidArgs),
+	RunE:  runCherryPick,
+}
+
+func runCherryPick(cmd *cobra.Command, args []string) error {
+	repository, err := repo.FindRepository(".", true)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case cherrypickcontinue:
+		return sequencerContinue(repository)
+	case cherrypickskip:
+		return sequencerSkip(repository)
+	case cherrypickabort:
+		return sequencerAbort(repository)
+	}
+
+	if len(args) == 0 {
+		return fmt.Errorf("no commits specified to cherry pick")
+	}
+	return sequencerStart(repository, "pick", args)
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Jcho114/go-git/ref"
+	"github.com/Jcho114/go-git/repo"
+	"github.com/Jcho114/go-git/transport"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(cloneCmd)
+}
+
+var cloneCmd = &cobra.Command{
+	Use:   "clone",
+	Short: "a very attempt at cloning a repository into a new directory",
+	Long:  "a very very bad attempt at cloning a repository into a new directory from scratch",
+	Args:  cobra.MatchAll(cobra.RangeArgs(1, 2), cobra.OnlyValidArgs),
+	RunE:  runClone,
+}
+
+func runClone(cmd *cobra.Command, args []string) error {
+	url := args[0]
+	path := cloneDirectory(url)
+	if !strings.Contains(url, "://") {
+		if _, ok := transport.LocalPath(url); ok {
+			abspath, err := filepath.Abs(url)
+			if err != nil {
+				return err
+			}
+			url = abspath
+		}
+	}
+	if len(args) > 1 {
+		path = args[1]
+	}
+	if path == "" {
+		return fmt.Errorf("unable to guess a directory name from '%s', please specify one", url)
+	}
+
+	entries, err := os.ReadDir(path)
+	created := errors.Is(err, os.ErrNotExist)
+	if err == nil && len(entries) > 0 {
+		return fmt.Errorf("destination path '%s' already exists and is not an empty directory", path)
+	}
+
+	fmt.Fprintf(os.Stderr, "Cloning into '%s'...\n", path)
+	repository, err := initRepository(path)
+	if err != nil {
+		return err
+	}
+	err = cloneRun(repository, url)
+	if err != nil {
+		if created {
+			os.RemoveAll(path)
+		} else {
+			os.RemoveAll(repository.Gitdir)
+		}
+		return err
+	}
+	return nil
+}
+
+// cloneDirectory names the directory a clone goes into after the last
+// component of its url, without any ".git" suffix.
+func cloneDirectory(url string) string {
+	name := strings.TrimRight(url, "/")
+	name = strings.TrimSuffix(name, "/.git")
+	name = name[strings.LastIndexAny(name, "/:")+1:]
+	return strings.TrimSuffix(name, ".git")
+}
+
+// cloneRun sets up the url as the origin remote, fetches every branch from
+// it and checks out the branch its HEAD points to.
+func cloneRun(repository *repo.Repository, url string) error {
+	configpath := filepath.Join(repository.Gitdir, "config")
+	repository.Config.Remotes["origin"] = &repo.RemoteConfig{
+		URL:   url,
+		Fetch: []string{"+refs/heads/*:refs/remotes/origin/*"},
+	}
+	err := repository.Config.Write(configpath)
+	if err != nil {
+		return err
+	}
+
+	_, refs, err := fetchRemote(repository, "origin", transport.NewProgressWriter(os.Stderr))
+	if err != nil {
+		return err
+	}
+
+	var head *transport.Ref
+	for i := range refs {
+		if refs[i].Name == "HEAD" {
+			head = &refs[i]
+		}
+	}
+	if head == nil {
+		fmt.Fprintln(os.Stderr, "warning: You appear to have cloned an empty repository.")
+		return nil
+	}
+
+	if branch, ok := strings.CutPrefix(head.Target, "refs/heads/"); ok {
+		err := ref.RefSymbolicWrite(repository, "refs/remotes/origin/HEAD", "refs/remotes/origin/"+branch)
+		if err != nil {
+			return err
+		}
+		err = ref.RefWrite(repository, head.Target, head.Sha)
+		if err != nil {
+			return err
+		}
+		err = ref.RefSymbolicWrite(repository, "HEAD", head.Target)
+		if err != nil {
+			return err
+		}
+		repository.Config.Branches[branch] = &repo.BranchConfig{Remote: "origin", Merge: head.Target}
+		err = repository.Config.Write(configpath)
+		if err != nil {
+			return err
+		}
+	} else {
+		err := ref.RefWrite(repository, "HEAD", head.Sha)
+		if err != nil {
+			return err
+		}
+	}
+
+	return checkoutSwitch(repository, "", head.Sha, "clone", false)
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Jcho114/go-git/ref"
+)
+
+func TestCloneRun(t *testing.T) {
+	server := testRepository(t)
+	main := testCommit(t, server, "master", true, map[string]string{"a.txt": "hello\n"}, "first")
+
+	client := testRepository(t)
+	err := cloneRun(client, testServer(t, server))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, _ := ref.RefResolve(client, "HEAD"); got != main {
+		t.Errorf("HEAD is at %s, want %s", got, main)
+	}
+	if target, _ := ref.RefSymbolicRead(client, "refs/remotes/origin/HEAD"); target != "refs/remotes/origin/master" {
+		t.Errorf("origin/HEAD points at %q", target)
+	}
+	if branch := client.Config.Branches["master"]; branch == nil || branch.Remote != "origin" || branch.Merge != "refs/heads/master" {
+		t.Errorf("master tracks %+v", branch)
+	}
+	content, err := os.ReadFile(filepath.Join(client.Worktree, "a.txt"))
+	if err != nil || string(content) != "hello\n" {
+		t.Errorf("a.txt was checked out as %q, %v", content, err)
+	}
+}
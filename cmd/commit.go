@@ -0,0 +1,333 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Jcho114/go-git/index"
+	"github.com/Jcho114/go-git/obj"
+	"github.com/Jcho114/go-git/ref"
+	"github.com/Jcho114/go-git/repo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	commitmessages []string
+	allowempty     bool
+)
+
+func init() {
+	commitCmd.Flags().StringArrayVarP(&commitmessages, "message", "m", []string{}, "use the given message as the commit message")
+	commitCmd.Flags().BoolVar(&allowempty, "allow-empty", false, "allow recording a commit with the same tree as its parent")
+	rootCmd.AddCommand(commitCmd)
+}
+
+var commitCmd = &cobra.Command{
+	Use:   "commit",
+	Short: "a very attempt at recording changes to the repository",
+	Long:  "a very very bad attempt at recording changes to the repository from scratch",
+	Args:  cobra.NoArgs,
+	RunE:  runCommit,
+}
+
+func runCommit(cmd *cobra.Command, args []string) error {
+	repository, err := repo.FindRepository(".", true)
+	if err != nil {
+		return err
+	}
+
+	mergeheads, mergemessage, err := commitMergeState(repository)
+	if err != nil {
+		return err
+	}
+
+	message := commitMessage(commitmessages)
+	if message == "" {
+		message = commitMessage([]string{mergemessage})
+	}
+	if message == "" {
+		return fmt.Errorf("aborting commit due to empty commit message")
+	}
+
+	ind, err := index.IndexRead(repository)
+	if err != nil {
+		return err
+	}
+
+	treename, err := treeFromIndex(repository, ind)
+	if err != nil {
+		return err
+	}
+
+	parents := []string{}
+	parent, err := ref.RefResolve(repository, "HEAD")
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if parent != "" {
+		parents = append(parents, parent)
+	}
+
+	parents = append(parents, mergeheads...)
+
+	if len(parents) == 1 && !allowempty {
+		parenttree, err := obj.ObjectFind(repository, parent, "tree", true)
+		if err != nil {
+			return err
+		}
+		if parenttree == treename {
+			return fmt.Errorf("nothing to commit, working tree clean")
+		}
+	}
+
+	author, err := sequencerPickAuthor(repository)
+	if err != nil {
+		return err
+	}
+	commitname, err := commitCreate(repository, treename, parents, message, author)
+	if err != nil {
+		return err
+	}
+
+	err = ref.RefUpdate(repository, "HEAD", commitname)
+	if err != nil {
+		return err
+	}
+	err = mergeStateClear(repository)
+	if err != nil {
+		return err
+	}
+	err = sequencerStateClear(repository)
+	if err != nil {
+		return err
+	}
+	err = sequencerCleanup(repository)
+	if err != nil {
+		return err
+	}
+
+	return commitReport(repository, commitname, message, len(parents) == 0)
+}
+
+func commitReport(repository *repo.Repository, commitname string, message string, root bool) error {
+	branch, err := ref.RefSymbolicRead(repository, "HEAD")
+	if err != nil {
+		return err
+	}
+	if branch == "" {
+		branch = "detached HEAD"
+	}
+	branch = strings.TrimPrefix(branch, "refs/heads/")
+	if root {
+		branch += " (root-commit)"
+	}
+	fmt.Printf("[%s %s] %s\n", branch, commitname[:7], strings.SplitN(message, "\n", 2)[0])
+	return nil
+}
+
+// A merge, cherry-pick or revert that stopped on conflicts leaves a prepared
+// message behind for the commit that concludes it, and a merge also leaves the
+// other parent.
+func commitMergeState(repository *repo.Repository) ([]string, string, error) {
+	content, err := os.ReadFile(filepath.Join(repository.Gitdir, "MERGE_HEAD"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, "", err
+	}
+	mergeheads := strings.Fields(string(content))
+
+	content, err = os.ReadFile(filepath.Join(repository.Gitdir, "MERGE_MSG"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, "", err
+	}
+	lines := []string{}
+	for _, line := range strings.Split(string(content), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	return mergeheads, strings.Join(lines, "\n"), nil
+}
+
+func commitMessage(messages []string) string {
+	paragraphs := []string{}
+	for _, message := range messages {
+		message = strings.TrimSpace(message)
+		if message != "" {
+			paragraphs = append(paragraphs, message)
+		}
+	}
+	if len(paragraphs) == 0 {
+		return ""
+	}
+	return strings.Join(paragraphs, "\n\n") + "\n"
+}
+
+func commitCreate(repository *repo.Repository, tree string, parents []string, message string, author string) (string, error) {
+	committer, err := commitIdentity(repository, "COMMITTER")
+	if err != nil {
+		return "", err
+	}
+	if author == "" {
+		author, err = commitIdentity(repository, "AUTHOR")
+		if err != nil {
+			return "", err
+		}
+	}
+
+	commit := obj.NewCommit(nil)
+	commit.Kvlm["tree"] = []string{tree}
+	if len(parents) > 0 {
+		commit.Kvlm["parent"] = parents
+	}
+	commit.Kvlm["author"] = []string{author}
+	commit.Kvlm["committer"] = []string{committer}
+	commit.Kvlm[""] = []string{message}
+
+	return obj.ObjectWrite(repository, commit)
+}
+
+func commitIdentity(repository *repo.Repository, role string) (string, error) {
+	name := os.Getenv("GIT_" + role + "_NAME")
+	email := os.Getenv("GIT_" + role + "_EMAIL")
+
+	if name == "" {
+		name = repository.Config.User.Name
+	}
+	if email == "" {
+		email = repository.Config.User.Email
+	}
+	if name == "" || email == "" {
+		global, err := repo.GlobalConfig()
+		if err != nil {
+			return "", err
+		}
+		if name == "" {
+			name = global.User.Name
+		}
+		if email == "" {
+			email = global.User.Email
+		}
+	}
+	if name == "" || email == "" {
+		return "", fmt.Errorf("unable to determine %s identity, set user.name and user.email", strings.ToLower(role))
+	}
+
+	now := time.Now()
+	date := fmt.Sprintf("%d %s", now.Unix(), now.Format("-0700"))
+	if value := os.Getenv("GIT_" + role + "_DATE"); value != "" {
+		var err error
+		date, err = commitParseDate(value)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return fmt.Sprintf("%s <%s> %s", name, email, date), nil
+}
+
+var commitRawDate = regexp.MustCompile(`^@?(\d+)(?: ([+-]\d{4}))?$`)
+
+// commitParseDate turns a date given in the environment into the
+// "<unix> <+hhmm>" form commits record. It takes git's raw form, with or
+// without "@", and the RFC 2822 and ISO 8601 forms.
+func commitParseDate(value string) (string, error) {
+	if match := commitRawDate.FindStringSubmatch(value); match != nil {
+		zone := match[2]
+		if zone == "" {
+			zone = "+0000"
+		}
+		return match[1] + " " + zone, nil
+	}
+
+	layouts := []string{
+		time.RFC1123Z,
+		"2 Jan 2006 15:04:05 -0700",
+		time.RFC3339,
+		"2006-01-02T15:04:05-0700",
+		"2006-01-02 15:04:05 -0700",
+		"2006-01-02 15:04:05Z07:00",
+		"2006-01-02T15:04:05",
+		"2006-01-02 15:04:05",
+	}
+	for _, layout := range layouts {
+		when, err := time.ParseInLocation(layout, value, time.Local)
+		if err == nil {
+			return fmt.Sprintf("%d %s", when.Unix(), when.Format("-0700")), nil
+		}
+	}
+	return "", fmt.Errorf("invalid date format: %s", value)
+}
+
+func treeFromIndex(repository *repo.Repository, ind *index.Index) (string, error) {
+	leaves := make(map[string]*obj.TreeLeaf)
+	for _, entry := range ind.Entries {
+		if entry.Flagstage != 0 {
+			return "", fmt.Errorf("unable to write tree with unmerged path %s", entry.Name)
+		}
+		leaves[entry.Name] = obj.NewTreeLeaf(indexEntryMode(entry), entry.Name, entry.Sha)
+	}
+	return treeFromLeaves(repository, leaves)
+}
+
+func treeFromLeaves(repository *repo.Repository, leaves map[string]*obj.TreeLeaf) (string, error) {
+	contents := map[string][]*obj.TreeLeaf{"": {}}
+
+	for name, entry := range leaves {
+		dirname := path.Dir(name)
+		if dirname == "." {
+			dirname = ""
+		}
+		for key := dirname; key != ""; {
+			if _, ok := contents[key]; !ok {
+				contents[key] = []*obj.TreeLeaf{}
+			}
+			key = path.Dir(key)
+			if key == "." {
+				key = ""
+			}
+		}
+
+		leaf := obj.NewTreeLeaf(entry.Mode, path.Base(name), entry.Sha)
+		contents[dirname] = append(contents[dirname], leaf)
+	}
+
+	dirnames := []string{}
+	for dirname := range contents {
+		dirnames = append(dirnames, dirname)
+	}
+	sort.Slice(dirnames, func(i, j int) bool {
+		return len(dirnames[i]) > len(dirnames[j])
+	})
+
+	var sha string
+	for _, dirname := range dirnames {
+		tree := obj.NewTree(nil)
+		tree.Items = contents[dirname]
+
+		var err error
+		sha, err = obj.ObjectWrite(repository, tree)
+		if err != nil {
+			return "", err
+		}
+
+		if dirname == "" {
+			break
+		}
+
+		parent := path.Dir(dirname)
+		if parent == "." {
+			parent = ""
+		}
+		leaf := obj.NewTreeLeaf("040000", path.Base(dirname), sha)
+		contents[parent] = append(contents[parent], leaf)
+	}
+
+	return sha, nil
+}
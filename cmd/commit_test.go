@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestCommitParseDate(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"@1700000000 +0100", "1700000000 +0100"},
+		{"@1700000000", "1700000000 +0000"},
+		{"1700000000 -0530", "1700000000 -0530"},
+		{"Tue, 14 Nov 2023 22:13:20 +0000", "1700000000 +0000"},
+		{"14 Nov 2023 23:13:20 +0100", "1700000000 +0100"},
+		{"2023-11-14T22:13:20Z", "1700000000 +0000"},
+		{"2023-11-14T17:13:20-05:00", "1700000000 -0500"},
+		{"2023-11-14 23:13:20 +0100", "1700000000 +0100"},
+	}
+	for _, test := range tests {
+		got, err := commitParseDate(test.value)
+		if err != nil || got != test.want {
+			t.Errorf("commitParseDate(%q) = %q, %v, want %q", test.value, got, err, test.want)
+		}
+	}
+
+	for _, value := range []string{"yesterday", "2024-13-01", "1700000000 +01", "@17000 0000"} {
+		if got, err := commitParseDate(value); err == nil {
+			t.Errorf("commitParseDate(%q) = %q, want an error", value, got)
+		}
+	}
+}
+
+func TestCommitIdentityDate(t *testing.T) {
+	repository := testRepository(t)
+	t.Setenv("GIT_AUTHOR_NAME", "a")
+	t.Setenv("GIT_AUTHOR_EMAIL", "a@b")
+	t.Setenv("GIT_AUTHOR_DATE", "2024-01-01T00:00:00Z")
+	ident, err := commitIdentity(repository, "AUTHOR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "a <a@b> 1704067200 +0000"; ident != want {
+		t.Errorf("identity is %q, want %q", ident, want)
+	}
+
+	t.Setenv("GIT_AUTHOR_DATE", "")
+	before := time.Now().Unix()
+	ident, err = commitIdentity(repository, "AUTHOR")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var seconds int64
+	var zone string
+	_, err = fmt.Sscanf(ident, "a <a@b> %d %s", &seconds, &zone)
+	if err != nil || seconds < before || len(zone) != 5 {
+		t.Errorf("identity without a date is %q", ident)
+	}
+}
@@ -0,0 +1,503 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Jcho114/go-git/diff"
+	"github.com/Jcho114/go-git/index"
+	"github.com/Jcho114/go-git/obj"
+	"github.com/Jcho114/go-git/ref"
+	"github.com/Jcho114/go-git/repo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffcached  bool
+	diffcontext int
+)
+
+func init() {
+	diffCmd.Flags().BoolVar(&diffcached, "cached", false, "compare the index against HEAD or the given commit")
+	diffCmd.Flags().IntVarP(&diffcontext, "unified", "U", 3, "number of context lines around each change")
+	rootCmd.AddCommand(diffCmd)
+}
+
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "a very attempt at showing changes between commits, the index and the worktree",
+	Long:  "a very very bad attempt at showing changes between commits, the index and the worktree from scratch",
+	Args:  cobra.ArbitraryArgs,
+	RunE:  runDiff,
+}
+
+type diffEntry struct {
+	Mode     string
+	Sha      string
+	Worktree bool
+}
+
+type diffSide = map[string]*diffEntry
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	repository, err := repo.FindRepository(".", true)
+	if err != nil {
+		return err
+	}
+
+	revisions, pathspecs, err := diffArgs(repository, args, cmd.ArgsLenAtDash())
+	if err != nil {
+		return err
+	}
+	if len(revisions) > 2 || (diffcached && len(revisions) > 1) {
+		return fmt.Errorf("too many revisions given")
+	}
+
+	paths := []string{}
+	for _, pathspec := range pathspecs {
+		name, err := worktreeRelative(repository, pathspec)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, name)
+	}
+
+	var oldside, newside diffSide
+	switch {
+	case diffcached:
+		commit := "HEAD"
+		if len(revisions) == 1 {
+			commit = revisions[0]
+		}
+		oldside, err = diffCommitSide(repository, commit)
+		if err != nil {
+			return err
+		}
+		newside, err = diffIndexSide(repository)
+	case len(revisions) == 2:
+		oldside, err = diffCommitSide(repository, revisions[0])
+		if err != nil {
+			return err
+		}
+		newside, err = diffCommitSide(repository, revisions[1])
+	case len(revisions) == 1:
+		oldside, err = diffCommitSide(repository, revisions[0])
+		if err != nil {
+			return err
+		}
+		newside, err = diffWorktreeSide(repository)
+	default:
+		oldside, err = diffIndexSide(repository)
+		if err != nil {
+			return err
+		}
+		newside, err = diffWorktreeSide(repository)
+	}
+	if err != nil {
+		return err
+	}
+
+	return diffPrint(repository, oldside, newside, paths, diffcontext)
+}
+
+// diffArgs splits the arguments into revisions and paths. Without "--" the
+// paths start at the first argument that is not a revision, and each of them
+// must then be in the worktree or the index.
+func diffArgs(repository *repo.Repository, args []string, dash int) ([]string, []string, error) {
+	if dash != -1 {
+		return args[:dash], args[dash:], nil
+	}
+	for i, arg := range args {
+		if arg == "HEAD" {
+			continue
+		}
+		if _, err := obj.ObjectFind(repository, arg, "commit", true); err == nil {
+			continue
+		}
+
+		ind, err := index.IndexRead(repository)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, path := range args[i:] {
+			known := false
+			if _, err := os.Lstat(path); err == nil {
+				known = true
+			} else if name, err := worktreeRelative(repository, path); err == nil {
+				for _, entry := range ind.Entries {
+					if pathWithin(entry.Name, name) {
+						known = true
+						break
+					}
+				}
+			}
+			if !known {
+				return nil, nil, fmt.Errorf("ambiguous argument '%s': unknown revision or path not in the working tree, use '--' to separate paths from revisions", path)
+			}
+		}
+		return args[:i], args[i:], nil
+	}
+	return args, []string{}, nil
+}
+
+func diffCommitSide(repository *repo.Repository, name string) (diffSide, error) {
+	commit := ""
+	if name == "HEAD" {
+		head, err := ref.RefResolve(repository, "HEAD")
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		commit = head
+	} else {
+		sha, err := obj.ObjectFind(repository, name, "commit", true)
+		if err != nil {
+			return nil, err
+		}
+		commit = sha
+	}
+
+	leaves, err := commitTreeEntries(repository, commit)
+	if err != nil {
+		return nil, err
+	}
+	return diffTreeSide(leaves), nil
+}
+
+func diffTreeSide(leaves map[string]*obj.TreeLeaf) diffSide {
+	side := make(diffSide)
+	for name, leaf := range leaves {
+		side[name] = &diffEntry{Mode: leaf.Mode, Sha: leaf.Sha}
+	}
+	return side
+}
+
+func diffIndexSide(repository *repo.Repository) (diffSide, error) {
+	ind, err := index.IndexRead(repository)
+	if err != nil {
+		return nil, err
+	}
+
+	side := make(diffSide)
+	for _, entry := range ind.Entries {
+		if entry.Flagstage != 0 {
+			continue
+		}
+		side[entry.Name] = &diffEntry{Mode: indexEntryMode(entry), Sha: entry.Sha}
+	}
+	return side, nil
+}
+
+func diffWorktreeSide(repository *repo.Repository) (diffSide, error) {
+	ind, err := index.IndexRead(repository)
+	if err != nil {
+		return nil, err
+	}
+	indexinfo, err := os.Stat(filepath.Join(repository.Gitdir, "index"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	side := make(diffSide)
+	for _, entry := range ind.Entries {
+		if entry.Flagstage != 0 {
+			continue
+		}
+
+		state, err := worktreeState(repository, entry, indexinfo)
+		if err != nil {
+			return nil, err
+		}
+		switch state {
+		case "":
+			side[entry.Name] = &diffEntry{Mode: indexEntryMode(entry), Sha: entry.Sha}
+		case "D":
+		default:
+			info, err := os.Lstat(worktreeAbsolute(repository, entry.Name))
+			if err != nil {
+				return nil, err
+			}
+			sha, err := worktreeHash(repository, entry.Name, info)
+			if err != nil {
+				return nil, err
+			}
+			current := index.NewIndexEntry(entry.Name, sha, info)
+			if !repository.Config.Core.FileMode && current.Modetype == entry.Modetype {
+				current.Modeperms = entry.Modeperms
+			}
+			side[entry.Name] = &diffEntry{Mode: indexEntryMode(current), Sha: sha, Worktree: true}
+		}
+	}
+	return side, nil
+}
+
+func diffEntryData(repository *repo.Repository, name string, entry *diffEntry) ([]byte, error) {
+	if entry == nil || entry.Mode[:2] == "16" {
+		return []byte{}, nil
+	}
+
+	if entry.Worktree {
+		info, err := os.Lstat(worktreeAbsolute(repository, name))
+		if err != nil {
+			return nil, err
+		}
+		return worktreeRead(repository, name, info)
+	}
+
+	object, err := obj.ObjectRead(repository, entry.Sha)
+	if err != nil {
+		return nil, err
+	}
+	blob, ok := object.(*obj.Blob)
+	if !ok {
+		return nil, fmt.Errorf("object %s is not a blob", entry.Sha)
+	}
+	return blob.Data, nil
+}
+
+func diffChangedPaths(oldside diffSide, newside diffSide, paths []string) []string {
+	names := []string{}
+	seen := make(map[string]bool)
+	for _, side := range []diffSide{oldside, newside} {
+		for name := range side {
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+
+			matched := len(paths) == 0
+			for _, path := range paths {
+				matched = matched || pathWithin(name, path)
+			}
+			if !matched {
+				continue
+			}
+
+			oldentry, newentry := oldside[name], newside[name]
+			if oldentry != nil && newentry != nil && oldentry.Sha == newentry.Sha && oldentry.Mode == newentry.Mode {
+				continue
+			}
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func diffPrint(repository *repo.Repository, oldside diffSide, newside diffSide, paths []string, context int) error {
+	for _, name := range diffChangedPaths(oldside, newside, paths) {
+		err := diffPrintOne(repository, name, oldside[name], newside[name], context)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func diffPrintOne(repository *repo.Repository, name string, oldentry *diffEntry, newentry *diffEntry, context int) error {
+	fmt.Printf("diff --git a/%s b/%s\n", name, name)
+
+	nullsha := "0000000"
+	oldsha, newsha := nullsha, nullsha
+	if oldentry != nil {
+		oldsha = oldentry.Sha[:7]
+	}
+	if newentry != nil {
+		newsha = newentry.Sha[:7]
+	}
+
+	oldpath, newpath := "a/"+name, "b/"+name
+	switch {
+	case oldentry == nil:
+		fmt.Printf("new file mode %s\n", newentry.Mode)
+		fmt.Printf("index %s..%s\n", oldsha, newsha)
+		oldpath = "/dev/null"
+	case newentry == nil:
+		fmt.Printf("deleted file mode %s\n", oldentry.Mode)
+		fmt.Printf("index %s..%s\n", oldsha, newsha)
+		newpath = "/dev/null"
+	case oldentry.Mode != newentry.Mode:
+		fmt.Printf("old mode %s\n", oldentry.Mode)
+		fmt.Printf("new mode %s\n", newentry.Mode)
+		if oldentry.Sha != newentry.Sha {
+			fmt.Printf("index %s..%s\n", oldsha, newsha)
+		}
+	default:
+		fmt.Printf("index %s..%s %s\n", oldsha, newsha, oldentry.Mode)
+	}
+
+	if oldentry != nil && newentry != nil && oldentry.Sha == newentry.Sha {
+		return nil
+	}
+
+	olddata, err := diffEntryData(repository, name, oldentry)
+	if err != nil {
+		return err
+	}
+	newdata, err := diffEntryData(repository, name, newentry)
+	if err != nil {
+		return err
+	}
+
+	if diff.DiffIsBinary(olddata) || diff.DiffIsBinary(newdata) {
+		fmt.Printf("Binary files %s and %s differ\n", oldpath, newpath)
+		return nil
+	}
+
+	patch := diff.DiffUnified(olddata, newdata, context)
+	if patch == "" {
+		return nil
+	}
+	fmt.Printf("--- %s\n", oldpath)
+	fmt.Printf("+++ %s\n", newpath)
+	fmt.Print(patch)
+	return nil
+}
+
+func diffPrintNames(oldside diffSide, newside diffSide, paths []string) {
+	for _, name := range diffChangedPaths(oldside, newside, paths) {
+		fmt.Println(name)
+	}
+}
+
+type diffFileStat struct {
+	Name    string
+	Added   int
+	Deleted int
+	Binary  bool
+}
+
+// diffPrintStat prints the changed files with a graph of the lines added and
+// deleted, laid out the same way as git's --stat in 80 columns.
+func diffPrintStat(repository *repo.Repository, oldside diffSide, newside diffSide, paths []string) error {
+	stats := []diffFileStat{}
+	for _, name := range diffChangedPaths(oldside, newside, paths) {
+		olddata, err := diffEntryData(repository, name, oldside[name])
+		if err != nil {
+			return err
+		}
+		newdata, err := diffEntryData(repository, name, newside[name])
+		if err != nil {
+			return err
+		}
+
+		stat := diffFileStat{Name: name}
+		if diff.DiffIsBinary(olddata) || diff.DiffIsBinary(newdata) {
+			stat.Binary, stat.Added, stat.Deleted = true, len(newdata), len(olddata)
+		} else {
+			for _, edit := range diff.DiffLines(diff.DiffSplitLines(olddata), diff.DiffSplitLines(newdata)) {
+				switch edit.Type {
+				case diff.EditInsert:
+					stat.Added++
+				case diff.EditDelete:
+					stat.Deleted++
+				}
+			}
+		}
+		stats = append(stats, stat)
+	}
+
+	width, maxlen, maxchange, numberwidth, binwidth := 80, 0, 0, 0, 0
+	for _, stat := range stats {
+		maxlen = max(maxlen, len(stat.Name))
+		if stat.Binary {
+			binwidth = max(binwidth, 14+len(strconv.Itoa(stat.Added))+len(strconv.Itoa(stat.Deleted)))
+			numberwidth = 3
+			continue
+		}
+		maxchange = max(maxchange, stat.Added+stat.Deleted)
+	}
+	numberwidth = max(numberwidth, len(strconv.Itoa(maxchange)))
+
+	// The name gets at most 5/8 of the width and the graph at most what is
+	// left, but neither is wider than it needs to be.
+	graphwidth := maxchange
+	if maxchange+4 <= binwidth {
+		graphwidth = binwidth - 4
+	}
+	namewidth := maxlen
+	if namewidth+numberwidth+6+graphwidth > width {
+		if graphwidth > width*3/8-numberwidth-6 {
+			graphwidth = max(width*3/8-numberwidth-6, 6)
+		}
+		if namewidth > width-numberwidth-6-graphwidth {
+			namewidth = width - numberwidth - 6 - graphwidth
+		} else {
+			graphwidth = width - numberwidth - 6 - namewidth
+		}
+	}
+	scale := func(value int) int {
+		if value == 0 {
+			return 0
+		}
+		return 1 + value*(graphwidth-1)/maxchange
+	}
+
+	added, deleted := 0, 0
+	for _, stat := range stats {
+		name, prefix := stat.Name, ""
+		if len(name) > namewidth {
+			prefix = "..."
+			name = name[len(name)-max(namewidth-3, 0):]
+			if slash := strings.Index(name, "/"); slash >= 0 {
+				name = name[slash:]
+			}
+		}
+		fmt.Printf(" %s%-*s |", prefix, namewidth-len(prefix), name)
+
+		if stat.Binary {
+			fmt.Printf(" %*s", numberwidth, "Bin")
+			if stat.Added != 0 || stat.Deleted != 0 {
+				fmt.Printf(" %d -> %d bytes", stat.Deleted, stat.Added)
+			}
+			fmt.Println()
+			continue
+		}
+		added += stat.Added
+		deleted += stat.Deleted
+
+		plus, minus := stat.Added, stat.Deleted
+		if graphwidth <= maxchange {
+			total := scale(plus + minus)
+			if total < 2 && plus > 0 && minus > 0 {
+				total = 2
+			}
+			if plus < minus {
+				plus = scale(plus)
+				minus = total - plus
+			} else {
+				minus = scale(minus)
+				plus = total - minus
+			}
+		}
+		fmt.Printf(" %*d", numberwidth, stat.Added+stat.Deleted)
+		if stat.Added+stat.Deleted > 0 {
+			fmt.Print(" ")
+		}
+		fmt.Println(strings.Repeat("+", plus) + strings.Repeat("-", minus))
+	}
+
+	summary := fmt.Sprintf(" %d files changed", len(stats))
+	if len(stats) == 1 {
+		summary = " 1 file changed"
+	}
+	if added > 0 || deleted == 0 {
+		summary += fmt.Sprintf(", %d insertion%s(+)", added, diffPlural(added))
+	}
+	if deleted > 0 || added == 0 {
+		summary += fmt.Sprintf(", %d deletion%s(-)", deleted, diffPlural(deleted))
+	}
+	fmt.Println(summary)
+	return nil
+}
+
+func diffPlural(count int) string {
+	if count == 1 {
+		return ""
+	}
+	return "s"
+}
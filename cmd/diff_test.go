@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDiffArgs(t *testing.T) {
+	repository := testRepository(t)
+	testCommit(t, repository, "master", true, map[string]string{"a.txt": "a\n", "gone.txt": "g\n"}, "first")
+	testCheckoutMaster(t, repository)
+	err := os.Remove(filepath.Join(repository.Worktree, "gone.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(repository.Worktree, "new.txt"), []byte("new\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args      []string
+		dash      int
+		revisions string
+		paths     string
+	}{
+		{[]string{"a.txt"}, -1, "[]", "[a.txt]"},
+		{[]string{"master", "a.txt"}, -1, "[master]", "[a.txt]"},
+		{[]string{"HEAD", "master"}, -1, "[HEAD master]", "[]"},
+		{[]string{"gone.txt", "new.txt"}, -1, "[]", "[gone.txt new.txt]"},
+		{[]string{"master", "missing"}, 1, "[master]", "[missing]"},
+	}
+	for _, test := range tests {
+		revisions, paths, err := diffArgs(repository, test.args, test.dash)
+		if err != nil || fmt.Sprint(revisions) != test.revisions || fmt.Sprint(paths) != test.paths {
+			t.Errorf("diffArgs(%q) = %v, %v, %v, want %s, %s", test.args, revisions, paths, err, test.revisions, test.paths)
+		}
+	}
+
+	_, _, err = diffArgs(repository, []string{"a.txt", "missing"}, -1)
+	if err == nil || !strings.Contains(err.Error(), "ambiguous argument 'missing'") {
+		t.Errorf("an unknown argument returned %v", err)
+	}
+}
@@ -0,0 +1,249 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Jcho114/go-git/graph"
+	"github.com/Jcho114/go-git/obj"
+	"github.com/Jcho114/go-git/ref"
+	"github.com/Jcho114/go-git/repo"
+	"github.com/Jcho114/go-git/transport"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(fetchCmd)
+}
+
+var fetchCmd = &cobra.Command{
+	Use:   "fetch",
+	Short: "a very attempt at downloading objects and refs from another repository",
+	Long:  "a very very bad attempt at downloading objects and refs from another repository from scratch",
+	Args:  cobra.MatchAll(cobra.MaximumNArgs(1), cobra.OnlyValidArgs),
+	RunE:  runFetch,
+}
+
+// fetchUpdate is a local ref that a fetch moves to match a remote one.
+type fetchUpdate struct {
+	Remote string
+	Local  string
+	Old    string
+	New    string
+	Force  bool
+	Status string
+}
+
+const (
+	fetchNew         = "new"
+	fetchFastForward = "fast-forward"
+	fetchForced      = "forced"
+	fetchRejected    = "rejected"
+	fetchUpToDate    = "up to date"
+)
+
+func runFetch(cmd *cobra.Command, args []string) error {
+	repository, err := repo.FindRepository(".", true)
+	if err != nil {
+		return err
+	}
+
+	name := remoteDefault(repository)
+	if len(args) > 0 {
+		name = args[0]
+	}
+
+	updates, _, err := fetchRemote(repository, name, transport.NewProgressWriter(os.Stderr))
+	if err != nil {
+		return err
+	}
+	return fetchPrint(repository.Config.Remotes[name].URL, updates)
+}
+
+// remoteDefault names the remote the current branch tracks, or origin.
+func remoteDefault(repository *repo.Repository) string {
+	current, err := branchCurrent(repository)
+	if err != nil {
+		return "origin"
+	}
+	if config := repository.Config.Branches[current]; config != nil && config.Remote != "" && config.Remote != "." {
+		return config.Remote
+	}
+	return "origin"
+}
+
+// fetchRemote lists the refs of the named remote, downloads whatever they
+// point to that is missing here, and updates the local refs its refspecs map
+// them to. Tags that point into the fetched history are followed as well.
+// It returns the updates along with every ref the remote advertised.
+func fetchRemote(repository *repo.Repository, name string, progress io.Writer) ([]*fetchUpdate, []transport.Ref, error) {
+	remote := repository.Config.Remotes[name]
+	if remote == nil {
+		return nil, nil, fmt.Errorf("'%s' does not appear to be a git repository", name)
+	}
+	refspecs := []*ref.Refspec{}
+	prefixes := []string{"HEAD", "refs/tags/"}
+	for _, fetch := range remote.Fetch {
+		refspec, err := ref.RefspecParse(fetch)
+		if err != nil {
+			return nil, nil, err
+		}
+		refspecs = append(refspecs, refspec)
+		prefixes = append(prefixes, refspec.Prefix())
+	}
+
+	conn, err := transport.Open(remote.URL)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer conn.Close()
+	refs, err := conn.LsRefs(prefixes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	updates := []*fetchUpdate{}
+	tags := []*fetchUpdate{}
+	mapped := make(map[string]bool)
+	wants := []string{}
+	wanted := make(map[string]bool)
+	for _, remoteref := range refs {
+		for _, refspec := range refspecs {
+			local, ok := refspec.Match(remoteref.Name)
+			if !ok || local == "" {
+				continue
+			}
+			updates = append(updates, &fetchUpdate{Remote: remoteref.Name, Local: local, New: remoteref.Sha, Force: refspec.Force})
+			mapped[local] = true
+			if !wanted[remoteref.Sha] && !obj.ObjectExists(repository, remoteref.Sha) {
+				wants = append(wants, remoteref.Sha)
+				wanted[remoteref.Sha] = true
+			}
+			break
+		}
+	}
+	for _, remoteref := range refs {
+		if !strings.HasPrefix(remoteref.Name, "refs/tags/") || mapped[remoteref.Name] {
+			continue
+		}
+		if _, err := ref.RefResolve(repository, remoteref.Name); err == nil {
+			continue
+		}
+		tags = append(tags, &fetchUpdate{Remote: remoteref.Name, Local: remoteref.Name, New: remoteref.Sha})
+	}
+
+	if len(wants) > 0 {
+		err := conn.Fetch(repository, wants, progress)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, tag := range tags {
+		if obj.ObjectExists(repository, tag.New) {
+			updates = append(updates, tag)
+		}
+	}
+
+	for _, update := range updates {
+		err := fetchApply(repository, update)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return updates, refs, nil
+}
+
+// fetchApply moves a local ref to its new value unless that would lose
+// commits and the refspec does not allow forcing it.
+func fetchApply(repository *repo.Repository, update *fetchUpdate) error {
+	old, err := ref.RefResolve(repository, update.Local)
+	if err == nil {
+		update.Old = old
+	}
+
+	switch {
+	case update.Old == update.New:
+		update.Status = fetchUpToDate
+		return nil
+	case update.Old == "":
+		update.Status = fetchNew
+	case !strings.HasPrefix(update.Local, "refs/tags/") && fetchFastForwards(repository, update.Old, update.New):
+		update.Status = fetchFastForward
+	case update.Force:
+		update.Status = fetchForced
+	default:
+		update.Status = fetchRejected
+		return nil
+	}
+	return ref.RefWrite(repository, update.Local, update.New)
+}
+
+func fetchFastForwards(repository *repo.Repository, old string, new string) bool {
+	oldcommit, err := obj.ObjectFind(repository, old, "commit", true)
+	if err != nil {
+		return false
+	}
+	newcommit, err := obj.ObjectFind(repository, new, "commit", true)
+	if err != nil {
+		return false
+	}
+	ancestor, err := graph.IsAncestor(repository, oldcommit, newcommit)
+	return err == nil && ancestor
+}
+
+// fetchPrint reports the updated refs the way git does, one line each with
+// a flag, a summary of the change and the names on both sides.
+func fetchPrint(url string, updates []*fetchUpdate) error {
+	width := 0
+	for _, update := range updates {
+		if update.Status != fetchUpToDate {
+			width = max(width, len(fetchShortName(update.Remote)))
+		}
+	}
+	if width == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "From %s\n", url)
+	rejected := false
+	for _, update := range updates {
+		flag, summary, suffix := " ", "", ""
+		switch update.Status {
+		case fetchUpToDate:
+			continue
+		case fetchNew:
+			flag, summary = "*", "[new ref]"
+			if strings.HasPrefix(update.Remote, "refs/heads/") {
+				summary = "[new branch]"
+			} else if strings.HasPrefix(update.Remote, "refs/tags/") {
+				summary = "[new tag]"
+			}
+		case fetchFastForward:
+			summary = update.Old[:7] + ".." + update.New[:7]
+		case fetchForced:
+			flag, summary, suffix = "+", update.Old[:7]+"..."+update.New[:7], "  (forced update)"
+		case fetchRejected:
+			flag, summary, suffix = "!", "[rejected]", "  (non-fast-forward)"
+			if strings.HasPrefix(update.Local, "refs/tags/") {
+				suffix = "  (would clobber existing tag)"
+			}
+			rejected = true
+		}
+		fmt.Fprintf(os.Stderr, " %s %-17s %-*s -> %s%s\n", flag, summary, width, fetchShortName(update.Remote), fetchShortName(update.Local), suffix)
+	}
+	if rejected {
+		return fmt.Errorf("some local refs could not be updated")
+	}
+	return nil
+}
+
+func fetchShortName(name string) string {
+	for _, prefix := range []string{"refs/heads/", "refs/tags/", "refs/remotes/"} {
+		if short, ok := strings.CutPrefix(name, prefix); ok {
+			return short
+		}
+	}
+	return name
+}
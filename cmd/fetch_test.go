@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Jcho114/go-git/obj"
+	"github.com/Jcho114/go-git/ref"
+	"github.com/Jcho114/go-git/repo"
+	"github.com/Jcho114/go-git/transport"
+)
+
+func testRepository(t *testing.T) *repo.Repository {
+	t.Helper()
+	repository, err := initRepository(filepath.Join(t.TempDir(), "repo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repository
+}
+
+// testCommit commits the given files on top of a branch, or of nothing when
+// parent is false, and moves the branch to the new commit.
+func testCommit(t *testing.T, repository *repo.Repository, branch string, parent bool, files map[string]string, message string) string {
+	t.Helper()
+	tree := &obj.Tree{}
+	for name, content := range files {
+		sha, err := obj.ObjectWriteRaw(repository, "blob", []byte(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tree.Items = append(tree.Items, obj.NewTreeLeaf("100644", name, sha))
+	}
+	treesha, err := obj.ObjectWrite(repository, tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var content strings.Builder
+	fmt.Fprintf(&content, "tree %s\n", treesha)
+	if sha, err := ref.RefResolve(repository, "refs/heads/"+branch); err == nil && parent {
+		fmt.Fprintf(&content, "parent %s\n", sha)
+	}
+	content.WriteString("author Tester <t@example.com> 1700000000 +0000\n")
+	content.WriteString("committer Tester <t@example.com> 1700000000 +0000\n")
+	fmt.Fprintf(&content, "\n%s\n", message)
+	sha, err := obj.ObjectWriteRaw(repository, "commit", []byte(content.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ref.RefWrite(repository, "refs/heads/"+branch, sha)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sha
+}
+
+func testServer(t *testing.T, repository *repo.Repository) string {
+	t.Helper()
+	server := httptest.NewServer(transport.NewHandler(repository, nil))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func testRemote(t *testing.T, repository *repo.Repository, url string, fetch string) {
+	t.Helper()
+	repository.Config.Remotes["origin"] = &repo.RemoteConfig{URL: url, Fetch: []string{fetch}}
+	err := repository.Config.Write(filepath.Join(repository.Gitdir, "config"))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testStatuses(updates []*fetchUpdate) string {
+	res := []string{}
+	for _, update := range updates {
+		res = append(res, update.Local+" "+update.Status)
+	}
+	return strings.Join(res, ", ")
+}
+
+func TestFetchRemoteTracking(t *testing.T) {
+	server := testRepository(t)
+	main := testCommit(t, server, "master", true, map[string]string{"a.txt": "a\n"}, "first")
+	topic := testCommit(t, server, "topic", true, map[string]string{"b.txt": "b\n"}, "topic")
+	tag := fmt.Sprintf("object %s\ntype commit\ntag v1\ntagger Tester <t@example.com> 1700000000 +0000\n\nv1\n", main)
+	tagsha, err := obj.ObjectWriteRaw(server, "tag", []byte(tag))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ref.RefWrite(server, "refs/tags/v1", tagsha)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := testRepository(t)
+	testRemote(t, client, testServer(t, server), "+refs/heads/*:refs/remotes/origin/*")
+
+	updates, _, err := fetchRemote(client, "origin", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "refs/remotes/origin/master new, refs/remotes/origin/topic new, refs/tags/v1 new"
+	if got := testStatuses(updates); got != want {
+		t.Errorf("first fetch made %s, want %s", got, want)
+	}
+	for local, sha := range map[string]string{"refs/remotes/origin/master": main, "refs/remotes/origin/topic": topic, "refs/tags/v1": tagsha} {
+		if got, _ := ref.RefResolve(client, local); got != sha {
+			t.Errorf("%s is at %s, want %s", local, got, sha)
+		}
+	}
+
+	advanced := testCommit(t, server, "master", true, map[string]string{"a.txt": "a\n", "c.txt": "c\n"}, "second")
+	rewritten := testCommit(t, server, "topic", false, map[string]string{"d.txt": "d\n"}, "rewritten")
+	updates, _, err = fetchRemote(client, "origin", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "refs/remotes/origin/master fast-forward, refs/remotes/origin/topic forced"
+	if got := testStatuses(updates); got != want {
+		t.Errorf("second fetch made %s, want %s", got, want)
+	}
+	for local, sha := range map[string]string{"refs/remotes/origin/master": advanced, "refs/remotes/origin/topic": rewritten} {
+		if got, _ := ref.RefResolve(client, local); got != sha {
+			t.Errorf("%s is at %s, want %s", local, got, sha)
+		}
+	}
+}
+
+func TestFetchRejectsNonFastForward(t *testing.T) {
+	server := testRepository(t)
+	testCommit(t, server, "master", true, map[string]string{"a.txt": "a\n"}, "first")
+
+	client := testRepository(t)
+	testRemote(t, client, testServer(t, server), "refs/heads/*:refs/remotes/origin/*")
+	_, _, err := fetchRemote(client, "origin", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old, _ := ref.RefResolve(client, "refs/remotes/origin/master")
+
+	testCommit(t, server, "master", false, map[string]string{"b.txt": "b\n"}, "unrelated")
+	updates, _, err := fetchRemote(client, "origin", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := testStatuses(updates); got != "refs/remotes/origin/master rejected" {
+		t.Errorf("fetch made %s, want a rejected update", got)
+	}
+	if got, _ := ref.RefResolve(client, "refs/remotes/origin/master"); got != old {
+		t.Errorf("a rejected update moved the ref to %s", got)
+	}
+}
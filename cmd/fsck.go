@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/Jcho114/go-git/obj"
+	"github.com/Jcho114/go-git/repo"
+	"github.com/spf13/cobra"
+)
+
+var fscknodangling bool
+
+func init() {
+	fsckCmd.Flags().BoolVar(&fscknodangling, "no-dangling", false, "do not report dangling objects")
+	rootCmd.AddCommand(fsckCmd)
+}
+
+var fsckCmd = &cobra.Command{
+	Use:   "fsck",
+	Short: "a very attempt at verifying the object database",
+	Long:  "a very very bad attempt at verifying the connectivity and validity of objects from scratch",
+	Args:  cobra.NoArgs,
+	RunE:  runFsck,
+}
+
+func runFsck(cmd *cobra.Command, args []string) error {
+	repository, err := repo.FindRepository(".", true)
+	if err != nil {
+		return err
+	}
+
+	problems := 0
+	report := func(format string, args ...any) {
+		fmt.Fprintf(os.Stderr, "error: "+format+"\n", args...)
+		problems++
+	}
+
+	shas := make(map[string]bool)
+	loose, err := obj.ObjectLooseList(repository)
+	if err != nil {
+		return err
+	}
+	for _, sha := range loose {
+		shas[sha] = true
+	}
+
+	packs, err := obj.PackList(repository)
+	if err != nil {
+		return err
+	}
+	corrupt := make(map[string]bool)
+	for _, packpath := range packs {
+		err := obj.PackVerify(packpath)
+		if err != nil {
+			report("%v", err)
+		}
+		// A pack that fails its checksum is still read object by object,
+		// so only the objects that are actually damaged are reported.
+		damaged, err := obj.PackCheck(repository, packpath)
+		if err != nil {
+			report("%v", err)
+			continue
+		}
+		packed, err := obj.PackObjects(packpath)
+		if err != nil {
+			report("%v", err)
+			continue
+		}
+		for _, sha := range packed {
+			if reason, ok := damaged[sha]; ok {
+				report("corrupt object %s in %s: %v", sha, packpath, reason)
+				corrupt[sha] = true
+				continue
+			}
+			shas[sha] = true
+		}
+	}
+
+	names := []string{}
+	for sha := range shas {
+		names = append(names, sha)
+	}
+	sort.Strings(names)
+
+	formats := make(map[string]string)
+	links := make(map[string][]obj.WalkEntry)
+	referenced := make(map[string]bool)
+	for _, sha := range names {
+		format, data, err := obj.ObjectReadRaw(repository, sha)
+		if err != nil {
+			report("unable to read object %s: %v", sha, err)
+			continue
+		}
+
+		header := format + " " + strconv.Itoa(len(data)) + "\x00"
+		hash := sha1.New()
+		hash.Write([]byte(header))
+		hash.Write(data)
+		if actual := hex.EncodeToString(hash.Sum(nil)); actual != sha {
+			report("hash mismatch for %s (content hashes to %s)", sha, actual)
+			continue
+		}
+
+		formats[sha] = format
+		err = obj.ObjectCheck(format, data)
+		if err != nil {
+			report("in %s %s: %v", format, sha, err)
+			continue
+		}
+
+		objectlinks, err := obj.ObjectLinks(format, data)
+		if err != nil {
+			report("in %s %s: %v", format, sha, err)
+			continue
+		}
+		links[sha] = objectlinks
+		for _, link := range objectlinks {
+			referenced[link.Sha] = true
+		}
+	}
+
+	expected := make(map[string]string)
+	for _, sha := range names {
+		for _, link := range links[sha] {
+			expected[link.Sha] = link.Format
+			format, ok := formats[link.Sha]
+			if !ok && corrupt[link.Sha] {
+				continue
+			}
+			if !ok {
+				fmt.Printf("broken link from %7s %s\n              to %7s %s\n", formats[sha], sha, link.Format, link.Sha)
+				problems++
+				continue
+			}
+			if link.Format != "" && format != link.Format {
+				report("object %s is a %s, not a %s (referenced by %s)", link.Sha, format, link.Format, sha)
+			}
+		}
+	}
+
+	roots, err := reachableRoots(repository)
+	if err != nil {
+		return err
+	}
+	reachable := make(map[string]bool)
+	queue := []obj.WalkEntry{}
+	for _, root := range roots {
+		queue = append(queue, obj.WalkEntry{Sha: root})
+	}
+	for len(queue) > 0 {
+		entry := queue[0]
+		queue = queue[1:]
+		if reachable[entry.Sha] {
+			continue
+		}
+		reachable[entry.Sha] = true
+
+		if _, ok := formats[entry.Sha]; !ok {
+			if !shas[entry.Sha] && !corrupt[entry.Sha] {
+				format := expected[entry.Sha]
+				if format == "" {
+					format = "object"
+				}
+				fmt.Printf("missing %s %s\n", format, entry.Sha)
+				problems++
+			}
+			continue
+		}
+		queue = append(queue, links[entry.Sha]...)
+	}
+
+	if !fscknodangling {
+		for _, sha := range names {
+			format, ok := formats[sha]
+			if ok && !reachable[sha] && !referenced[sha] {
+				fmt.Printf("dangling %s %s\n", format, sha)
+			}
+		}
+	}
+
+	if problems > 0 {
+		return fmt.Errorf("fsck found %d problems", problems)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Jcho114/go-git/obj"
+	"github.com/Jcho114/go-git/repo"
+	"github.com/spf13/cobra"
+)
+
+var gcprune string
+
+func init() {
+	gcCmd.Flags().StringVar(&gcprune, "prune", "336h", "prune unreachable loose objects older than this duration, or \"now\" or \"never\"")
+	rootCmd.AddCommand(gcCmd)
+}
+
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "a very attempt at cleaning up the object store",
+	Long:  "a very very bad attempt at packing reachable objects and pruning unreachable ones from scratch",
+	Args:  cobra.NoArgs,
+	RunE:  runGc,
+}
+
+func runGc(cmd *cobra.Command, args []string) error {
+	repository, err := repo.FindRepository(".", true)
+	if err != nil {
+		return err
+	}
+
+	var grace time.Duration
+	switch gcprune {
+	case "now":
+		grace = 0
+	case "never":
+		grace = -1
+	default:
+		grace, err = time.ParseDuration(gcprune)
+		if err != nil || grace < 0 {
+			return fmt.Errorf("invalid prune duration '%s'", gcprune)
+		}
+	}
+
+	reachable, err := repackRun(repository, true)
+	if err != nil {
+		return err
+	}
+	if grace < 0 {
+		return nil
+	}
+
+	pruned, err := gcPrune(repository, reachable, time.Now().Add(-grace))
+	if err != nil {
+		return err
+	}
+	if pruned > 0 {
+		fmt.Printf("Pruned %d unreachable objects\n", pruned)
+	}
+	repackRemoveEmptyDirs(repository)
+	return nil
+}
+
+func gcPrune(repository *repo.Repository, reachable map[string]bool, expire time.Time) (int, error) {
+	loose, err := obj.ObjectLooseList(repository)
+	if err != nil {
+		return 0, err
+	}
+
+	pruned := 0
+	for _, sha := range loose {
+		if reachable[sha] {
+			continue
+		}
+		objectpath := filepath.Join(repository.Gitdir, "objects", sha[:2], sha[2:])
+		info, err := os.Stat(objectpath)
+		if err != nil {
+			return 0, err
+		}
+		if info.ModTime().After(expire) {
+			continue
+		}
+		err = os.Remove(objectpath)
+		if err != nil {
+			return 0, err
+		}
+		pruned++
+	}
+	return pruned, nil
+}
@@ -23,10 +23,14 @@ var initCmd = &cobra.Command{
 }
 
 func runInit(cmd *cobra.Command, args []string) error {
-	path := args[0]
+	_, err := initRepository(args[0])
+	return err
+}
+
+func initRepository(path string) (*repo.Repository, error) {
 	repository, err := repo.NewRepository(path, true)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	info, err := os.Stat(repository.Worktree)
@@ -36,55 +40,55 @@ func runInit(cmd *cobra.Command, args []string) error {
 	if !pathexists {
 		err := os.Mkdir(repository.Worktree, 0755)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	} else if err != nil && !info.IsDir() {
-		return fmt.Errorf("%s is not a directory", path)
+		return nil, fmt.Errorf("%s is not a directory", path)
 	} else if gitexists {
-		return fmt.Errorf("%s is not empty", path)
+		return nil, fmt.Errorf("%s is not empty", path)
 	}
 
 	objectsdir := filepath.Join(repository.Gitdir, "objects")
 	err = os.MkdirAll(objectsdir, 0755)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	branchesdir := filepath.Join(repository.Gitdir, "branches")
 	err = os.MkdirAll(branchesdir, 0755)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	tagrefsdir := filepath.Join(repository.Gitdir, "refs", "tags")
 	err = os.MkdirAll(tagrefsdir, 0755)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	headrefsdir := filepath.Join(repository.Gitdir, "refs", "heads")
 	err = os.MkdirAll(headrefsdir, 0755)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	descfilepath := filepath.Join(repository.Gitdir, "description")
 	err = os.WriteFile(descfilepath, []byte("Unnamed repository; edit this file 'description' to name the repository.\n"), 0644)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	headfilepath := filepath.Join(repository.Gitdir, "HEAD")
 	err = os.WriteFile(headfilepath, []byte("ref: refs/heads/master\n"), 0644)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	configfilepath := filepath.Join(repository.Gitdir, "config")
 	err = repository.Config.Write(configfilepath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return repository, nil
 }
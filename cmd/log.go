@@ -1,15 +1,52 @@
 package cmd
 
 import (
+	"bufio"
 	"fmt"
+	"os"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/Jcho114/go-git/graph"
 	"github.com/Jcho114/go-git/obj"
 	"github.com/Jcho114/go-git/repo"
 	"github.com/spf13/cobra"
 )
 
+var (
+	logoneline   bool
+	logformat    string
+	logmaxcount  int
+	logauthors   []string
+	loggreps     []string
+	logsince     string
+	loguntil     string
+	loggraph     bool
+	logtopoorder bool
+	logall       bool
+	logdot       bool
+	logleftright bool
+	lognot       revisionNot
+)
+
 func init() {
+	logCmd.Flags().BoolVar(&logoneline, "oneline", false, "show each commit on a single line")
+	logCmd.Flags().StringVar(&logformat, "format", "", "pretty-print the commits in the given format")
+	logCmd.Flags().StringVar(&logformat, "pretty", "", "pretty-print the commits in the given format")
+	logCmd.Flags().IntVarP(&logmaxcount, "max-count", "n", -1, "limit the number of commits to output")
+	logCmd.Flags().StringArrayVar(&logauthors, "author", []string{}, "limit the commits to those with an author matching the pattern")
+	logCmd.Flags().StringArrayVar(&loggreps, "grep", []string{}, "limit the commits to those with a message matching the pattern")
+	logCmd.Flags().StringVar(&logsince, "since", "", "show commits more recent than a specific date")
+	logCmd.Flags().StringVar(&loguntil, "until", "", "show commits older than a specific date")
+	logCmd.Flags().BoolVar(&loggraph, "graph", false, "draw a text-based graphical representation of the commit history")
+	logCmd.Flags().BoolVar(&logtopoorder, "topo-order", false, "show no parents before all of their children are shown")
+	logCmd.Flags().BoolVar(&logall, "all", false, "pretend as if all the refs and HEAD are listed on the command line")
+	logCmd.Flags().BoolVar(&logleftright, "left-right", false, "mark which side of a symmetric difference a commit is reachable from")
+	lognot.flags = logCmd.Flags()
+	logCmd.Flags().VarPF(&lognot, "not", "", "reverse the meaning of the ^ prefix for all following revisions").NoOptDefVal = "true"
+	logCmd.Flags().BoolVar(&logdot, "dot", false, "output the history as a graphviz digraph")
 	rootCmd.AddCommand(logCmd)
 }
 
@@ -17,40 +54,279 @@ var logCmd = &cobra.Command{
 	Use:   "log",
 	Short: "a very attempt at displaying commit history",
 	Long:  "a very very bad attempt at displaying commit history from scratch",
-	Args:  cobra.RangeArgs(0, 1),
+	Args:  cobra.MatchAll(cobra.ArbitraryArgs, cobra.OnlyValidArgs),
 	RunE:  runLog,
 }
 
 func runLog(cmd *cobra.Command, args []string) error {
-	var commit string
-	if len(args) == 0 {
-		commit = "HEAD"
-	} else {
-		commit = args[0]
+	repository, err := repo.FindRepository(".", true)
+	if err != nil {
+		return err
 	}
 
-	repository, err := repo.FindRepository(".", true)
+	revisions, paths, err := revisionArgs(repository, args, cmd.ArgsLenAtDash(), lognot.positions)
+	if err != nil {
+		return err
+	}
+	if logall {
+		refs, err := revisionAll(repository)
+		if err != nil {
+			return err
+		}
+		revisions.Include = append(revisions.Include, refs...)
+	}
+
+	if logdot {
+		hidden, err := graph.NewGraph(repository).Range(revisions.Exclude, nil)
+		if err != nil {
+			return err
+		}
+		excluded := make(map[string]bool)
+		for _, sha := range hidden {
+			excluded[sha] = true
+		}
+		seen := make(map[string]bool)
+		fmt.Println("digraph log{")
+		fmt.Println("  node[shape=rect]")
+		for _, objname := range revisions.Include {
+			err = outputGraphViz(repository, objname, seen, excluded)
+			if err != nil {
+				return err
+			}
+		}
+		fmt.Println("}")
+		return nil
+	}
+
+	name := logformat
+	if logoneline {
+		name = "oneline"
+	}
+	format, err := prettyParseFormat(name)
 	if err != nil {
 		return err
 	}
 
-	objname, err := obj.ObjectFind(repository, commit, "commit", true)
+	filter, err := logFilter(repository, paths)
 	if err != nil {
 		return err
 	}
-	seen := make(map[string]bool)
-	fmt.Println("digraph log{")
-	fmt.Println("  node[shape=rect]")
-	err = outputGraphViz(repository, objname, seen)
+
+	g := graph.NewGraph(repository)
+	order, parents, err := revisions.Walk(g, filter, loggraph || logtopoorder, logmaxcount)
 	if err != nil {
 		return err
 	}
-	fmt.Println("}")
+	if logmaxcount >= 0 && len(order) > logmaxcount {
+		order = order[:logmaxcount]
+	}
+
+	var marks map[string]string
+	if logleftright {
+		marks, err = revisions.Sides(g, order)
+		if err != nil {
+			return err
+		}
+	}
+
+	var renderer *graph.Renderer
+	if loggraph {
+		renderer = graph.NewRenderer()
+	}
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	return logOutput(repository, out, order, parents, marks, format, renderer)
+}
+
+// logFilter builds the history simplification for the walk. On top of the
+// path limiting, the author, message and date filters hide commits without
+// changing the walk.
+func logFilter(repository *repo.Repository, paths []string) (graph.Simplify, error) {
+	authors, err := logPatterns(logauthors)
+	if err != nil {
+		return nil, err
+	}
+	greps, err := logPatterns(loggreps)
+	if err != nil {
+		return nil, err
+	}
+	since, until := int64(0), int64(-1)
+	if logsince != "" {
+		since, err = logParseDate(logsince)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if loguntil != "" {
+		until, err = logParseDate(loguntil)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	simplify := revisionSimplify(repository, paths)
+	return func(sha string, parents []string) ([]string, bool, error) {
+		parents, show, err := simplify(sha, parents)
+		if err != nil || !show {
+			return parents, show, err
+		}
+
+		if len(authors) == 0 && len(greps) == 0 && logsince == "" && loguntil == "" {
+			return parents, true, nil
+		}
+		commit, err := prettyRead(repository, sha)
+		if err != nil {
+			return nil, false, err
+		}
+		date := graph.GraphIdentDate(commit.Committer)
+		show = date >= since && (until < 0 || date <= until)
+		show = show && logMatches(authors, commit.Author)
+		show = show && logMatches(greps, commit.Message)
+		return parents, show, nil
+	}, nil
+}
+
+func logPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	res := []*regexp.Regexp{}
+	for _, pattern := range patterns {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s': %w", pattern, err)
+		}
+		res = append(res, compiled)
+	}
+	return res, nil
+}
+
+func logMatches(patterns []*regexp.Regexp, value string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if pattern.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+var logRelativeDate = regexp.MustCompile(`^(\d+)[. ](second|minute|hour|day|week|month|year)s?[. ]ago$`)
+
+// logParseDate accepts absolute dates in the common ISO forms, unix
+// timestamps prefixed with "@", and relative dates such as "2 weeks ago".
+func logParseDate(value string) (int64, error) {
+	if seconds, ok := strings.CutPrefix(value, "@"); ok {
+		return strconv.ParseInt(seconds, 10, 64)
+	}
+
+	if match := logRelativeDate.FindStringSubmatch(value); match != nil {
+		count, _ := strconv.Atoi(match[1])
+		units := map[string]time.Duration{
+			"second": time.Second,
+			"minute": time.Minute,
+			"hour":   time.Hour,
+			"day":    24 * time.Hour,
+			"week":   7 * 24 * time.Hour,
+			"month":  30 * 24 * time.Hour,
+			"year":   365 * 24 * time.Hour,
+		}
+		return time.Now().Add(-time.Duration(count) * units[match[2]]).Unix(), nil
+	}
+
+	layouts := []string{time.RFC3339, "2006-01-02 15:04:05 -0700", "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"}
+	for _, layout := range layouts {
+		when, err := time.ParseInLocation(layout, value, time.Local)
+		if err == nil {
+			return when.Unix(), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid date '%s'", value)
+}
+
+// logOutput writes the commits the way git's log does, interleaving the
+// graph with every line of text when a renderer is given. Marks, when given,
+// show the side of a symmetric difference each commit is on.
+func logOutput(repository *repo.Repository, out *bufio.Writer, order []string, parents map[string][]string, marks map[string]string, format *prettyFormat, renderer *graph.Renderer) error {
+	graphline := func() {
+		if renderer != nil {
+			line, _ := renderer.NextLine()
+			out.WriteString(line)
+		}
+	}
+	graphpadding := func() {
+		if renderer != nil {
+			out.WriteString(renderer.PaddingLine())
+		}
+	}
+
+	shown, missingnewline := false, false
+	for _, sha := range order {
+		commit, err := prettyRead(repository, sha)
+		if err != nil {
+			return err
+		}
+		commitparents := commit.Parents
+		if renderer != nil {
+			commitparents = parents[sha]
+			renderer.Update(sha, commitparents, marks[sha])
+		}
+
+		if shown && !format.Terminator {
+			if !missingnewline {
+				graphpadding()
+			}
+			out.WriteString("\n")
+		}
+		shown = true
+
+		if renderer != nil {
+			out.WriteString(renderer.ShowCommit())
+		}
+		mark := ""
+		if renderer == nil && marks != nil {
+			mark = marks[sha] + " "
+		}
+		switch format.Kind {
+		case "oneline":
+			out.WriteString(mark + sha[:7] + " ")
+		case "user":
+		default:
+			out.WriteString("commit " + mark + sha + "\n")
+			graphline()
+		}
+
+		message := prettyMessage(commit, format, commitparents)
+		missingnewline = !strings.HasSuffix(message, "\n")
+
+		lines := strings.SplitAfter(message, "\n")
+		for i, line := range lines {
+			out.WriteString(line)
+			if i < len(lines)-1 && lines[i+1] != "" {
+				graphline()
+			}
+		}
+		if renderer != nil && !renderer.Finished() {
+			if missingnewline {
+				out.WriteString("\n")
+			}
+			out.WriteString(renderer.ShowRemainder())
+			if !missingnewline {
+				out.WriteString("\n")
+			}
+		}
+
+		if format.Terminator && (format.Kind != "user" || format.Template != "") {
+			if !missingnewline {
+				graphpadding()
+			}
+			out.WriteString("\n")
+		}
+	}
 	return nil
 }
 
-func outputGraphViz(repository *repo.Repository, objname string, seen map[string]bool) error {
-	if _, ok := seen[objname]; ok {
+func outputGraphViz(repository *repo.Repository, objname string, seen map[string]bool, excluded map[string]bool) error {
+	if _, ok := seen[objname]; ok || excluded[objname] {
 		return nil
 	}
 	seen[objname] = true
@@ -84,8 +360,11 @@ func outputGraphViz(repository *repo.Repository, objname string, seen map[string
 	}
 
 	for _, parent := range parents {
+		if excluded[parent] {
+			continue
+		}
 		fmt.Printf("  c_%s -> c_%s\n", objname, parent)
-		err := outputGraphViz(repository, parent, seen)
+		err := outputGraphViz(repository, parent, seen, excluded)
 		if err != nil {
 			return err
 		}
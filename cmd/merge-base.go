@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Jcho114/go-git/graph"
+	"github.com/Jcho114/go-git/obj"
+	"github.com/Jcho114/go-git/repo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mergebaseall        bool
+	mergebaseoctopus    bool
+	mergebaseisancestor bool
+)
+
+func init() {
+	mergeBaseCmd.Flags().BoolVarP(&mergebaseall, "all", "a", false, "output all merge bases instead of just one")
+	mergeBaseCmd.Flags().BoolVar(&mergebaseoctopus, "octopus", false, "compute the best common ancestors of all supplied commits")
+	mergeBaseCmd.Flags().BoolVar(&mergebaseisancestor, "is-ancestor", false, "exit with status 0 if the first commit is an ancestor of the second, 1 otherwise")
+	rootCmd.AddCommand(mergeBaseCmd)
+}
+
+var mergeBaseCmd = &cobra.Command{
+	Use:   "merge-base",
+	Short: "a very attempt at finding good common ancestors for a merge",
+	Long:  "a very very bad attempt at finding as good common ancestors as possible for a merge from scratch",
+	Args:  cobra.MatchAll(cobra.MinimumNArgs(1), cobra.OnlyValidArgs),
+	RunE:  runMergeBase,
+}
+
+func runMergeBase(cmd *cobra.Command, args []string) error {
+	repository, err := repo.FindRepository(".", true)
+	if err != nil {
+		return err
+	}
+
+	shas := []string{}
+	for _, arg := range args {
+		sha, err := obj.ObjectFind(repository, arg, "commit", true)
+		if err != nil {
+			return fmt.Errorf("not a valid commit name %s", arg)
+		}
+		shas = append(shas, sha)
+	}
+
+	g := graph.NewGraph(repository)
+
+	if mergebaseisancestor {
+		if len(shas) != 2 {
+			return fmt.Errorf("--is-ancestor takes exactly two commits")
+		}
+		isancestor, err := g.IsAncestor(shas[0], shas[1])
+		if err != nil {
+			return err
+		}
+		if !isancestor {
+			os.Exit(1)
+		}
+		return nil
+	}
+
+	var bases []string
+	if mergebaseoctopus {
+		bases, err = g.MergeBaseOctopus(shas)
+	} else {
+		if len(shas) < 2 {
+			return fmt.Errorf("merge-base needs at least two commits")
+		}
+		bases, err = g.MergeBaseMany(shas[0], shas[1:])
+	}
+	if err != nil {
+		return err
+	}
+
+	// Like git, having no common ancestor is reported only through the exit
+	// status.
+	if len(bases) == 0 {
+		os.Exit(1)
+	}
+	if !mergebaseall {
+		bases = bases[:1]
+	}
+	for _, base := range bases {
+		fmt.Println(base)
+	}
+	return nil
+}
@@ -0,0 +1,526 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Jcho114/go-git/diff"
+	"github.com/Jcho114/go-git/graph"
+	"github.com/Jcho114/go-git/index"
+	"github.com/Jcho114/go-git/obj"
+	"github.com/Jcho114/go-git/ref"
+	"github.com/Jcho114/go-git/repo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mergemessages  []string
+	mergenoff      bool
+	mergeffonly    bool
+	mergeabort     bool
+	mergeunrelated bool
+)
+
+func init() {
+	mergeCmd.Flags().StringArrayVarP(&mergemessages, "message", "m", []string{}, "use the given message for the merge commit")
+	mergeCmd.Flags().BoolVar(&mergenoff, "no-ff", false, "create a merge commit even when a fast-forward is possible")
+	mergeCmd.Flags().BoolVar(&mergeffonly, "ff-only", false, "refuse to merge unless a fast-forward is possible")
+	mergeCmd.Flags().BoolVar(&mergeabort, "abort", false, "abort the current conflict resolution and restore HEAD")
+	mergeCmd.Flags().BoolVar(&mergeunrelated, "allow-unrelated-histories", false, "allow merging histories that do not share a common ancestor")
+	rootCmd.AddCommand(mergeCmd)
+}
+
+var mergeCmd = &cobra.Command{
+	Use:   "merge",
+	Short: "a very attempt at joining two development histories together",
+	Long:  "a very very bad attempt at joining two development histories together from scratch",
+	Args:  cobra.MatchAll(cobra.MaximumNArgs(1), cobra.OnlyValidArgs),
+	RunE:  runMerge,
+}
+
+type mergeEntry struct {
+	Leaf     *obj.TreeLeaf
+	Stages   [3]*obj.TreeLeaf
+	Conflict string
+}
+
+func runMerge(cmd *cobra.Command, args []string) error {
+	repository, err := repo.FindRepository(".", true)
+	if err != nil {
+		return err
+	}
+
+	mergeheadpath := filepath.Join(repository.Gitdir, "MERGE_HEAD")
+	_, err = os.Stat(mergeheadpath)
+	inprogress := err == nil
+
+	if mergeabort {
+		if !inprogress {
+			return fmt.Errorf("there is no merge to abort (MERGE_HEAD missing)")
+		}
+		head, err := ref.RefResolve(repository, "HEAD")
+		if err != nil {
+			return err
+		}
+		err = checkoutSwitch(repository, head, head, "merge", true)
+		if err != nil {
+			return err
+		}
+		return mergeStateClear(repository)
+	}
+
+	if len(args) == 0 {
+		return fmt.Errorf("no commit specified to merge")
+	}
+	if inprogress {
+		return fmt.Errorf("you have not concluded your merge (MERGE_HEAD exists)")
+	}
+
+	name := args[0]
+	theirs, err := obj.ObjectFind(repository, name, "commit", true)
+	if err != nil {
+		return fmt.Errorf("%s - not something we can merge", name)
+	}
+
+	head, err := ref.RefResolve(repository, "HEAD")
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if head == "" {
+		err := checkoutSwitch(repository, "", theirs, "merge", false)
+		if err != nil {
+			return err
+		}
+		return ref.RefUpdate(repository, "HEAD", theirs)
+	}
+
+	bases, err := graph.MergeBaseAll(repository, head, theirs)
+	if err != nil {
+		return err
+	}
+	if len(bases) == 0 && !mergeunrelated {
+		return fmt.Errorf("refusing to merge unrelated histories")
+	}
+	if slices.Contains(bases, theirs) {
+		fmt.Println("Already up to date.")
+		return nil
+	}
+
+	if slices.Contains(bases, head) && !mergenoff {
+		fmt.Printf("Updating %s..%s\n", head[:7], theirs[:7])
+		err := checkoutSwitch(repository, head, theirs, "merge", false)
+		if err != nil {
+			return err
+		}
+		err = ref.RefWrite(repository, "ORIG_HEAD", head)
+		if err != nil {
+			return err
+		}
+		fmt.Println("Fast-forward")
+		return ref.RefUpdate(repository, "HEAD", theirs)
+	}
+	if mergeffonly {
+		return fmt.Errorf("not possible to fast-forward, aborting")
+	}
+
+	ourtree, err := commitTreeEntries(repository, head)
+	if err != nil {
+		return err
+	}
+	err = mergeCheckIndex(repository, ourtree)
+	if err != nil {
+		return err
+	}
+
+	message := commitMessage(mergemessages)
+	if message == "" {
+		message, err = mergeDefaultMessage(repository, name)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = ref.RefWrite(repository, "ORIG_HEAD", head)
+	if err != nil {
+		return err
+	}
+
+	results, err := mergeCommits(repository, head, theirs, bases, "HEAD", name)
+	if err != nil {
+		return err
+	}
+	conflicts, err := mergeApplyResults(repository, ourtree, results)
+	if err != nil {
+		return err
+	}
+
+	if len(conflicts) > 0 {
+		message += "\n# Conflicts:\n"
+		for _, conflict := range conflicts {
+			message += "#\t" + conflict + "\n"
+		}
+		err := os.WriteFile(mergeheadpath, []byte(theirs+"\n"), 0644)
+		if err != nil {
+			return err
+		}
+		err = os.WriteFile(filepath.Join(repository.Gitdir, "MERGE_MSG"), []byte(message), 0644)
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("automatic merge failed; fix conflicts and then commit the result")
+	}
+
+	ind, err := index.IndexRead(repository)
+	if err != nil {
+		return err
+	}
+	tree, err := treeFromIndex(repository, ind)
+	if err != nil {
+		return err
+	}
+	commit, err := commitCreate(repository, tree, []string{head, theirs}, message, "")
+	if err != nil {
+		return err
+	}
+	err = ref.RefUpdate(repository, "HEAD", commit)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("Merge made by the 'recursive' strategy.")
+	return nil
+}
+
+func mergeStateClear(repository *repo.Repository) error {
+	for _, name := range []string{"MERGE_HEAD", "MERGE_MSG", "MERGE_MODE"} {
+		err := os.Remove(filepath.Join(repository.Gitdir, name))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func mergeDefaultMessage(repository *repo.Repository, name string) (string, error) {
+	message := fmt.Sprintf("Merge commit '%s'", name)
+	if _, err := ref.RefResolve(repository, "refs/heads/"+name); err == nil {
+		message = fmt.Sprintf("Merge branch '%s'", name)
+	}
+
+	current, err := branchCurrent(repository)
+	if err != nil {
+		return "", err
+	}
+	if current != "" && current != "master" && current != "main" {
+		message += " into " + current
+	}
+	return message + "\n", nil
+}
+
+// Merging rewrites the index from scratch, so staged work that is not in HEAD
+// would be lost.
+func mergeCheckIndex(repository *repo.Repository, headtree map[string]*obj.TreeLeaf) error {
+	ind, err := index.IndexRead(repository)
+	if err != nil {
+		return err
+	}
+
+	staged := 0
+	for _, entry := range ind.Entries {
+		if entry.Flagstage != 0 {
+			return fmt.Errorf("you need to resolve your current index first")
+		}
+		if !leafMatchesEntry(headtree[entry.Name], entry) {
+			return fmt.Errorf("your local changes to %s would be overwritten by merge, commit them first", entry.Name)
+		}
+		staged++
+	}
+	if staged != len(headtree) {
+		return fmt.Errorf("your index does not match HEAD, commit your changes before you merge")
+	}
+	return nil
+}
+
+// mergeCommits merges theirs into ours. When there is more than one merge
+// base they are first merged with each other into a virtual base commit, the
+// same way the recursive strategy does.
+func mergeCommits(repository *repo.Repository, ours string, theirs string, bases []string, ourlabel string, theirlabel string) (map[string]*mergeEntry, error) {
+	basetree, err := mergeVirtualBase(repository, bases)
+	if err != nil {
+		return nil, err
+	}
+	ourtree, err := commitTreeEntries(repository, ours)
+	if err != nil {
+		return nil, err
+	}
+	theirtree, err := commitTreeEntries(repository, theirs)
+	if err != nil {
+		return nil, err
+	}
+	return mergeTrees(repository, basetree, ourtree, theirtree, ourlabel, theirlabel)
+}
+
+func mergeVirtualBase(repository *repo.Repository, bases []string) (map[string]*obj.TreeLeaf, error) {
+	if len(bases) == 0 {
+		return map[string]*obj.TreeLeaf{}, nil
+	}
+
+	current := bases[0]
+	for _, next := range bases[1:] {
+		subbases, err := graph.MergeBaseAll(repository, current, next)
+		if err != nil {
+			return nil, err
+		}
+		results, err := mergeCommits(repository, current, next, subbases, "Temporary merge branch 1", "Temporary merge branch 2")
+		if err != nil {
+			return nil, err
+		}
+
+		leaves := make(map[string]*obj.TreeLeaf)
+		for name, result := range results {
+			if result.Leaf != nil {
+				leaves[name] = result.Leaf
+			}
+		}
+		tree, err := treeFromLeaves(repository, leaves)
+		if err != nil {
+			return nil, err
+		}
+		current, err = commitCreate(repository, tree, []string{current, next}, "merged common ancestors\n", "")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return commitTreeEntries(repository, current)
+}
+
+func mergeTrees(repository *repo.Repository, base map[string]*obj.TreeLeaf, ours map[string]*obj.TreeLeaf, theirs map[string]*obj.TreeLeaf, ourlabel string, theirlabel string) (map[string]*mergeEntry, error) {
+	paths := make(map[string]bool)
+	for _, side := range []map[string]*obj.TreeLeaf{base, ours, theirs} {
+		for name := range side {
+			paths[name] = true
+		}
+	}
+
+	results := make(map[string]*mergeEntry)
+	for name := range paths {
+		b, o, t := base[name], ours[name], theirs[name]
+		result := &mergeEntry{}
+		switch {
+		case leafEqual(o, t):
+			result.Leaf = o
+		case leafEqual(b, o):
+			result.Leaf = t
+		case leafEqual(b, t):
+			result.Leaf = o
+		case o == nil || t == nil:
+			result.Conflict = "modify/delete"
+			result.Leaf = o
+			if o == nil {
+				result.Leaf = t
+			}
+		default:
+			leaf, conflicted, err := mergeBlobs(repository, b, o, t, ourlabel, theirlabel)
+			if err != nil {
+				return nil, err
+			}
+			result.Leaf = leaf
+			if conflicted {
+				result.Conflict = "content"
+				if b == nil {
+					result.Conflict = "add/add"
+				}
+			}
+		}
+		if result.Conflict != "" {
+			result.Stages = [3]*obj.TreeLeaf{b, o, t}
+		}
+		if result.Leaf != nil || result.Conflict != "" {
+			results[name] = result
+		}
+	}
+
+	// A file on one side where the other side has a directory cannot be
+	// checked out, so the file is moved aside next to it.
+	names := []string{}
+	for name := range results {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for dirname := path.Dir(name); dirname != "."; dirname = path.Dir(dirname) {
+			result, ok := results[dirname]
+			if !ok || result.Leaf == nil || results[name].Leaf == nil {
+				continue
+			}
+
+			label := theirlabel
+			if leafEqual(result.Leaf, ours[dirname]) {
+				label = ourlabel
+			}
+			moved := strings.ReplaceAll(dirname+"~"+label, " ", "_")
+			results[moved] = &mergeEntry{Leaf: obj.NewTreeLeaf(result.Leaf.Mode, moved, result.Leaf.Sha)}
+			results[dirname] = &mergeEntry{
+				Stages:   [3]*obj.TreeLeaf{base[dirname], ours[dirname], theirs[dirname]},
+				Conflict: "file/directory",
+			}
+		}
+	}
+
+	return results, nil
+}
+
+func mergeBlobs(repository *repo.Repository, base *obj.TreeLeaf, ours *obj.TreeLeaf, theirs *obj.TreeLeaf, ourlabel string, theirlabel string) (*obj.TreeLeaf, bool, error) {
+	conflicted := false
+	mode := ours.Mode
+	switch {
+	case ours.Mode == theirs.Mode:
+	case base != nil && ours.Mode == base.Mode:
+		mode = theirs.Mode
+	case base != nil && theirs.Mode == base.Mode:
+	default:
+		conflicted = true
+	}
+
+	regular := func(leaf *obj.TreeLeaf) bool {
+		return leaf == nil || leaf.Mode[:2] == "10"
+	}
+	if !regular(base) || !regular(ours) || !regular(theirs) {
+		if ours.Sha == theirs.Sha {
+			return obj.NewTreeLeaf(mode, ours.Path, ours.Sha), conflicted, nil
+		}
+		return ours, true, nil
+	}
+
+	sha := ours.Sha
+	switch {
+	case ours.Sha == theirs.Sha:
+	case base != nil && base.Sha == ours.Sha:
+		sha = theirs.Sha
+	case base != nil && base.Sha == theirs.Sha:
+	default:
+		basedata := []byte{}
+		if base != nil {
+			data, err := mergeBlobData(repository, base.Sha)
+			if err != nil {
+				return nil, false, err
+			}
+			basedata = data
+		}
+		ourdata, err := mergeBlobData(repository, ours.Sha)
+		if err != nil {
+			return nil, false, err
+		}
+		theirdata, err := mergeBlobData(repository, theirs.Sha)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if diff.DiffIsBinary(basedata) || diff.DiffIsBinary(ourdata) || diff.DiffIsBinary(theirdata) {
+			return obj.NewTreeLeaf(mode, ours.Path, ours.Sha), true, nil
+		}
+
+		merged, conflicts := diff.DiffMerge(basedata, ourdata, theirdata, ourlabel, theirlabel)
+		sha, err = obj.ObjectWrite(repository, obj.NewBlob(merged))
+		if err != nil {
+			return nil, false, err
+		}
+		conflicted = conflicted || conflicts > 0
+	}
+
+	return obj.NewTreeLeaf(mode, ours.Path, sha), conflicted, nil
+}
+
+func mergeBlobData(repository *repo.Repository, sha string) ([]byte, error) {
+	object, err := obj.ObjectRead(repository, sha)
+	if err != nil {
+		return nil, err
+	}
+	blob, ok := object.(*obj.Blob)
+	if !ok {
+		return nil, fmt.Errorf("object %s is not a blob", sha)
+	}
+	return blob.Data, nil
+}
+
+// mergeApplyResults checks the merge result out on top of the current tree
+// and then records every conflicted path as its base, ours and theirs stages.
+// It returns the conflicted paths.
+func mergeApplyResults(repository *repo.Repository, current map[string]*obj.TreeLeaf, results map[string]*mergeEntry) ([]string, error) {
+	target := make(map[string]*obj.TreeLeaf)
+	conflicts := []string{}
+	for name, result := range results {
+		if result.Leaf != nil {
+			target[name] = result.Leaf
+		}
+		if result.Conflict != "" {
+			conflicts = append(conflicts, name)
+		}
+	}
+	sort.Strings(conflicts)
+
+	err := treeSwitch(repository, current, target, false, "merge")
+	if err != nil {
+		return nil, err
+	}
+	if len(conflicts) == 0 {
+		return conflicts, nil
+	}
+
+	ind, err := index.IndexRead(repository)
+	if err != nil {
+		return nil, err
+	}
+	entries := []index.IndexEntry{}
+	for _, entry := range ind.Entries {
+		if _, ok := results[entry.Name]; ok && results[entry.Name].Conflict != "" {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	for _, name := range conflicts {
+		result := results[name]
+		fmt.Printf("CONFLICT (%s): Merge conflict in %s\n", result.Conflict, name)
+
+		info, err := os.Lstat(worktreeAbsolute(repository, name))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		for stage, leaf := range result.Stages {
+			if leaf == nil {
+				continue
+			}
+			entry := index.IndexEntry{Name: name}
+			if info != nil && !info.IsDir() {
+				entry = index.NewIndexEntry(name, leaf.Sha, info)
+			}
+			entry.Sha = leaf.Sha
+			mode, err := strconv.ParseInt(leaf.Mode, 8, 32)
+			if err != nil {
+				return nil, err
+			}
+			entry.Modetype = int(mode >> 12)
+			entry.Modeperms = int(mode & 0o777)
+			entry.Flagstage = stage + 1
+			entries = append(entries, entry)
+		}
+	}
+
+	ind.Entries = entries
+	ind.InvalidateCaches()
+	err = index.IndexWrite(repository, ind)
+	if err != nil {
+		return nil, err
+	}
+	return conflicts, nil
+}
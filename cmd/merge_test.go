@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Jcho114/go-git/graph"
+	"github.com/Jcho114/go-git/index"
+	"github.com/Jcho114/go-git/obj"
+	"github.com/Jcho114/go-git/ref"
+	"github.com/Jcho114/go-git/repo"
+)
+
+// testMergeSetup commits base on master and topic, then lets each branch
+// change it and checks master out.
+func testMergeSetup(t *testing.T, base map[string]string, master map[string]string, topic map[string]string) *repo.Repository {
+	t.Helper()
+	testIdentity(t)
+	repository := testRepository(t)
+	sha := testCommit(t, repository, "master", true, base, "base")
+	err := ref.RefWrite(repository, "refs/heads/topic", sha)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCommit(t, repository, "topic", true, topic, "topic")
+	testCommit(t, repository, "master", true, master, "master")
+	testCheckoutMaster(t, repository)
+	return repository
+}
+
+// testMergeCommit records a merge of other into branch with the given files.
+func testMergeCommit(t *testing.T, repository *repo.Repository, branch string, other string, files map[string]string) string {
+	t.Helper()
+	tree := &obj.Tree{}
+	for name, content := range files {
+		sha, err := obj.ObjectWriteRaw(repository, "blob", []byte(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tree.Items = append(tree.Items, obj.NewTreeLeaf("100644", name, sha))
+	}
+	treesha, err := obj.ObjectWrite(repository, tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := fmt.Sprintf("tree %s\nparent %s\nparent %s\n", treesha, testResolve(t, repository, branch), testResolve(t, repository, other))
+	content += "author Tester <t@example.com> 1700000000 +0000\ncommitter Tester <t@example.com> 1700000000 +0000\n\nmerge\n"
+	sha, err := obj.ObjectWriteRaw(repository, "commit", []byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ref.RefWrite(repository, "refs/heads/"+branch, sha)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sha
+}
+
+func testMergeParents(t *testing.T, repository *repo.Repository) []string {
+	t.Helper()
+	object, err := obj.ObjectRead(repository, testResolve(t, repository, "master"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return object.(*obj.Commit).Kvlm["parent"]
+}
+
+func testFile(t *testing.T, repository *repo.Repository, name string) string {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join(repository.Worktree, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(content)
+}
+
+func testStages(t *testing.T, repository *repo.Repository, name string) []int {
+	t.Helper()
+	ind, err := index.IndexRead(repository)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stages := []int{}
+	for _, entry := range ind.Entries {
+		if entry.Name == name {
+			stages = append(stages, entry.Flagstage)
+		}
+	}
+	return stages
+}
+
+func TestMergeClean(t *testing.T) {
+	repository := testMergeSetup(t,
+		map[string]string{"a.txt": "1\n2\n3\n", "b.txt": "b\n"},
+		map[string]string{"a.txt": "one\n2\n3\n", "b.txt": "b\n"},
+		map[string]string{"a.txt": "1\n2\nthree\n", "b.txt": "b\n", "c.txt": "c\n"})
+	topic := testResolve(t, repository, "topic")
+
+	err := runMerge(nil, []string{"topic"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parents := testMergeParents(t, repository); len(parents) != 2 || parents[1] != topic {
+		t.Errorf("merge commit has parents %v", parents)
+	}
+	if got := testFile(t, repository, "a.txt"); got != "one\n2\nthree\n" {
+		t.Errorf("a.txt merged to %q", got)
+	}
+	if got := testFile(t, repository, "c.txt"); got != "c\n" {
+		t.Errorf("c.txt is %q", got)
+	}
+}
+
+func TestMergeConflict(t *testing.T) {
+	repository := testMergeSetup(t,
+		map[string]string{"a.txt": "1\n2\n3\n"},
+		map[string]string{"a.txt": "1\nours\n3\n"},
+		map[string]string{"a.txt": "1\ntheirs\n3\n"})
+	master, topic := testResolve(t, repository, "master"), testResolve(t, repository, "topic")
+
+	err := runMerge(nil, []string{"topic"})
+	if err == nil || !strings.Contains(err.Error(), "automatic merge failed") {
+		t.Fatalf("conflicting merge returned %v", err)
+	}
+	if got := testResolve(t, repository, "master"); got != master {
+		t.Errorf("a conflicted merge moved master to %s", got)
+	}
+	if mergehead, _ := ref.RefResolve(repository, "MERGE_HEAD"); mergehead != topic {
+		t.Errorf("MERGE_HEAD is %s, want %s", mergehead, topic)
+	}
+	if stages := fmt.Sprint(testStages(t, repository, "a.txt")); stages != "[1 2 3]" {
+		t.Errorf("a.txt has stages %s", stages)
+	}
+	want := "1\n<<<<<<< HEAD\nours\n=======\ntheirs\n>>>>>>> topic\n3\n"
+	if got := testFile(t, repository, "a.txt"); got != want {
+		t.Errorf("a.txt is\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestMergeDeleteModify(t *testing.T) {
+	repository := testMergeSetup(t,
+		map[string]string{"a.txt": "a\n", "d.txt": "d\n", "e.txt": "e\n"},
+		map[string]string{"a.txt": "a\n"},
+		map[string]string{"a.txt": "a\n", "d.txt": "changed\n", "e.txt": "e\n"})
+
+	err := runMerge(nil, []string{"topic"})
+	if err == nil {
+		t.Fatal("merging a modification into a deletion succeeded")
+	}
+	// Deleted on one side only, e.txt just goes.
+	if _, err := os.Stat(filepath.Join(repository.Worktree, "e.txt")); err == nil {
+		t.Error("e.txt was kept")
+	}
+	if stages := fmt.Sprint(testStages(t, repository, "e.txt")); stages != "[]" {
+		t.Errorf("e.txt has stages %s", stages)
+	}
+	if stages := fmt.Sprint(testStages(t, repository, "d.txt")); stages != "[1 3]" {
+		t.Errorf("d.txt has stages %s, want base and theirs", stages)
+	}
+	if got := testFile(t, repository, "d.txt"); got != "changed\n" {
+		t.Errorf("d.txt is %q, want their modification", got)
+	}
+}
+
+func TestMergeCrissCross(t *testing.T) {
+	testIdentity(t)
+	repository := testRepository(t)
+	base := testCommit(t, repository, "master", true, map[string]string{"f.txt": "1\n2\n3\n4\n5\n"}, "base")
+	err := ref.RefWrite(repository, "refs/heads/topic", base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := testCommit(t, repository, "master", true, map[string]string{"f.txt": "a\n2\n3\n4\n5\n"}, "a")
+	second := testCommit(t, repository, "topic", true, map[string]string{"f.txt": "1\n2\n3\n4\nb\n"}, "b")
+	testMergeCommit(t, repository, "master", "topic", map[string]string{"f.txt": "a\n2\n3\n4\nb\n"})
+	err = ref.RefWrite(repository, "refs/heads/topic", second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ref.RefWrite(repository, "refs/heads/side", first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testMergeCommit(t, repository, "topic", "side", map[string]string{"f.txt": "a\n2\n3\n4\nb\n"})
+	testCommit(t, repository, "master", true, map[string]string{"f.txt": "a\nA\n3\n4\nb\n"}, "A")
+	testCommit(t, repository, "topic", true, map[string]string{"f.txt": "a\n2\n3\nB\nb\n"}, "B")
+	testCheckoutMaster(t, repository)
+
+	bases, err := graph.MergeBaseAll(repository, testResolve(t, repository, "master"), testResolve(t, repository, "topic"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bases) != 2 {
+		t.Fatalf("criss-cross merge has bases %v, want both first commits", bases)
+	}
+	err = runMerge(nil, []string{"topic"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := testFile(t, repository, "f.txt"); got != "a\nA\n3\nB\nb\n" {
+		t.Errorf("f.txt merged to %q", got)
+	}
+}
+
+func TestMergeUnrelated(t *testing.T) {
+	testIdentity(t)
+	repository := testRepository(t)
+	testCommit(t, repository, "master", true, map[string]string{"a.txt": "a\n"}, "master")
+	testCommit(t, repository, "topic", false, map[string]string{"b.txt": "b\n"}, "unrelated")
+	testCheckoutMaster(t, repository)
+	master := testResolve(t, repository, "master")
+
+	err := runMerge(nil, []string{"topic"})
+	if err == nil || err.Error() != "refusing to merge unrelated histories" {
+		t.Errorf("merging unrelated histories returned %v", err)
+	}
+	if got := testResolve(t, repository, "master"); got != master {
+		t.Errorf("a refused merge moved master to %s", got)
+	}
+
+	mergeunrelated = true
+	t.Cleanup(func() { mergeunrelated = false })
+	err = runMerge(nil, []string{"topic"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := testFile(t, repository, "b.txt"); got != "b\n" {
+		t.Errorf("b.txt is %q", got)
+	}
+	if parents := testMergeParents(t, repository); len(parents) != 2 {
+		t.Errorf("merge commit has parents %v", parents)
+	}
+}
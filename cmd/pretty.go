@@ -0,0 +1,256 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Jcho114/go-git/obj"
+	"github.com/Jcho114/go-git/repo"
+)
+
+type prettyCommit struct {
+	Sha       string
+	Tree      string
+	Parents   []string
+	Author    string
+	Committer string
+	Message   string
+}
+
+type prettyFormat struct {
+	Kind       string
+	Template   string
+	Terminator bool
+}
+
+func prettyRead(repository *repo.Repository, sha string) (*prettyCommit, error) {
+	object, err := obj.ObjectRead(repository, sha)
+	if err != nil {
+		return nil, err
+	}
+	commit, ok := object.(*obj.Commit)
+	if !ok {
+		return nil, fmt.Errorf("object %s is not a commit", sha)
+	}
+
+	res := &prettyCommit{Sha: sha, Parents: commit.Kvlm["parent"]}
+	if tree := commit.Kvlm["tree"]; len(tree) > 0 {
+		res.Tree = tree[0]
+	}
+	if author := commit.Kvlm["author"]; len(author) > 0 {
+		res.Author = author[0]
+	}
+	if committer := commit.Kvlm["committer"]; len(committer) > 0 {
+		res.Committer = committer[0]
+	}
+	if message := commit.Kvlm[""]; len(message) > 0 {
+		res.Message = message[0]
+	}
+	return res, nil
+}
+
+// prettyParseFormat understands the same names as git's --pretty: one of the
+// builtin formats, "format:" and "tformat:" templates, or a bare template
+// containing placeholders, which is treated as "tformat:".
+func prettyParseFormat(name string) (*prettyFormat, error) {
+	switch {
+	case name == "" || name == "medium":
+		return &prettyFormat{Kind: "medium"}, nil
+	case name == "oneline":
+		return &prettyFormat{Kind: "oneline", Terminator: true}, nil
+	case name == "short" || name == "full" || name == "fuller":
+		return &prettyFormat{Kind: name}, nil
+	case strings.HasPrefix(name, "format:"):
+		return &prettyFormat{Kind: "user", Template: strings.TrimPrefix(name, "format:")}, nil
+	case strings.HasPrefix(name, "tformat:"):
+		return &prettyFormat{Kind: "user", Template: strings.TrimPrefix(name, "tformat:"), Terminator: true}, nil
+	case strings.Contains(name, "%"):
+		return &prettyFormat{Kind: "user", Template: name, Terminator: true}, nil
+	}
+	return nil, fmt.Errorf("invalid --pretty format: %s", name)
+}
+
+// prettyIdent splits an author or committer line into the name, the email
+// and the time in the timezone it was recorded in.
+func prettyIdent(ident string) (string, string, time.Time) {
+	open := strings.Index(ident, "<")
+	close := strings.LastIndex(ident, ">")
+	if open == -1 || close < open {
+		return strings.TrimSpace(ident), "", time.Unix(0, 0).UTC()
+	}
+	name := strings.TrimSpace(ident[:open])
+	email := ident[open+1 : close]
+
+	fields := strings.Fields(ident[close+1:])
+	when := time.Unix(0, 0).UTC()
+	if len(fields) > 0 {
+		seconds, _ := strconv.ParseInt(fields[0], 10, 64)
+		when = time.Unix(seconds, 0).UTC()
+	}
+	if len(fields) > 1 && len(fields[1]) == 5 {
+		hours, _ := strconv.Atoi(fields[1][1:3])
+		minutes, _ := strconv.Atoi(fields[1][3:5])
+		offset := hours*3600 + minutes*60
+		if fields[1][0] == '-' {
+			offset = -offset
+		}
+		when = when.In(time.FixedZone("", offset))
+	}
+	return name, email, when
+}
+
+func prettyDate(when time.Time) string {
+	return when.Format("Mon Jan 2 15:04:05 2006 -0700")
+}
+
+// prettySubject returns the first paragraph of a message joined into a
+// single line, along with the body that follows it.
+func prettySubject(message string) (string, string) {
+	lines := strings.Split(message, "\n")
+	i := 0
+	for i < len(lines) && strings.TrimSpace(lines[i]) == "" {
+		i++
+	}
+	subject := []string{}
+	for i < len(lines) && strings.TrimSpace(lines[i]) != "" {
+		subject = append(subject, strings.TrimSpace(lines[i]))
+		i++
+	}
+	for i < len(lines) && strings.TrimSpace(lines[i]) == "" {
+		i++
+	}
+	body := strings.Join(lines[i:], "\n")
+	return strings.Join(subject, " "), body
+}
+
+// prettyIndent indents every line of the message by four spaces, dropping
+// leading blank lines. With short set only the first paragraph is kept.
+func prettyIndent(message string, short bool) string {
+	var builder strings.Builder
+	first := true
+	for _, line := range strings.Split(message, "\n") {
+		blank := strings.TrimSpace(line) == ""
+		if blank && first {
+			continue
+		}
+		if blank && short {
+			break
+		}
+		first = false
+		builder.WriteString("    " + line + "\n")
+	}
+	return builder.String()
+}
+
+// prettyMessage formats everything about a commit that follows the "commit"
+// line for the builtin formats, or the expanded template for user formats.
+func prettyMessage(commit *prettyCommit, format *prettyFormat, parents []string) string {
+	subject, _ := prettySubject(commit.Message)
+	switch format.Kind {
+	case "oneline":
+		return subject
+	case "user":
+		return prettyExpand(commit, format.Template, parents)
+	}
+
+	var builder strings.Builder
+	if len(parents) > 1 {
+		builder.WriteString("Merge:")
+		for _, parent := range parents {
+			builder.WriteString(" " + parent[:7])
+		}
+		builder.WriteString("\n")
+	}
+
+	authorname, authoremail, authordate := prettyIdent(commit.Author)
+	committername, committeremail, committerdate := prettyIdent(commit.Committer)
+	switch format.Kind {
+	case "short":
+		fmt.Fprintf(&builder, "Author: %s <%s>\n", authorname, authoremail)
+	case "medium":
+		fmt.Fprintf(&builder, "Author: %s <%s>\n", authorname, authoremail)
+		fmt.Fprintf(&builder, "Date:   %s\n", prettyDate(authordate))
+	case "full":
+		fmt.Fprintf(&builder, "Author: %s <%s>\n", authorname, authoremail)
+		fmt.Fprintf(&builder, "Commit: %s <%s>\n", committername, committeremail)
+	case "fuller":
+		fmt.Fprintf(&builder, "Author:     %s <%s>\n", authorname, authoremail)
+		fmt.Fprintf(&builder, "AuthorDate: %s\n", prettyDate(authordate))
+		fmt.Fprintf(&builder, "Commit:     %s <%s>\n", committername, committeremail)
+		fmt.Fprintf(&builder, "CommitDate: %s\n", prettyDate(committerdate))
+	}
+	builder.WriteString("\n")
+	builder.WriteString(prettyIndent(commit.Message, format.Kind == "short"))
+
+	return strings.TrimRight(builder.String(), " \t\n") + "\n"
+}
+
+var prettyPlaceholder = regexp.MustCompile(`%(?:x[0-9a-fA-F]{2}|[ac][nedtiIs]|[HhTtPpsbBn%])`)
+
+// prettyExpand fills in the placeholders of a user format. Placeholders it
+// does not know about are left as they are.
+func prettyExpand(commit *prettyCommit, template string, parents []string) string {
+	return prettyPlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		key := placeholder[1:]
+		switch key {
+		case "H":
+			return commit.Sha
+		case "h":
+			return commit.Sha[:7]
+		case "T":
+			return commit.Tree
+		case "t":
+			return commit.Tree[:7]
+		case "P":
+			return strings.Join(parents, " ")
+		case "p":
+			short := []string{}
+			for _, parent := range parents {
+				short = append(short, parent[:7])
+			}
+			return strings.Join(short, " ")
+		case "s":
+			subject, _ := prettySubject(commit.Message)
+			return subject
+		case "b":
+			_, body := prettySubject(commit.Message)
+			return body
+		case "B":
+			return commit.Message
+		case "n":
+			return "\n"
+		case "%":
+			return "%"
+		}
+		if key[0] == 'x' {
+			value, _ := strconv.ParseUint(key[1:], 16, 8)
+			return string([]byte{byte(value)})
+		}
+
+		ident := commit.Author
+		if key[0] == 'c' {
+			ident = commit.Committer
+		}
+		name, email, when := prettyIdent(ident)
+		switch key[1] {
+		case 'n':
+			return name
+		case 'e':
+			return email
+		case 'd':
+			return prettyDate(when)
+		case 't':
+			return strconv.FormatInt(when.Unix(), 10)
+		case 'i':
+			return when.Format("2006-01-02 15:04:05 -0700")
+		case 'I':
+			return when.Format(time.RFC3339)
+		case 's':
+			return when.Format("2006-01-02")
+		}
+		return placeholder
+	})
+}
@@ -0,0 +1,360 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/Jcho114/go-git/obj"
+	"github.com/Jcho114/go-git/ref"
+	"github.com/Jcho114/go-git/repo"
+	"github.com/Jcho114/go-git/transport"
+	"github.com/spf13/cobra"
+)
+
+var pushforce bool
+
+func init() {
+	pushCmd.Flags().BoolVarP(&pushforce, "force", "f", false, "update remote refs even when the update is not a fast-forward")
+	rootCmd.AddCommand(pushCmd)
+}
+
+var pushCmd = &cobra.Command{
+	Use:   "push",
+	Short: "a very attempt at updating remote refs along with associated objects",
+	Long:  "a very very bad attempt at updating remote refs along with associated objects from scratch",
+	Args:  cobra.MatchAll(cobra.ArbitraryArgs, cobra.OnlyValidArgs),
+	RunE:  runPush,
+}
+
+// pushUpdate is a remote ref that a push asks to move, along with the local
+// ref it comes from.
+type pushUpdate struct {
+	Src    string
+	Dst    string
+	Old    string
+	New    string
+	Force  bool
+	Status string
+	Reason string
+}
+
+const (
+	pushNew            = "new"
+	pushFastForward    = "fast-forward"
+	pushForced         = "forced"
+	pushDeleted        = "deleted"
+	pushUpToDate       = "up to date"
+	pushRejected       = "rejected"
+	pushRemoteRejected = "remote rejected"
+)
+
+func runPush(cmd *cobra.Command, args []string) error {
+	repository, err := repo.FindRepository(".", true)
+	if err != nil {
+		return err
+	}
+
+	name := remoteDefault(repository)
+	specs := []string{}
+	if len(args) > 0 {
+		name, specs = args[0], args[1:]
+	}
+	remote := repository.Config.Remotes[name]
+	if remote == nil {
+		return fmt.Errorf("'%s' does not appear to be a git repository", name)
+	}
+	if len(specs) == 0 {
+		current, err := branchCurrent(repository)
+		if err != nil {
+			return err
+		}
+		if current == "" {
+			return fmt.Errorf("you are not currently on a branch")
+		}
+		specs = []string{"refs/heads/" + current}
+	}
+
+	updates, err := pushRemote(repository, remote, specs)
+	if err != nil {
+		return err
+	}
+	return pushPrint(remote.URL, updates)
+}
+
+// pushRemote sends the updates the refspecs ask for to a remote and returns
+// what became of each.
+func pushRemote(repository *repo.Repository, remote *repo.RemoteConfig, specs []string) ([]*pushUpdate, error) {
+	conn, err := transport.Open(remote.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	remoterefs, err := conn.PushRefs()
+	if err != nil {
+		return nil, err
+	}
+
+	updates, err := pushResolve(repository, specs, remoterefs)
+	if err != nil {
+		return nil, err
+	}
+
+	commands := []transport.Command{}
+	haves := []string{}
+	for _, remoteref := range remoterefs {
+		if obj.ObjectExists(repository, remoteref.Sha) {
+			haves = append(haves, remoteref.Sha)
+		}
+	}
+	for _, update := range updates {
+		pushClassify(repository, update)
+		if update.Status != pushRejected && update.Status != pushUpToDate {
+			commands = append(commands, transport.Command{Name: update.Dst, Old: update.Old, New: update.New})
+		}
+	}
+
+	if len(commands) > 0 {
+		reasons, err := conn.Push(repository, commands, haves, transport.NewProgressWriter(os.Stderr))
+		if err != nil {
+			return nil, err
+		}
+		for _, update := range updates {
+			if reason, ok := reasons[update.Dst]; ok {
+				update.Status, update.Reason = pushRemoteRejected, reason
+			}
+		}
+		err = pushTrack(repository, remote, updates)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return updates, nil
+}
+
+// pushResolve turns refspecs such as "main", "HEAD:refs/heads/topic",
+// "+side" or ":old" into the remote refs to update. A destination that is
+// not a full ref name is looked up among the remote refs, or else goes
+// under the same kind of ref as the source.
+func pushResolve(repository *repo.Repository, specs []string, remoterefs []transport.Ref) ([]*pushUpdate, error) {
+	remote := make(map[string]string)
+	for _, remoteref := range remoterefs {
+		remote[remoteref.Name] = remoteref.Sha
+	}
+
+	res := []*pushUpdate{}
+	for _, spec := range specs {
+		refspec, err := ref.RefspecParse(spec)
+		if err != nil {
+			return nil, err
+		}
+		force := refspec.Force || pushforce
+
+		if strings.Contains(refspec.Src, "*") {
+			refmap, err := ref.RefList(repository, "")
+			if err != nil {
+				return nil, err
+			}
+			refs := ref.RefFlatten(refmap, "refs")
+			names := []string{}
+			for name := range refs {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				dst, ok := refspec.Match(name)
+				if !ok {
+					continue
+				}
+				if refspec.Dst == "" {
+					dst = name
+				}
+				res = append(res, &pushUpdate{Src: name, Dst: dst, Old: remote[dst], New: refs[name], Force: force})
+			}
+			continue
+		}
+
+		if refspec.Src == "" {
+			dst := pushDestination(refspec.Dst, "", remote)
+			if dst == "" {
+				return nil, fmt.Errorf("unable to delete '%s': remote ref does not exist", refspec.Dst)
+			}
+			res = append(res, &pushUpdate{Dst: dst, Old: remote[dst], Force: force})
+			continue
+		}
+
+		src := pushSource(repository, refspec.Src)
+		sha, err := obj.ObjectFind(repository, refspec.Src, "any", true)
+		if err != nil {
+			return nil, fmt.Errorf("src refspec %s does not match any", refspec.Src)
+		}
+		dst := refspec.Dst
+		if dst == "" {
+			if src == "" {
+				return nil, fmt.Errorf("src refspec %s does not name a ref, the destination must be given", refspec.Src)
+			}
+			dst = src
+		}
+		dst = pushDestination(dst, src, remote)
+		if dst == "" {
+			return nil, fmt.Errorf("the destination refspec %s neither matches an existing ref on the remote nor begins with refs/", refspec.Dst)
+		}
+		if src == "" || refspec.Src == "HEAD" || refspec.Src == "@" {
+			src = refspec.Src
+		}
+		res = append(res, &pushUpdate{Src: src, Dst: dst, Old: remote[dst], New: sha, Force: force})
+	}
+	return res, nil
+}
+
+// pushSource returns the full name of the local ref a refspec source names,
+// or nothing when it names a commit some other way.
+func pushSource(repository *repo.Repository, name string) string {
+	if name == "HEAD" || name == "@" {
+		current, err := ref.RefSymbolicRead(repository, "HEAD")
+		if err != nil {
+			return ""
+		}
+		return current
+	}
+	for _, candidate := range []string{name, "refs/heads/" + name, "refs/tags/" + name} {
+		if !strings.HasPrefix(candidate, "refs/") {
+			continue
+		}
+		if _, err := ref.RefResolve(repository, candidate); err == nil {
+			return candidate
+		}
+	}
+	return ""
+}
+
+func pushDestination(name string, src string, remote map[string]string) string {
+	if strings.HasPrefix(name, "refs/") {
+		return name
+	}
+	for _, prefix := range []string{"refs/heads/", "refs/tags/"} {
+		if _, ok := remote[prefix+name]; ok {
+			return prefix + name
+		}
+	}
+	switch {
+	case src == "":
+		return ""
+	case strings.HasPrefix(src, "refs/tags/"):
+		return "refs/tags/" + name
+	}
+	return "refs/heads/" + name
+}
+
+// pushClassify decides what an update does to the remote ref, rejecting it
+// when it would lose commits without being forced, or when the remote ref
+// points at something not here, so it cannot be checked.
+func pushClassify(repository *repo.Repository, update *pushUpdate) {
+	switch {
+	case update.New == "" && update.Old == "":
+		update.Status, update.Reason = pushRejected, "remote ref does not exist"
+	case update.New == "":
+		update.Status = pushDeleted
+	case update.Old == update.New:
+		update.Status = pushUpToDate
+	case update.Old == "":
+		update.Status = pushNew
+	case strings.HasPrefix(update.Dst, "refs/tags/") && !update.Force:
+		update.Status, update.Reason = pushRejected, "already exists"
+	case strings.HasPrefix(update.Dst, "refs/tags/"):
+		update.Status = pushForced
+	case !obj.ObjectExists(repository, update.Old) && !update.Force:
+		update.Status, update.Reason = pushRejected, "fetch first"
+	case fetchFastForwards(repository, update.Old, update.New):
+		update.Status = pushFastForward
+	case update.Force:
+		update.Status = pushForced
+	default:
+		update.Status, update.Reason = pushRejected, "non-fast-forward"
+	}
+}
+
+// pushTrack moves the remote-tracking refs of the updated remote refs, the
+// same way a fetch right after the push would.
+func pushTrack(repository *repo.Repository, remote *repo.RemoteConfig, updates []*pushUpdate) error {
+	for _, update := range updates {
+		if update.Status == pushRejected || update.Status == pushRemoteRejected || update.Status == pushUpToDate {
+			continue
+		}
+		for _, fetch := range remote.Fetch {
+			refspec, err := ref.RefspecParse(fetch)
+			if err != nil {
+				return err
+			}
+			local, ok := refspec.Match(update.Dst)
+			if !ok || local == "" {
+				continue
+			}
+			if update.Status == pushDeleted {
+				if _, err := ref.RefResolve(repository, local); err == nil {
+					err := ref.RefDelete(repository, local)
+					if err != nil {
+						return err
+					}
+				}
+			} else {
+				err := ref.RefWrite(repository, local, update.New)
+				if err != nil {
+					return err
+				}
+			}
+			break
+		}
+	}
+	return nil
+}
+
+// pushPrint reports every update the way git does, with a flag and a
+// summary of the change in front of the names on both sides.
+func pushPrint(url string, updates []*pushUpdate) error {
+	changed := false
+	for _, update := range updates {
+		changed = changed || update.Status != pushUpToDate
+	}
+	if !changed {
+		fmt.Fprintln(os.Stderr, "Everything up-to-date")
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "To %s\n", url)
+	failed := false
+	for _, update := range updates {
+		flag, summary, suffix := " ", "", ""
+		switch update.Status {
+		case pushUpToDate:
+			continue
+		case pushNew:
+			flag, summary = "*", "[new reference]"
+			if strings.HasPrefix(update.Dst, "refs/heads/") {
+				summary = "[new branch]"
+			} else if strings.HasPrefix(update.Dst, "refs/tags/") {
+				summary = "[new tag]"
+			}
+		case pushFastForward:
+			summary = update.Old[:7] + ".." + update.New[:7]
+		case pushForced:
+			flag, summary, suffix = "+", update.Old[:7]+"..."+update.New[:7], " (forced update)"
+		case pushDeleted:
+			fmt.Fprintf(os.Stderr, " - %-17s %s\n", "[deleted]", fetchShortName(update.Dst))
+			continue
+		case pushRejected, pushRemoteRejected:
+			flag, summary, suffix = "!", "["+update.Status+"]", " ("+update.Reason+")"
+			failed = true
+		}
+		src := fetchShortName(update.Src)
+		if src == "" {
+			src = "(delete)"
+		}
+		fmt.Fprintf(os.Stderr, " %s %-17s %s -> %s%s\n", flag, summary, src, fetchShortName(update.Dst), suffix)
+	}
+	if failed {
+		return fmt.Errorf("failed to push some refs to '%s'", url)
+	}
+	return nil
+}
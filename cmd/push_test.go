@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/Jcho114/go-git/obj"
+	"github.com/Jcho114/go-git/ref"
+	"github.com/Jcho114/go-git/repo"
+)
+
+// testPushSetup returns a client that has fetched a server holding a single
+// commit on master. The server is taken as bare, so its checked out branch
+// may be pushed to.
+func testPushSetup(t *testing.T) (*repo.Repository, *repo.Repository, string) {
+	t.Helper()
+	server := testRepository(t)
+	server.Config.Core.Bare = true
+	base := testCommit(t, server, "master", true, map[string]string{"a.txt": "a\n"}, "base")
+
+	client := testRepository(t)
+	testRemote(t, client, testServer(t, server), "+refs/heads/*:refs/remotes/origin/*")
+	_, _, err := fetchRemote(client, "origin", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ref.RefWrite(client, "refs/heads/master", base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return server, client, base
+}
+
+func testPush(t *testing.T, client *repo.Repository, specs ...string) *pushUpdate {
+	t.Helper()
+	updates, err := pushRemote(client, client.Config.Remotes["origin"], specs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updates) != 1 {
+		t.Fatalf("push made %d updates, want 1", len(updates))
+	}
+	return updates[0]
+}
+
+func TestPushFastForward(t *testing.T) {
+	server, client, _ := testPushSetup(t)
+	next := testCommit(t, client, "master", true, map[string]string{"a.txt": "a\n", "b.txt": "b\n"}, "next")
+
+	update := testPush(t, client, "master")
+	if update.Status != pushFastForward {
+		t.Errorf("push was %s (%s), want a fast-forward", update.Status, update.Reason)
+	}
+	if got, _ := ref.RefResolve(server, "refs/heads/master"); got != next {
+		t.Errorf("remote master is at %s, want %s", got, next)
+	}
+	if got, _ := ref.RefResolve(client, "refs/remotes/origin/master"); got != next {
+		t.Errorf("origin/master is at %s, want %s", got, next)
+	}
+	if !obj.ObjectExists(server, next) {
+		t.Errorf("the remote is missing %s", next)
+	}
+}
+
+func TestPushNonFastForward(t *testing.T) {
+	server, client, base := testPushSetup(t)
+	testCommit(t, server, "master", true, map[string]string{"c.txt": "c\n"}, "remote only")
+	remote, _ := ref.RefResolve(server, "refs/heads/master")
+	testCommit(t, client, "master", false, map[string]string{"d.txt": "d\n"}, "diverged")
+	err := ref.RefWrite(client, "refs/remotes/origin/master", base)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	update := testPush(t, client, "master")
+	if update.Status != pushRejected || update.Reason != "fetch first" {
+		t.Errorf("push was %s (%s), want rejected to fetch first", update.Status, update.Reason)
+	}
+
+	_, _, err = fetchRemote(client, "origin", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	update = testPush(t, client, "master")
+	if update.Status != pushRejected || update.Reason != "non-fast-forward" {
+		t.Errorf("push was %s (%s), want rejected as non-fast-forward", update.Status, update.Reason)
+	}
+	if got, _ := ref.RefResolve(server, "refs/heads/master"); got != remote {
+		t.Errorf("a rejected push moved remote master to %s", got)
+	}
+}
+
+func TestPushForce(t *testing.T) {
+	server, client, _ := testPushSetup(t)
+	testCommit(t, server, "master", true, map[string]string{"c.txt": "c\n"}, "remote only")
+	_, _, err := fetchRemote(client, "origin", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	diverged := testCommit(t, client, "master", true, map[string]string{"d.txt": "d\n"}, "diverged")
+
+	update := testPush(t, client, "+master")
+	if update.Status != pushForced {
+		t.Errorf("push was %s (%s), want forced", update.Status, update.Reason)
+	}
+	if got, _ := ref.RefResolve(server, "refs/heads/master"); got != diverged {
+		t.Errorf("remote master is at %s, want %s", got, diverged)
+	}
+}
+
+func TestPushNewBranchAndDelete(t *testing.T) {
+	server, client, base := testPushSetup(t)
+
+	update := testPush(t, client, "master:refs/heads/topic")
+	if update.Status != pushNew {
+		t.Errorf("push was %s (%s), want a new branch", update.Status, update.Reason)
+	}
+	if got, _ := ref.RefResolve(server, "refs/heads/topic"); got != base {
+		t.Errorf("remote topic is at %s, want %s", got, base)
+	}
+	if got, _ := ref.RefResolve(client, "refs/remotes/origin/topic"); got != base {
+		t.Errorf("origin/topic is at %s, want %s", got, base)
+	}
+
+	update = testPush(t, client, ":topic")
+	if update.Status != pushDeleted {
+		t.Errorf("push was %s (%s), want a deletion", update.Status, update.Reason)
+	}
+	if _, err := ref.RefResolve(server, "refs/heads/topic"); err == nil {
+		t.Error("remote topic still exists")
+	}
+	if _, err := ref.RefResolve(client, "refs/remotes/origin/topic"); err == nil {
+		t.Error("origin/topic still exists")
+	}
+}
+
+func TestPushUpToDate(t *testing.T) {
+	_, client, _ := testPushSetup(t)
+	update := testPush(t, client, "master")
+	if update.Status != pushUpToDate {
+		t.Errorf("push was %s (%s), want up to date", update.Status, update.Reason)
+	}
+}
@@ -0,0 +1,451 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/Jcho114/go-git/graph"
+	"github.com/Jcho114/go-git/index"
+	"github.com/Jcho114/go-git/obj"
+	"github.com/Jcho114/go-git/ref"
+	"github.com/Jcho114/go-git/repo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	rebaseonto     string
+	rebasecontinue bool
+	rebaseskip     bool
+	rebaseabort    bool
+)
+
+func init() {
+	rebaseCmd.Flags().StringVar(&rebaseonto, "onto", "", "starting point at which to create the new commits")
+	rebaseCmd.Flags().BoolVar(&rebasecontinue, "continue", false, "restart the rebasing process after having resolved a merge conflict")
+	rebaseCmd.Flags().BoolVar(&rebaseskip, "skip", false, "restart the rebasing process by skipping the current patch")
+	rebaseCmd.Flags().BoolVar(&rebaseabort, "abort", false, "abort the rebase operation and reset HEAD to the original branch")
+	rootCmd.AddCommand(rebaseCmd)
+}
+
+var rebaseCmd = &cobra.Command{
+	Use:   "rebase",
+	Short: "a very attempt at reapplying commits on top of another base tip",
+	Long:  "a very very bad attempt at reapplying commits on top of another base tip from scratch",
+	Args:  cobra.MatchAll(cobra.MaximumNArgs(1), cobra.OnlyValidArgs),
+	RunE:  runRebase,
+}
+
+func runRebase(cmd *cobra.Command, args []string) error {
+	repository, err := repo.FindRepository(".", true)
+	if err != nil {
+		return err
+	}
+
+	_, err = os.Stat(rebaseStatePath(repository, ""))
+	inprogress := err == nil
+
+	if rebasecontinue || rebaseskip || rebaseabort {
+		if !inprogress {
+			return fmt.Errorf("no rebase in progress")
+		}
+		switch {
+		case rebaseabort:
+			return rebaseAbort(repository)
+		case rebaseskip:
+			return rebaseSkip(repository)
+		default:
+			return rebaseContinue(repository)
+		}
+	}
+
+	if inprogress {
+		return fmt.Errorf("it looks like a rebase is already in progress, use --continue, --skip or --abort")
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("no upstream specified to rebase onto")
+	}
+
+	upstream, err := obj.ObjectFind(repository, args[0], "commit", true)
+	if err != nil {
+		return fmt.Errorf("invalid upstream '%s'", args[0])
+	}
+	onto := upstream
+	if rebaseonto != "" {
+		onto, err = obj.ObjectFind(repository, rebaseonto, "commit", true)
+		if err != nil {
+			return fmt.Errorf("does not point to a valid commit '%s'", rebaseonto)
+		}
+	}
+
+	head, err := ref.RefResolve(repository, "HEAD")
+	if err != nil {
+		return err
+	}
+	headname, err := ref.RefSymbolicRead(repository, "HEAD")
+	if err != nil {
+		return err
+	}
+
+	report, err := statusCollect(repository)
+	if err != nil {
+		return err
+	}
+	if len(report.Staged) > 0 || len(report.Unstaged) > 0 || len(report.Unmerged) > 0 {
+		return fmt.Errorf("cannot rebase: you have uncommitted changes, please commit or stash them")
+	}
+
+	g := graph.NewGraph(repository)
+	if onto == upstream {
+		uptodate, err := g.IsAncestor(upstream, head)
+		if err != nil {
+			return err
+		}
+		if uptodate {
+			fmt.Printf("Current branch %s is up to date.\n", strings.TrimPrefix(headname, "refs/heads/"))
+			return nil
+		}
+	}
+
+	commits, err := g.Range([]string{head}, []string{upstream})
+	if err != nil {
+		return err
+	}
+	todo := []string{}
+	for i := len(commits) - 1; i >= 0; i-- {
+		parents, err := g.Parents(commits[i])
+		if err != nil {
+			return err
+		}
+		if len(parents) > 1 {
+			continue
+		}
+		summary, err := commitSummary(repository, commits[i])
+		if err != nil {
+			return err
+		}
+		todo = append(todo, fmt.Sprintf("pick %s %s", commits[i], summary))
+	}
+
+	err = ref.RefWrite(repository, "ORIG_HEAD", head)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(rebaseStatePath(repository, ""), 0755)
+	if err != nil {
+		return err
+	}
+	state := map[string]string{
+		"head-name":       headname,
+		"onto":            onto,
+		"orig-head":       head,
+		"git-rebase-todo": strings.Join(todo, "\n"),
+		"done":            "",
+		"msgnum":          "0",
+		"end":             strconv.Itoa(len(todo)),
+	}
+	for name, value := range state {
+		err := rebaseStateWrite(repository, name, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = checkoutSwitch(repository, head, onto, "rebase", false)
+	if err != nil {
+		os.RemoveAll(rebaseStatePath(repository, ""))
+		return err
+	}
+	err = ref.RefWrite(repository, "HEAD", onto)
+	if err != nil {
+		return err
+	}
+
+	return rebaseRun(repository)
+}
+
+func rebaseStatePath(repository *repo.Repository, name string) string {
+	return filepath.Join(repository.Gitdir, "rebase-merge", name)
+}
+
+func rebaseStateRead(repository *repo.Repository, name string) (string, error) {
+	content, err := os.ReadFile(rebaseStatePath(repository, name))
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+func rebaseStateWrite(repository *repo.Repository, name string, value string) error {
+	if value != "" && !strings.HasSuffix(value, "\n") {
+		value += "\n"
+	}
+	return os.WriteFile(rebaseStatePath(repository, name), []byte(value), 0644)
+}
+
+func rebaseStateClearStop(repository *repo.Repository) error {
+	for _, name := range []string{"message", "author", "stopped-sha"} {
+		err := os.Remove(rebaseStatePath(repository, name))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// rebaseRun picks the remaining todo entries one by one, stopping with the
+// state saved as soon as one of them does not apply cleanly.
+func rebaseRun(repository *repo.Repository) error {
+	for {
+		content, err := rebaseStateRead(repository, "git-rebase-todo")
+		if err != nil {
+			return err
+		}
+		todo := strings.Split(strings.TrimSpace(content), "\n")
+		if todo[0] == "" {
+			return rebaseFinish(repository)
+		}
+
+		line := todo[0]
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) < 2 || fields[0] != "pick" {
+			return fmt.Errorf("invalid todo line '%s'", line)
+		}
+		sha := fields[1]
+
+		err = rebaseStateWrite(repository, "git-rebase-todo", strings.Join(todo[1:], "\n"))
+		if err != nil {
+			return err
+		}
+		done, err := rebaseStateRead(repository, "done")
+		if err != nil {
+			return err
+		}
+		err = rebaseStateWrite(repository, "done", done+line)
+		if err != nil {
+			return err
+		}
+		msgnum, err := rebaseStateRead(repository, "msgnum")
+		if err != nil {
+			return err
+		}
+		num, _ := strconv.Atoi(strings.TrimSpace(msgnum))
+		err = rebaseStateWrite(repository, "msgnum", strconv.Itoa(num+1))
+		if err != nil {
+			return err
+		}
+
+		stopped, err := rebasePick(repository, sha)
+		if err != nil {
+			return err
+		}
+		if stopped {
+			return fmt.Errorf("could not apply %s... %s\nresolve all conflicts manually, mark them as resolved with \"add\", then run \"rebase --continue\", or run \"rebase --skip\" or \"rebase --abort\"", sha[:7], strings.Join(fields[2:], " "))
+		}
+	}
+}
+
+// rebasePick replays a single commit on top of HEAD. It reports whether the
+// rebase had to stop because of conflicts.
+func rebasePick(repository *repo.Repository, sha string) (bool, error) {
+	object, err := obj.ObjectRead(repository, sha)
+	if err != nil {
+		return false, err
+	}
+	commit, ok := object.(*obj.Commit)
+	if !ok {
+		return false, fmt.Errorf("object %s is not a commit", sha)
+	}
+	message, author := "", ""
+	if len(commit.Kvlm[""]) > 0 {
+		message = commit.Kvlm[""][0]
+	}
+	if len(commit.Kvlm["author"]) > 0 {
+		author = commit.Kvlm["author"][0]
+	}
+	parent := ""
+	if len(commit.Kvlm["parent"]) > 0 {
+		parent = commit.Kvlm["parent"][0]
+	}
+
+	head, err := ref.RefResolve(repository, "HEAD")
+	if err != nil {
+		return false, err
+	}
+	summary, err := commitSummary(repository, sha)
+	if err != nil {
+		return false, err
+	}
+
+	conflicts, err := pickApply(repository, head, parent, sha, sha[:7]+" ("+summary+")")
+	if err != nil {
+		return false, err
+	}
+	if len(conflicts) > 0 {
+		for name, value := range map[string]string{"message": message, "author": author, "stopped-sha": sha} {
+			err := rebaseStateWrite(repository, name, value)
+			if err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+
+	return false, rebaseCommit(repository, head, message, author)
+}
+
+// rebaseCommit records the index on top of HEAD, dropping the commit when it
+// no longer changes anything.
+func rebaseCommit(repository *repo.Repository, head string, message string, author string) error {
+	ind, err := index.IndexRead(repository)
+	if err != nil {
+		return err
+	}
+	tree, err := treeFromIndex(repository, ind)
+	if err != nil {
+		return err
+	}
+	headtree, err := obj.ObjectFind(repository, head, "tree", true)
+	if err != nil {
+		return err
+	}
+	if tree == headtree {
+		fmt.Printf("dropping %s -- patch contents already upstream\n", strings.SplitN(strings.TrimSpace(message), "\n", 2)[0])
+		return nil
+	}
+
+	commit, err := commitCreate(repository, tree, []string{head}, message, author)
+	if err != nil {
+		return err
+	}
+	return ref.RefWrite(repository, "HEAD", commit)
+}
+
+func rebaseFinish(repository *repo.Repository) error {
+	headname, err := rebaseStateRead(repository, "head-name")
+	if err != nil {
+		return err
+	}
+	headname = strings.TrimSpace(headname)
+	head, err := ref.RefResolve(repository, "HEAD")
+	if err != nil {
+		return err
+	}
+
+	if headname != "" {
+		err := ref.RefWrite(repository, headname, head)
+		if err != nil {
+			return err
+		}
+		err = ref.RefSymbolicWrite(repository, "HEAD", headname)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = os.RemoveAll(rebaseStatePath(repository, ""))
+	if err != nil {
+		return err
+	}
+	if headname == "" {
+		headname = "detached HEAD"
+	}
+	fmt.Printf("Successfully rebased and updated %s.\n", headname)
+	return nil
+}
+
+func rebaseContinue(repository *repo.Repository) error {
+	ind, err := index.IndexRead(repository)
+	if err != nil {
+		return err
+	}
+	for _, entry := range ind.Entries {
+		if entry.Flagstage != 0 {
+			return fmt.Errorf("you must edit all merge conflicts and then mark them as resolved using add")
+		}
+	}
+
+	message, err := rebaseStateRead(repository, "message")
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err == nil {
+		author, err := rebaseStateRead(repository, "author")
+		if err != nil {
+			return err
+		}
+		head, err := ref.RefResolve(repository, "HEAD")
+		if err != nil {
+			return err
+		}
+		err = rebaseCommit(repository, head, message, strings.TrimSpace(author))
+		if err != nil {
+			return err
+		}
+		err = rebaseStateClearStop(repository)
+		if err != nil {
+			return err
+		}
+	}
+
+	report, err := statusCollect(repository)
+	if err != nil {
+		return err
+	}
+	if len(report.Staged) > 0 || len(report.Unstaged) > 0 {
+		return fmt.Errorf("cannot continue: you have uncommitted changes, please commit or stash them")
+	}
+
+	return rebaseRun(repository)
+}
+
+func rebaseSkip(repository *repo.Repository) error {
+	head, err := ref.RefResolve(repository, "HEAD")
+	if err != nil {
+		return err
+	}
+	err = checkoutSwitch(repository, head, head, "rebase", true)
+	if err != nil {
+		return err
+	}
+	err = rebaseStateClearStop(repository)
+	if err != nil {
+		return err
+	}
+	return rebaseRun(repository)
+}
+
+func rebaseAbort(repository *repo.Repository) error {
+	orighead, err := rebaseStateRead(repository, "orig-head")
+	if err != nil {
+		return err
+	}
+	orighead = strings.TrimSpace(orighead)
+	headname, err := rebaseStateRead(repository, "head-name")
+	if err != nil {
+		return err
+	}
+	headname = strings.TrimSpace(headname)
+
+	head, err := ref.RefResolve(repository, "HEAD")
+	if err != nil {
+		return err
+	}
+	err = checkoutSwitch(repository, head, orighead, "rebase", true)
+	if err != nil {
+		return err
+	}
+
+	if headname != "" {
+		err = ref.RefSymbolicWrite(repository, "HEAD", headname)
+	} else {
+		err = ref.RefWrite(repository, "HEAD", orighead)
+	}
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(rebaseStatePath(repository, ""))
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/Jcho114/go-git/repo"
+	"github.com/Jcho114/go-git/transport"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(receivePackCmd)
+}
+
+var receivePackCmd = &cobra.Command{
+	Use:   "receive-pack",
+	Short: "a very attempt at receiving what is pushed into a repository",
+	Long:  "a very very bad attempt at receiving what is pushed into a repository from scratch",
+	Args:  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	RunE:  runReceivePack,
+}
+
+func runReceivePack(cmd *cobra.Command, args []string) error {
+	repository, err := repo.FindRepository(args[0], true)
+	if err != nil {
+		return err
+	}
+	err = transport.ReceivePackAdvertise(repository, os.Stdout)
+	if err != nil {
+		return err
+	}
+	return transport.ReceivePack(repository, os.Stdin, os.Stdout, transport.NewHookScripts())
+}
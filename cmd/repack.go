@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Jcho114/go-git/index"
+	"github.com/Jcho114/go-git/obj"
+	"github.com/Jcho114/go-git/ref"
+	"github.com/Jcho114/go-git/repo"
+	"github.com/spf13/cobra"
+)
+
+var repackdelete bool
+
+func init() {
+	repackCmd.Flags().BoolVarP(&repackdelete, "delete", "d", false, "remove redundant packs and loose objects after packing")
+	rootCmd.AddCommand(repackCmd)
+}
+
+var repackCmd = &cobra.Command{
+	Use:   "repack",
+	Short: "a very attempt at packing reachable objects",
+	Long:  "a very very bad attempt at packing reachable objects into a single delta compressed packfile from scratch",
+	Args:  cobra.NoArgs,
+	RunE:  runRepack,
+}
+
+func runRepack(cmd *cobra.Command, args []string) error {
+	repository, err := repo.FindRepository(".", true)
+	if err != nil {
+		return err
+	}
+
+	_, err = repackRun(repository, repackdelete)
+	return err
+}
+
+var reachablePseudoRefs = []string{"HEAD", "ORIG_HEAD", "MERGE_HEAD", "CHERRY_PICK_HEAD", "REVERT_HEAD"}
+
+// The index and in-progress operations can point at objects that no ref
+// reaches yet, and those must survive a gc just like branch tips.
+func reachableRoots(repository *repo.Repository) ([]string, error) {
+	roots := []string{}
+
+	refmap, err := ref.RefList(repository, "")
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	for _, sha := range ref.RefFlatten(refmap, "") {
+		roots = append(roots, sha)
+	}
+
+	for _, name := range reachablePseudoRefs {
+		content, err := ref.RefResolve(repository, name)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		roots = append(roots, strings.Fields(content)...)
+	}
+
+	logs, err := reachableLogs(repository)
+	if err != nil {
+		return nil, err
+	}
+	roots = append(roots, logs...)
+
+	ind, err := index.IndexRead(repository)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range ind.Entries {
+		if entry.Modetype == 0b1110 || entry.Flagintenttoadd {
+			continue
+		}
+		roots = append(roots, entry.Sha)
+	}
+
+	return roots, nil
+}
+
+// reachableLogs returns the old and new side of every reflog entry, so that
+// a reset or amend can still be undone after a gc.
+func reachableLogs(repository *repo.Repository) ([]string, error) {
+	zero := strings.Repeat("0", 40)
+	shas := []string{}
+	err := filepath.WalkDir(filepath.Join(repository.Gitdir, "logs"), func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if err != nil || d.IsDir() {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for _, line := range strings.Split(string(content), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) < 2 {
+				continue
+			}
+			for _, sha := range fields[:2] {
+				if sha != zero && obj.ObjectExists(repository, sha) {
+					shas = append(shas, sha)
+				}
+			}
+		}
+		return nil
+	})
+	return shas, err
+}
+
+// repackRun writes every reachable object into one new pack. With remove set,
+// older packs and loose copies of packed objects are deleted afterwards, and
+// unreachable objects from the old packs are loosened so that pruning can
+// apply its grace period to them. It returns the set of reachable objects.
+func repackRun(repository *repo.Repository, remove bool) (map[string]bool, error) {
+	roots, err := reachableRoots(repository)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := obj.ObjectWalk(repository, roots, nil)
+	if err != nil {
+		return nil, err
+	}
+	reachable := make(map[string]bool)
+	for _, entry := range entries {
+		reachable[entry.Sha] = true
+	}
+
+	oldpacks, err := obj.PackList(repository)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("Nothing new to pack.")
+	} else {
+		packpath, deltas, err := obj.PackWrite(repository, entries)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("Packed %d objects (%d deltas) into %s\n", len(entries), deltas, filepath.Base(packpath))
+		for i, oldpack := range oldpacks {
+			if oldpack == packpath {
+				oldpacks = append(oldpacks[:i], oldpacks[i+1:]...)
+				break
+			}
+		}
+	}
+
+	if !remove {
+		return reachable, nil
+	}
+
+	for _, oldpack := range oldpacks {
+		err := repackLoosen(repository, oldpack, reachable)
+		if err != nil {
+			return nil, err
+		}
+		err = obj.PackRemove(repository, oldpack)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	loose, err := obj.ObjectLooseList(repository)
+	if err != nil {
+		return nil, err
+	}
+	removed := 0
+	for _, sha := range loose {
+		if !reachable[sha] {
+			continue
+		}
+		err := os.Remove(filepath.Join(repository.Gitdir, "objects", sha[:2], sha[2:]))
+		if err != nil {
+			return nil, err
+		}
+		removed++
+	}
+	if removed > 0 {
+		fmt.Printf("Removed %d loose objects\n", removed)
+	}
+	repackRemoveEmptyDirs(repository)
+
+	return reachable, nil
+}
+
+func repackLoosen(repository *repo.Repository, packpath string, reachable map[string]bool) error {
+	info, err := os.Stat(packpath)
+	if err != nil {
+		return err
+	}
+	shas, err := obj.PackObjects(packpath)
+	if err != nil {
+		return err
+	}
+
+	for _, sha := range shas {
+		if reachable[sha] {
+			continue
+		}
+		objectpath := filepath.Join(repository.Gitdir, "objects", sha[:2], sha[2:])
+		if _, err := os.Stat(objectpath); err == nil {
+			continue
+		}
+
+		format, data, err := obj.ObjectReadRaw(repository, sha)
+		if err != nil {
+			return err
+		}
+		_, err = obj.ObjectWriteRaw(repository, format, data)
+		if err != nil {
+			return err
+		}
+		err = os.Chtimes(objectpath, info.ModTime(), info.ModTime())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func repackRemoveEmptyDirs(repository *repo.Repository) {
+	objectsdir := filepath.Join(repository.Gitdir, "objects")
+	dirs, err := os.ReadDir(objectsdir)
+	if err != nil {
+		return
+	}
+	for _, dir := range dirs {
+		if dir.IsDir() && len(dir.Name()) == 2 {
+			os.Remove(filepath.Join(objectsdir, dir.Name()))
+		}
+	}
+}
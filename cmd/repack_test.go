@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/Jcho114/go-git/ref"
+)
+
+func TestRepackKeepsReflogCommits(t *testing.T) {
+	repository := testRepository(t)
+	first := testCommit(t, repository, "master", true, map[string]string{"a.txt": "a\n"}, "first")
+	second := testCommit(t, repository, "master", true, map[string]string{"a.txt": "b\n"}, "second")
+
+	// git reset --hard HEAD~ leaves second reachable only from the reflog.
+	err := ref.RefWrite(repository, "refs/heads/master", first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ref.RefLogAppend(repository, "refs/heads/master", second, first, "Tester <t@example.com> 1700000000 +0000", "reset: moving to HEAD~")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reachable, err := repackRun(repository, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reachable[second] {
+		t.Errorf("repack dropped %s, which the reflog still names", second)
+	}
+}
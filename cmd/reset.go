@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/Jcho114/go-git/index"
+	"github.com/Jcho114/go-git/obj"
+	"github.com/Jcho114/go-git/ref"
+	"github.com/Jcho114/go-git/repo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	resetsoft  bool
+	resetmixed bool
+	resethard  bool
+)
+
+func init() {
+	resetCmd.Flags().BoolVar(&resetsoft, "soft", false, "only move HEAD, leaving the index and working tree alone")
+	resetCmd.Flags().BoolVar(&resetmixed, "mixed", false, "move HEAD and reset the index but not the working tree (default)")
+	resetCmd.Flags().BoolVar(&resethard, "hard", false, "move HEAD and reset both the index and the working tree")
+	resetCmd.MarkFlagsMutuallyExclusive("soft", "mixed", "hard")
+	rootCmd.AddCommand(resetCmd)
+}
+
+var resetCmd = &cobra.Command{
+	Use:   "reset",
+	Short: "a very attempt at resetting current HEAD to the specified state",
+	Long:  "a very very bad attempt at resetting current HEAD to the specified state from scratch",
+	Args:  cobra.MatchAll(cobra.ArbitraryArgs, cobra.OnlyValidArgs),
+	RunE:  runReset,
+}
+
+func runReset(cmd *cobra.Command, args []string) error {
+	repository, err := repo.FindRepository(".", true)
+	if err != nil {
+		return err
+	}
+
+	// Like git, the first argument names the commit when it resolves to one,
+	// and everything after it (or after "--") is a path.
+	revision := ""
+	paths := args
+	dash := cmd.ArgsLenAtDash()
+	if dash > 1 {
+		return fmt.Errorf("only one commit can be given before '--'")
+	}
+	if dash == 1 {
+		revision, paths = args[0], args[1:]
+	} else if dash == -1 && len(args) > 0 {
+		if _, err := obj.ObjectFind(repository, args[0], "commit", true); err == nil {
+			revision, paths = args[0], args[1:]
+		}
+	}
+
+	head, err := ref.RefResolve(repository, "HEAD")
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	target := head
+	if revision != "" {
+		target, err = obj.ObjectFind(repository, revision, "commit", true)
+		if err != nil {
+			return fmt.Errorf("failed to resolve '%s' as a valid revision", revision)
+		}
+	}
+
+	if len(paths) > 0 {
+		if resetsoft || resethard {
+			return fmt.Errorf("cannot do a %s reset with paths", map[bool]string{true: "soft", false: "hard"}[resetsoft])
+		}
+		names := []string{}
+		for _, path := range paths {
+			name, err := worktreeRelative(repository, path)
+			if err != nil {
+				return err
+			}
+			names = append(names, name)
+		}
+		err = resetIndex(repository, target, names)
+		if err != nil {
+			return err
+		}
+		return resetReportUnstaged(repository)
+	}
+
+	if target == "" {
+		return fmt.Errorf("failed to resolve 'HEAD' as a valid ref")
+	}
+
+	switch {
+	case resetsoft:
+		if _, err := os.Stat(filepath.Join(repository.Gitdir, "MERGE_HEAD")); err == nil {
+			return fmt.Errorf("cannot do a soft reset in the middle of a merge")
+		}
+	case resethard:
+		err = checkoutSwitch(repository, head, target, "reset", true)
+	default:
+		err = resetIndex(repository, target, nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	if head != "" {
+		err = ref.RefWrite(repository, "ORIG_HEAD", head)
+		if err != nil {
+			return err
+		}
+	}
+	err = ref.RefUpdate(repository, "HEAD", target)
+	if err != nil {
+		return err
+	}
+	err = mergeStateClear(repository)
+	if err != nil {
+		return err
+	}
+	err = sequencerStateClear(repository)
+	if err != nil {
+		return err
+	}
+
+	if !resetsoft && !resethard {
+		return resetReportUnstaged(repository)
+	}
+	if resethard {
+		summary, err := commitSummary(repository, target)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("HEAD is now at %s %s\n", target[:7], summary)
+	}
+	return nil
+}
+
+// resetIndex makes the index entries under the given paths (or all of them
+// when there are none) match the tree of commit, keeping the stat data of
+// entries that do not change so unmodified files are not rehashed.
+func resetIndex(repository *repo.Repository, commit string, paths []string) error {
+	target, err := commitTreeEntries(repository, commit)
+	if err != nil {
+		return err
+	}
+	ind, err := index.IndexRead(repository)
+	if err != nil {
+		return err
+	}
+
+	entries := []index.IndexEntry{}
+	kept := make(map[string]bool)
+	for _, entry := range ind.Entries {
+		if !pathspecMatch(entry.Name, paths) {
+			entries = append(entries, entry)
+			continue
+		}
+		if entry.Flagstage == 0 && leafMatchesEntry(target[entry.Name], entry) {
+			entries = append(entries, entry)
+			kept[entry.Name] = true
+		}
+	}
+	for name, leaf := range target {
+		if kept[name] || !pathspecMatch(name, paths) {
+			continue
+		}
+		mode, err := strconv.ParseInt(leaf.Mode, 8, 32)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, index.IndexEntry{
+			Name:      name,
+			Sha:       leaf.Sha,
+			Modetype:  int(mode >> 12),
+			Modeperms: int(mode & 0o777),
+		})
+	}
+
+	ind.Entries = entries
+	ind.InvalidateCaches()
+	return index.IndexWrite(repository, ind)
+}
+
+func resetReportUnstaged(repository *repo.Repository) error {
+	report, err := statusCollect(repository)
+	if err != nil {
+		return err
+	}
+	if len(report.Unstaged) == 0 {
+		return nil
+	}
+
+	unstaged := []string{}
+	for name := range report.Unstaged {
+		unstaged = append(unstaged, name)
+	}
+	sort.Strings(unstaged)
+	fmt.Println("Unstaged changes after reset:")
+	for _, name := range unstaged {
+		fmt.Printf("%s\t%s\n", report.Unstaged[name], name)
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/Jcho114/go-git/index"
+	"github.com/Jcho114/go-git/obj"
+	"github.com/Jcho114/go-git/ref"
+	"github.com/Jcho114/go-git/repo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	restoresource   string
+	restorestaged   bool
+	restoreworktree bool
+)
+
+func init() {
+	restoreCmd.Flags().StringVarP(&restoresource, "source", "s", "", "restore the working tree files with the content from the given tree")
+	restoreCmd.Flags().BoolVarP(&restorestaged, "staged", "S", false, "restore the index")
+	restoreCmd.Flags().BoolVarP(&restoreworktree, "worktree", "W", false, "restore the working tree (default)")
+	rootCmd.AddCommand(restoreCmd)
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "a very attempt at restoring working tree files",
+	Long:  "a very very bad attempt at restoring working tree files from scratch",
+	Args:  cobra.MatchAll(cobra.MinimumNArgs(1), cobra.OnlyValidArgs),
+	RunE:  runRestore,
+}
+
+func runRestore(cmd *cobra.Command, args []string) error {
+	repository, err := repo.FindRepository(".", true)
+	if err != nil {
+		return err
+	}
+
+	if !restorestaged {
+		restoreworktree = true
+	}
+
+	// The worktree is restored from the index unless a source is given, while
+	// the index can only be restored from a commit, HEAD by default.
+	source := ""
+	if restoresource != "" {
+		source, err = obj.ObjectFind(repository, restoresource, "commit", true)
+		if err != nil {
+			return fmt.Errorf("could not resolve %s", restoresource)
+		}
+	} else if restorestaged {
+		source, err = ref.RefResolve(repository, "HEAD")
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	fromindex := restoresource == "" && !restorestaged
+
+	paths := []string{}
+	for _, arg := range args {
+		name, err := worktreeRelative(repository, arg)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, name)
+	}
+
+	ind, err := index.IndexRead(repository)
+	if err != nil {
+		return err
+	}
+	tree, err := commitTreeEntries(repository, source)
+	if err != nil {
+		return err
+	}
+
+	for i, path := range paths {
+		matched := false
+		for _, entry := range ind.Entries {
+			matched = matched || pathWithin(entry.Name, path)
+		}
+		if !fromindex {
+			for name := range tree {
+				matched = matched || pathWithin(name, path)
+			}
+		}
+		if !matched {
+			return fmt.Errorf("pathspec '%s' did not match any file(s) known to git", args[i])
+		}
+	}
+
+	if restoreworktree {
+		if fromindex {
+			err = restoreFromIndex(repository, ind, paths)
+		} else {
+			err = restoreFromTree(repository, ind, tree, paths)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if restorestaged {
+		return resetIndex(repository, source, paths)
+	}
+	return nil
+}
+
+// restoreFromIndex checks out the staged version of every matching path. The
+// rewritten files get fresh stat data in the index so they are not reported
+// as modified afterwards.
+func restoreFromIndex(repository *repo.Repository, ind *index.Index, paths []string) error {
+	for _, entry := range ind.Entries {
+		if entry.Flagstage != 0 && pathspecMatch(entry.Name, paths) {
+			return fmt.Errorf("path '%s' is unmerged", entry.Name)
+		}
+	}
+
+	for i, entry := range ind.Entries {
+		if !pathspecMatch(entry.Name, paths) || entry.Modetype == 0b1110 {
+			continue
+		}
+		leaf := obj.NewTreeLeaf(indexEntryMode(entry), entry.Name, entry.Sha)
+		checkedout, err := worktreeCheckout(repository, leaf)
+		if err != nil {
+			return err
+		}
+		checkedout.Flagintenttoadd = entry.Flagintenttoadd
+		checkedout.Flagskipworktree = entry.Flagskipworktree
+		ind.Entries[i] = checkedout
+	}
+
+	ind.InvalidateCaches()
+	return index.IndexWrite(repository, ind)
+}
+
+// restoreFromTree writes the matching files of a source tree into the
+// worktree without touching the index. Tracked files that do not exist in the
+// source are removed, the same as git's default no-overlay mode.
+func restoreFromTree(repository *repo.Repository, ind *index.Index, tree map[string]*obj.TreeLeaf, paths []string) error {
+	for _, entry := range ind.Entries {
+		if !pathspecMatch(entry.Name, paths) || tree[entry.Name] != nil {
+			continue
+		}
+		err := worktreeRemove(repository, entry.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	names := []string{}
+	for name := range tree {
+		if pathspecMatch(name, paths) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if tree[name].Mode == "160000" {
+			continue
+		}
+		_, err := worktreeCheckout(repository, tree[name])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/Jcho114/go-git/graph"
+	"github.com/Jcho114/go-git/repo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	revlistcount     bool
+	revlistreverse   bool
+	revlisttopoorder bool
+	revlistall       bool
+	revlistleftright bool
+	revlistmaxcount  int
+	revlistnot       revisionNot
+)
+
+func init() {
+	revlistnot.flags = revListCmd.Flags()
+	revListCmd.Flags().BoolVar(&revlistcount, "count", false, "print a number stating how many commits would have been listed")
+	revListCmd.Flags().BoolVar(&revlistreverse, "reverse", false, "output the commits chosen to be shown in reverse order")
+	revListCmd.Flags().BoolVar(&revlisttopoorder, "topo-order", false, "show no parents before all of their children are shown")
+	revListCmd.Flags().BoolVar(&revlistall, "all", false, "pretend as if all the refs and HEAD are listed on the command line")
+	revListCmd.Flags().BoolVar(&revlistleftright, "left-right", false, "mark which side of a symmetric difference a commit is reachable from")
+	revListCmd.Flags().IntVarP(&revlistmaxcount, "max-count", "n", -1, "limit the number of commits to output")
+	revListCmd.Flags().VarPF(&revlistnot, "not", "", "reverse the meaning of the ^ prefix for all following revisions").NoOptDefVal = "true"
+	rootCmd.AddCommand(revListCmd)
+}
+
+var revListCmd = &cobra.Command{
+	Use:   "rev-list",
+	Short: "a very attempt at listing commit objects in reverse chronological order",
+	Long:  "a very very bad attempt at listing commit objects in reverse chronological order from scratch",
+	Args:  cobra.MatchAll(cobra.ArbitraryArgs, cobra.OnlyValidArgs),
+	RunE:  runRevList,
+}
+
+func runRevList(cmd *cobra.Command, args []string) error {
+	repository, err := repo.FindRepository(".", true)
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 && !revlistall {
+		return fmt.Errorf("rev-list needs at least one revision")
+	}
+	revisions, paths, err := revisionArgs(repository, args, cmd.ArgsLenAtDash(), revlistnot.positions)
+	if err != nil {
+		return err
+	}
+	if revlistall {
+		refs, err := revisionAll(repository)
+		if err != nil {
+			return err
+		}
+		revisions.Include = append(revisions.Include, refs...)
+	}
+
+	g := graph.NewGraph(repository)
+	order, _, err := revisions.Walk(g, revisionSimplify(repository, paths), revlisttopoorder, revlistmaxcount)
+	if err != nil {
+		return err
+	}
+	if revlistmaxcount >= 0 && len(order) > revlistmaxcount {
+		order = order[:revlistmaxcount]
+	}
+	if revlistreverse {
+		slices.Reverse(order)
+	}
+
+	marks := make(map[string]string)
+	if revlistleftright {
+		marks, err = revisions.Sides(g, order)
+		if err != nil {
+			return err
+		}
+	}
+
+	if revlistcount {
+		if !revlistleftright {
+			fmt.Println(len(order))
+			return nil
+		}
+		left := 0
+		for _, sha := range order {
+			if marks[sha] == "<" {
+				left++
+			}
+		}
+		fmt.Printf("%d\t%d\n", left, len(order)-left)
+		return nil
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	for _, sha := range order {
+		out.WriteString(marks[sha] + sha + "\n")
+	}
+	return nil
+}
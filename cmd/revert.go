@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/Jcho114/go-git/repo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	revertcontinue bool
+	revertskip     bool
+	revertabort    bool
+)
+
+func init() {
+	revertCmd.Flags().BoolVar(&revertcontinue, "continue", false, "continue the operation in progress after resolving conflicts")
+	revertCmd.Flags().BoolVar(&revertskip, "skip", false, "skip the current commit and continue with the rest of the sequence")
+	revertCmd.Flags().BoolVar(&revertabort, "abort", false, "cancel the operation and return to the pre-sequence state")
+	rootCmd.AddCommand(revertCmd)
+}
+
+var revertCmd = &cobra.Command{
+	Use:   "revert",
+	Short: "a very attempt at reverting some existing commits",
+	Long:  "a very very bad attempt at reverting some existing commits from scratch",
+	Args:  cobra.MatchAll(cobra.ArbitraryArgs, cobra.OnlyValidArgs),
+	RunE:  runRevert,
+}
+
+func runRevert(cmd *cobra.Command, args []string) error {
+	repository, err := repo.FindRepository(".", true)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case revertcontinue:
+		return sequencerContinue(repository)
+	case revertskip:
+		return sequencerSkip(repository)
+	case revertabort:
+		return sequencerAbort(repository)
+	}
+
+	if len(args) == 0 {
+		return fmt.Errorf("no commits specified to revert")
+	}
+	return sequencerStart(repository, "revert", args)
+}
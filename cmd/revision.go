@@ -0,0 +1,170 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/Jcho114/go-git/graph"
+	"github.com/Jcho114/go-git/obj"
+	"github.com/Jcho114/go-git/ref"
+	"github.com/Jcho114/go-git/repo"
+	"github.com/Jcho114/go-git/rev"
+)
+
+// revisionNot is the --not flag of commands that take revisions. It flips the
+// meaning of the revisions after it, so it records how many arguments came
+// before each time it is given.
+type revisionNot struct {
+	flags     interface{ NArg() int }
+	positions []int
+}
+
+func (n *revisionNot) String() string { return "false" }
+func (n *revisionNot) Type() string   { return "bool" }
+func (n *revisionNot) Set(value string) error {
+	n.positions = append(n.positions, n.flags.NArg())
+	return nil
+}
+
+// revisionArgs splits log style arguments into the revisions to walk and the
+// paths to limit to. Without a "--" separator, arguments are revisions up to
+// the first one that does not resolve, and the rest must be paths that exist.
+// HEAD is walked when no revisions are given.
+func revisionArgs(repository *repo.Repository, args []string, dash int, nots []int) (*rev.Revisions, []string, error) {
+	count, paths := len(args), []string{}
+	if dash >= 0 {
+		count, paths = dash, args[dash:]
+	} else {
+		for i, arg := range args {
+			if _, err := rev.RevisionParse(repository, []string{arg}); err == nil {
+				continue
+			}
+			for _, path := range args[i:] {
+				if _, err := os.Lstat(path); err != nil {
+					return nil, nil, fmt.Errorf("ambiguous argument '%s': unknown revision or path not in the working tree", path)
+				}
+			}
+			count, paths = i, args[i:]
+			break
+		}
+	}
+
+	revisions := []string{}
+	for i, arg := range args[:count] {
+		for _, position := range nots {
+			if position == i {
+				revisions = append(revisions, "--not")
+			}
+		}
+		revisions = append(revisions, arg)
+	}
+
+	res, err := rev.RevisionParse(repository, revisions)
+	if err != nil {
+		return nil, nil, err
+	}
+	if count == 0 {
+		head, err := obj.ObjectFind(repository, "HEAD", "commit", true)
+		if err != nil {
+			return nil, nil, fmt.Errorf("your current branch does not have any commits yet")
+		}
+		res.Include = append(res.Include, head)
+	}
+
+	names := []string{}
+	for _, path := range paths {
+		name, err := worktreeRelative(repository, path)
+		if err != nil {
+			return nil, nil, err
+		}
+		names = append(names, name)
+	}
+	return res, names, nil
+}
+
+// revisionAll returns the commit HEAD points to followed by the commits
+// pointed to by every ref, sorted by ref name.
+func revisionAll(repository *repo.Repository) ([]string, error) {
+	refmap, err := ref.RefList(repository, "")
+	if err != nil {
+		return nil, err
+	}
+	refs := ref.RefFlatten(refmap, "refs")
+	names := []string{}
+	for name := range refs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	res := []string{}
+	head, err := obj.ObjectFind(repository, "HEAD", "commit", true)
+	if err == nil {
+		res = append(res, head)
+	}
+	for _, name := range names {
+		sha, err := obj.ObjectFind(repository, refs[name], "commit", true)
+		if err == nil {
+			res = append(res, sha)
+		}
+	}
+	return res, nil
+}
+
+// revisionSimplify limits the walk to the history of the given paths. A
+// commit whose paths of interest match one of its parents is hidden and only
+// that parent is followed, the same as git's default history simplification.
+func revisionSimplify(repository *repo.Repository, paths []string) graph.Simplify {
+	trees := make(map[string]map[string]string)
+	selected := func(sha string) (map[string]string, error) {
+		if entries, ok := trees[sha]; ok {
+			return entries, nil
+		}
+		leaves, err := commitTreeEntries(repository, sha)
+		if err != nil {
+			return nil, err
+		}
+		entries := make(map[string]string)
+		for name, leaf := range leaves {
+			if pathspecMatch(name, paths) {
+				entries[name] = leaf.Mode + " " + leaf.Sha
+			}
+		}
+		trees[sha] = entries
+		return entries, nil
+	}
+	treesame := func(a map[string]string, b map[string]string) bool {
+		if len(a) != len(b) {
+			return false
+		}
+		for name, value := range a {
+			if b[name] != value {
+				return false
+			}
+		}
+		return true
+	}
+
+	return func(sha string, parents []string) ([]string, bool, error) {
+		if len(paths) == 0 {
+			return parents, true, nil
+		}
+		entries, err := selected(sha)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(parents) == 0 && len(entries) == 0 {
+			return parents, false, nil
+		}
+		for _, parent := range parents {
+			parententries, err := selected(parent)
+			if err != nil {
+				return nil, false, err
+			}
+			if treesame(entries, parententries) {
+				return []string{parent}, false, nil
+			}
+		}
+		return parents, true, nil
+	}
+}
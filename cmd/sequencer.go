@@ -0,0 +1,403 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Jcho114/go-git/index"
+	"github.com/Jcho114/go-git/obj"
+	"github.com/Jcho114/go-git/ref"
+	"github.com/Jcho114/go-git/repo"
+)
+
+// .git/sequencer holds "head" from before the run, "abort-safety" from before
+// the latest step and the remaining "todo".
+
+func sequencerPath(repository *repo.Repository, name string) string {
+	return filepath.Join(repository.Gitdir, "sequencer", name)
+}
+
+func sequencerInProgress(repository *repo.Repository) bool {
+	_, err := os.Stat(sequencerPath(repository, ""))
+	return err == nil
+}
+
+func sequencerStart(repository *repo.Repository, action string, args []string) error {
+	if sequencerInProgress(repository) || sequencerStopped(repository) {
+		return fmt.Errorf("a cherry-pick or revert is already in progress, use --continue, --skip or --abort")
+	}
+
+	head, err := ref.RefResolve(repository, "HEAD")
+	if err != nil {
+		return fmt.Errorf("cannot %s without a current commit", action)
+	}
+
+	todo := []string{}
+	for _, arg := range args {
+		sha, err := obj.ObjectFind(repository, arg, "commit", true)
+		if err != nil {
+			return fmt.Errorf("bad revision '%s'", arg)
+		}
+		if len(args) == 1 {
+			return sequencerApply(repository, action, sha)
+		}
+		summary, err := commitSummary(repository, sha)
+		if err != nil {
+			return err
+		}
+		todo = append(todo, fmt.Sprintf("%s %s %s", action, sha, summary))
+	}
+
+	err = os.MkdirAll(sequencerPath(repository, ""), 0755)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(sequencerPath(repository, "head"), []byte(head+"\n"), 0644)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(sequencerPath(repository, "todo"), []byte(strings.Join(todo, "\n")+"\n"), 0644)
+	if err != nil {
+		return err
+	}
+
+	return sequencerRun(repository)
+}
+
+func sequencerRun(repository *repo.Repository) error {
+	for {
+		content, err := os.ReadFile(sequencerPath(repository, "todo"))
+		if err != nil {
+			return err
+		}
+		todo := strings.Split(strings.TrimSpace(string(content)), "\n")
+		if todo[0] == "" {
+			return os.RemoveAll(sequencerPath(repository, ""))
+		}
+
+		fields := strings.SplitN(todo[0], " ", 3)
+		if len(fields) < 2 || (fields[0] != "pick" && fields[0] != "revert") {
+			return fmt.Errorf("invalid todo line '%s'", todo[0])
+		}
+		rest := strings.Join(todo[1:], "\n")
+		if rest != "" {
+			rest += "\n"
+		}
+		err = os.WriteFile(sequencerPath(repository, "todo"), []byte(rest), 0644)
+		if err != nil {
+			return err
+		}
+
+		head, err := ref.RefResolve(repository, "HEAD")
+		if err != nil {
+			return err
+		}
+		err = os.WriteFile(sequencerPath(repository, "abort-safety"), []byte(head+"\n"), 0644)
+		if err != nil {
+			return err
+		}
+		err = sequencerApply(repository, fields[0], fields[1])
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func sequencerCleanup(repository *repo.Repository) error {
+	content, err := os.ReadFile(sequencerPath(repository, "todo"))
+	if errors.Is(err, os.ErrNotExist) || (err == nil && strings.TrimSpace(string(content)) != "") {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(sequencerPath(repository, ""))
+}
+
+func sequencerApply(repository *repo.Repository, action string, sha string) error {
+	object, err := obj.ObjectRead(repository, sha)
+	if err != nil {
+		return err
+	}
+	commit, ok := object.(*obj.Commit)
+	if !ok {
+		return fmt.Errorf("object %s is not a commit", sha)
+	}
+	parents := commit.Kvlm["parent"]
+	if len(parents) > 1 {
+		return fmt.Errorf("commit %s is a merge, which cannot be %s", sha, map[string]string{"pick": "cherry-picked", "revert": "reverted"}[action])
+	}
+	parent := ""
+	if len(parents) == 1 {
+		parent = parents[0]
+	}
+
+	head, err := ref.RefResolve(repository, "HEAD")
+	if err != nil {
+		return err
+	}
+	headtree, err := commitTreeEntries(repository, head)
+	if err != nil {
+		return err
+	}
+	err = mergeCheckIndex(repository, headtree)
+	if err != nil {
+		return err
+	}
+	summary, err := commitSummary(repository, sha)
+	if err != nil {
+		return err
+	}
+
+	var conflicts []string
+	message, author, pseudoref := "", "", "CHERRY_PICK_HEAD"
+	if action == "pick" {
+		if len(commit.Kvlm[""]) > 0 {
+			message = commit.Kvlm[""][0]
+		}
+		if len(commit.Kvlm["author"]) > 0 {
+			author = commit.Kvlm["author"][0]
+		}
+		conflicts, err = pickApply(repository, head, parent, sha, sha[:7]+" ("+summary+")")
+	} else {
+		pseudoref = "REVERT_HEAD"
+		message = fmt.Sprintf("Revert \"%s\"\n\nThis reverts commit %s.\n", summary, sha)
+		conflicts, err = pickApply(repository, head, sha, parent, "parent of "+sha[:7]+" ("+summary+")")
+	}
+	if err != nil {
+		return err
+	}
+
+	prepared := message
+	if len(conflicts) > 0 {
+		prepared += "\n# Conflicts:\n"
+		for _, conflict := range conflicts {
+			prepared += "#\t" + conflict + "\n"
+		}
+	}
+	err = ref.RefWrite(repository, pseudoref, sha)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(filepath.Join(repository.Gitdir, "MERGE_MSG"), []byte(prepared), 0644)
+	if err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		verb := map[string]string{"pick": "apply", "revert": "revert"}[action]
+		return fmt.Errorf("could not %s %s... %s\nafter resolving the conflicts, mark them with \"add\" and run \"--continue\", or use \"--skip\" or \"--abort\"", verb, sha[:7], summary)
+	}
+
+	err = sequencerCommit(repository, head, message, author)
+	if err != nil {
+		return err
+	}
+	return sequencerStateClear(repository)
+}
+
+func sequencerCommit(repository *repo.Repository, head string, message string, author string) error {
+	ind, err := index.IndexRead(repository)
+	if err != nil {
+		return err
+	}
+	tree, err := treeFromIndex(repository, ind)
+	if err != nil {
+		return err
+	}
+	headtree, err := obj.ObjectFind(repository, head, "tree", true)
+	if err != nil {
+		return err
+	}
+	if tree == headtree {
+		return fmt.Errorf("the previous commit is now empty, use --skip to drop it or --abort to stop")
+	}
+
+	commitname, err := commitCreate(repository, tree, []string{head}, message, author)
+	if err != nil {
+		return err
+	}
+	err = ref.RefUpdate(repository, "HEAD", commitname)
+	if err != nil {
+		return err
+	}
+	return commitReport(repository, commitname, message, false)
+}
+
+func sequencerPickAuthor(repository *repo.Repository) (string, error) {
+	content, err := os.ReadFile(filepath.Join(repository.Gitdir, "CHERRY_PICK_HEAD"))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	object, err := obj.ObjectRead(repository, strings.TrimSpace(string(content)))
+	if err != nil {
+		return "", err
+	}
+	commit, ok := object.(*obj.Commit)
+	if !ok || len(commit.Kvlm["author"]) == 0 {
+		return "", nil
+	}
+	return commit.Kvlm["author"][0], nil
+}
+
+func sequencerStateClear(repository *repo.Repository) error {
+	for _, name := range []string{"CHERRY_PICK_HEAD", "REVERT_HEAD", "MERGE_MSG"} {
+		err := os.Remove(filepath.Join(repository.Gitdir, name))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func sequencerStopped(repository *repo.Repository) bool {
+	for _, name := range []string{"CHERRY_PICK_HEAD", "REVERT_HEAD"} {
+		if _, err := os.Stat(filepath.Join(repository.Gitdir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+func sequencerContinue(repository *repo.Repository) error {
+	if !sequencerInProgress(repository) && !sequencerStopped(repository) {
+		return fmt.Errorf("no cherry-pick or revert in progress")
+	}
+
+	ind, err := index.IndexRead(repository)
+	if err != nil {
+		return err
+	}
+	for _, entry := range ind.Entries {
+		if entry.Flagstage != 0 {
+			return fmt.Errorf("you must edit all merge conflicts and then mark them as resolved using add")
+		}
+	}
+
+	if sequencerStopped(repository) {
+		_, message, err := commitMergeState(repository)
+		if err != nil {
+			return err
+		}
+		author, err := sequencerPickAuthor(repository)
+		if err != nil {
+			return err
+		}
+		head, err := ref.RefResolve(repository, "HEAD")
+		if err != nil {
+			return err
+		}
+		err = sequencerCommit(repository, head, commitMessage([]string{message}), author)
+		if err != nil {
+			return err
+		}
+		err = sequencerStateClear(repository)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !sequencerInProgress(repository) {
+		return nil
+	}
+	return sequencerRun(repository)
+}
+
+func sequencerSkip(repository *repo.Repository) error {
+	if !sequencerStopped(repository) {
+		return fmt.Errorf("no cherry-pick or revert in progress")
+	}
+
+	head, err := ref.RefResolve(repository, "HEAD")
+	if err != nil {
+		return err
+	}
+	err = checkoutSwitch(repository, head, head, "cherry-pick", true)
+	if err != nil {
+		return err
+	}
+	err = sequencerStateClear(repository)
+	if err != nil {
+		return err
+	}
+
+	if !sequencerInProgress(repository) {
+		return nil
+	}
+	return sequencerRun(repository)
+}
+
+func sequencerAbort(repository *repo.Repository) error {
+	if !sequencerInProgress(repository) && !sequencerStopped(repository) {
+		return fmt.Errorf("no cherry-pick or revert in progress")
+	}
+	head, err := ref.RefResolve(repository, "HEAD")
+	if err != nil {
+		return err
+	}
+
+	// A single pick or revert never moved HEAD, so only its changes are undone.
+	orighead := head
+	if sequencerInProgress(repository) {
+		content, err := os.ReadFile(sequencerPath(repository, "head"))
+		if err != nil {
+			return err
+		}
+		orighead = strings.TrimSpace(string(content))
+
+		// Commits made since the sequencer stopped are not thrown away.
+		content, err = os.ReadFile(sequencerPath(repository, "abort-safety"))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		if err == nil && strings.TrimSpace(string(content)) != head {
+			fmt.Fprintln(os.Stderr, "warning: You seem to have moved HEAD. Not rewinding, check your HEAD!")
+			err = sequencerStateClear(repository)
+			if err != nil {
+				return err
+			}
+			return os.RemoveAll(sequencerPath(repository, ""))
+		}
+	}
+
+	err = checkoutSwitch(repository, head, orighead, "cherry-pick", true)
+	if err != nil {
+		return err
+	}
+	err = ref.RefUpdate(repository, "HEAD", orighead)
+	if err != nil {
+		return err
+	}
+	err = sequencerStateClear(repository)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(sequencerPath(repository, ""))
+}
+
+func pickApply(repository *repo.Repository, head string, base string, pick string, label string) ([]string, error) {
+	basetree, err := commitTreeEntries(repository, base)
+	if err != nil {
+		return nil, err
+	}
+	ourtree, err := commitTreeEntries(repository, head)
+	if err != nil {
+		return nil, err
+	}
+	theirtree, err := commitTreeEntries(repository, pick)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := mergeTrees(repository, basetree, ourtree, theirtree, "HEAD", label)
+	if err != nil {
+		return nil, err
+	}
+	return mergeApplyResults(repository, ourtree, results)
+}
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Jcho114/go-git/ref"
+	"github.com/Jcho114/go-git/repo"
+)
+
+// testChdir runs the rest of a test from dir, which commands that find
+// their repository from the working directory need.
+func testChdir(t *testing.T, dir string) {
+	t.Helper()
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Chdir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(cwd) })
+}
+
+func testIdentity(t *testing.T) {
+	t.Helper()
+	for _, role := range []string{"AUTHOR", "COMMITTER"} {
+		t.Setenv("GIT_"+role+"_NAME", "Tester")
+		t.Setenv("GIT_"+role+"_EMAIL", "t@example.com")
+		t.Setenv("GIT_"+role+"_DATE", "1700000000 +0000")
+	}
+}
+
+// testPickSetup checks out master, where f.txt was changed one way, and
+// returns two commits on topic: one changing f.txt another way and one
+// adding g.txt.
+func testPickSetup(t *testing.T) (*repo.Repository, string, string) {
+	t.Helper()
+	testIdentity(t)
+	repository := testRepository(t)
+	base := testCommit(t, repository, "master", true, map[string]string{"f.txt": "base\n"}, "base")
+	err := ref.RefWrite(repository, "refs/heads/topic", base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conflicting := testCommit(t, repository, "topic", true, map[string]string{"f.txt": "topic\n"}, "change f")
+	clean := testCommit(t, repository, "topic", true, map[string]string{"f.txt": "topic\n", "g.txt": "g\n"}, "add g")
+	testCommit(t, repository, "master", true, map[string]string{"f.txt": "master\n"}, "master f")
+	err = checkoutSwitch(repository, "", testResolve(t, repository, "master"), "checkout", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testChdir(t, repository.Worktree)
+	return repository, conflicting, clean
+}
+
+// testResolveCommit resolves f.txt and concludes the stopped pick with a
+// plain add and commit.
+func testResolveCommit(t *testing.T, repository *repo.Repository) string {
+	t.Helper()
+	err := os.WriteFile(filepath.Join(repository.Worktree, "f.txt"), []byte("resolved\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = runAdd(nil, []string{"f.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitmessages = nil
+	err = runCommit(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testResolve(t, repository, "master")
+}
+
+func TestCherryPickSingleConcludedByCommit(t *testing.T) {
+	repository, conflicting, clean := testPickSetup(t)
+
+	err := sequencerStart(repository, "pick", []string{conflicting})
+	if err == nil || !strings.Contains(err.Error(), "could not apply") {
+		t.Fatalf("conflicting pick returned %v", err)
+	}
+	if sequencerInProgress(repository) {
+		t.Error("a single pick left a sequencer behind")
+	}
+	resolved := testResolveCommit(t, repository)
+	if sequencerStopped(repository) {
+		t.Error("commit left the pick stopped")
+	}
+
+	err = sequencerAbort(repository)
+	if err == nil {
+		t.Error("abort succeeded with nothing in progress")
+	}
+	if got := testResolve(t, repository, "master"); got != resolved {
+		t.Errorf("abort moved master to %s, want the resolved pick %s", got, resolved)
+	}
+	err = sequencerStart(repository, "pick", []string{clean})
+	if err != nil {
+		t.Errorf("the next pick failed: %v", err)
+	}
+}
+
+func TestCherryPickAbort(t *testing.T) {
+	repository, conflicting, clean := testPickSetup(t)
+	master := testResolve(t, repository, "master")
+
+	err := sequencerStart(repository, "pick", []string{clean, conflicting})
+	if err == nil {
+		t.Fatal("conflicting pick succeeded")
+	}
+	if !sequencerInProgress(repository) {
+		t.Fatal("a pick of several commits has no sequencer")
+	}
+	err = sequencerAbort(repository)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := testResolve(t, repository, "master"); got != master {
+		t.Errorf("abort left master at %s, want %s", got, master)
+	}
+	content, _ := os.ReadFile(filepath.Join(repository.Worktree, "f.txt"))
+	if string(content) != "master\n" {
+		t.Errorf("abort left f.txt as %q", content)
+	}
+	if sequencerInProgress(repository) || sequencerStopped(repository) {
+		t.Error("abort left cherry-pick state behind")
+	}
+}
+
+func TestCherryPickAbortKeepsLaterCommits(t *testing.T) {
+	repository, conflicting, clean := testPickSetup(t)
+
+	err := sequencerStart(repository, "pick", []string{conflicting, clean})
+	if err == nil {
+		t.Fatal("conflicting pick succeeded")
+	}
+	resolved := testResolveCommit(t, repository)
+	if !sequencerInProgress(repository) {
+		t.Fatal("commit dropped the sequencer with a pick still to do")
+	}
+
+	err = sequencerAbort(repository)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := testResolve(t, repository, "master"); got != resolved {
+		t.Errorf("abort moved master to %s past the commit %s made after the stop", got, resolved)
+	}
+	if sequencerInProgress(repository) {
+		t.Error("abort left the sequencer behind")
+	}
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/Jcho114/go-git/repo"
+	"github.com/Jcho114/go-git/transport"
+	"github.com/spf13/cobra"
+)
+
+var servelisten string
+
+func init() {
+	serveCmd.Flags().StringVarP(&servelisten, "listen", "l", "127.0.0.1:8080", "address to listen on")
+	rootCmd.AddCommand(serveCmd)
+}
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "a very attempt at serving the repository over smart HTTP",
+	Long:  "a very very bad attempt at serving the repository over smart HTTP from scratch",
+	Args:  cobra.MatchAll(cobra.NoArgs, cobra.OnlyValidArgs),
+	RunE:  runServe,
+}
+
+func runServe(cmd *cobra.Command, args []string) error {
+	repository, err := repo.FindRepository(".", true)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Serving %s on http://%s/\n", repository.Worktree, servelisten)
+	return http.ListenAndServe(servelisten, transport.NewHandler(repository, transport.NewHookScripts()))
+}
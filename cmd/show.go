@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Jcho114/go-git/obj"
+	"github.com/Jcho114/go-git/repo"
+	"github.com/spf13/cobra"
+)
+
+var (
+	showstat     bool
+	shownameonly bool
+)
+
+func init() {
+	showCmd.Flags().BoolVar(&showstat, "stat", false, "show a summary of the changed files instead of the patch")
+	showCmd.Flags().BoolVar(&shownameonly, "name-only", false, "show only the names of the changed files")
+	showCmd.MarkFlagsMutuallyExclusive("stat", "name-only")
+	rootCmd.AddCommand(showCmd)
+}
+
+var showCmd = &cobra.Command{
+	Use:   "show",
+	Short: "a very attempt at showing various types of objects",
+	Long:  "a very very bad attempt at showing various types of objects from scratch",
+	Args:  cobra.MatchAll(cobra.ArbitraryArgs, cobra.OnlyValidArgs),
+	RunE:  runShow,
+}
+
+func runShow(cmd *cobra.Command, args []string) error {
+	repository, err := repo.FindRepository(".", true)
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		args = []string{"HEAD"}
+	}
+
+	// Like git, a blank line separates everything but blobs from whatever
+	// was shown before, and a commit is only shown once.
+	shown := false
+	seen := make(map[string]bool)
+	for _, name := range args {
+		sha, err := obj.ObjectFind(repository, name, "any", true)
+		if err != nil {
+			return err
+		}
+
+		for sha != "" {
+			object, err := obj.ObjectRead(repository, sha)
+			if err != nil {
+				return err
+			}
+
+			next := ""
+			switch object := object.(type) {
+			case *obj.Blob:
+				os.Stdout.Write(object.Data)
+			case *obj.Tag:
+				if shown {
+					fmt.Println()
+				}
+				next = showTag(object)
+				shown = true
+			case *obj.Tree:
+				if shown {
+					fmt.Println()
+				}
+				fmt.Printf("tree %s\n\n", name)
+				for _, item := range object.Items {
+					if item.IsTree() {
+						fmt.Println(item.Path + "/")
+					} else {
+						fmt.Println(item.Path)
+					}
+				}
+				shown = true
+			case *obj.Commit:
+				if seen[sha] {
+					break
+				}
+				seen[sha] = true
+				if shown {
+					fmt.Println()
+				}
+				err := showCommit(repository, sha)
+				if err != nil {
+					return err
+				}
+				shown = true
+			}
+			sha = next
+		}
+	}
+	return nil
+}
+
+// showTag prints the tag header and message, and returns the tagged object
+// to be shown after it.
+func showTag(tag *obj.Tag) string {
+	if name := tag.Kvlm["tag"]; len(name) > 0 {
+		fmt.Printf("tag %s\n", name[0])
+	}
+	if tagger := tag.Kvlm["tagger"]; len(tagger) > 0 {
+		name, email, when := prettyIdent(tagger[0])
+		fmt.Printf("Tagger: %s <%s>\n", name, email)
+		fmt.Printf("Date:   %s\n", prettyDate(when))
+	}
+	if message := tag.Kvlm[""]; len(message) > 0 {
+		fmt.Printf("\n%s", message[0])
+	}
+
+	if object := tag.Kvlm["object"]; len(object) > 0 {
+		return object[0]
+	}
+	return ""
+}
+
+// showCommit prints the commit the way log does, followed by its changes
+// against its first parent, or against an empty tree for a root commit.
+func showCommit(repository *repo.Repository, sha string) error {
+	commit, err := prettyRead(repository, sha)
+	if err != nil {
+		return err
+	}
+	format, err := prettyParseFormat("medium")
+	if err != nil {
+		return err
+	}
+	fmt.Printf("commit %s\n", sha)
+	fmt.Print(prettyMessage(commit, format, commit.Parents))
+
+	parent := ""
+	if len(commit.Parents) > 0 {
+		parent = commit.Parents[0]
+	}
+	oldleaves, err := commitTreeEntries(repository, parent)
+	if err != nil {
+		return err
+	}
+	newleaves, err := commitTreeEntries(repository, sha)
+	if err != nil {
+		return err
+	}
+	oldside, newside := diffTreeSide(oldleaves), diffTreeSide(newleaves)
+	if len(diffChangedPaths(oldside, newside, nil)) == 0 {
+		return nil
+	}
+
+	fmt.Println()
+	switch {
+	case showstat:
+		return diffPrintStat(repository, oldside, newside, nil)
+	case shownameonly:
+		diffPrintNames(oldside, newside, nil)
+		return nil
+	}
+	return diffPrint(repository, oldside, newside, nil, 3)
+}
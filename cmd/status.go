@@ -0,0 +1,364 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Jcho114/go-git/ignore"
+	"github.com/Jcho114/go-git/index"
+	"github.com/Jcho114/go-git/obj"
+	"github.com/Jcho114/go-git/ref"
+	"github.com/Jcho114/go-git/repo"
+	"github.com/spf13/cobra"
+)
+
+var porcelain bool
+
+func init() {
+	statusCmd.Flags().BoolVar(&porcelain, "porcelain", false, "give the output in an easy-to-parse format for scripts")
+	rootCmd.AddCommand(statusCmd)
+}
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "a very attempt at showing the working tree status",
+	Long:  "a very very bad attempt at showing the working tree status from scratch",
+	Args:  cobra.NoArgs,
+	RunE:  runStatus,
+}
+
+type statusReport struct {
+	Branch    string
+	Head      string
+	Staged    map[string]string
+	Unstaged  map[string]string
+	Unmerged  map[string]string
+	Untracked []string
+}
+
+func runStatus(cmd *cobra.Command, args []string) error {
+	repository, err := repo.FindRepository(".", true)
+	if err != nil {
+		return err
+	}
+
+	report, err := statusCollect(repository)
+	if err != nil {
+		return err
+	}
+
+	if porcelain {
+		statusPorcelain(report)
+		return nil
+	}
+	return statusLong(repository, report)
+}
+
+func statusCollect(repository *repo.Repository) (*statusReport, error) {
+	report := &statusReport{
+		Staged:    make(map[string]string),
+		Unstaged:  make(map[string]string),
+		Unmerged:  make(map[string]string),
+		Untracked: []string{},
+	}
+
+	branch, err := ref.RefSymbolicRead(repository, "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	report.Branch = strings.TrimPrefix(branch, "refs/heads/")
+
+	head, err := ref.RefResolve(repository, "HEAD")
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	report.Head = head
+
+	headentries, err := commitTreeEntries(repository, head)
+	if err != nil {
+		return nil, err
+	}
+
+	ind, err := index.IndexRead(repository)
+	if err != nil {
+		return nil, err
+	}
+
+	indexinfo, err := os.Stat(filepath.Join(repository.Gitdir, "index"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	stages := make(map[string][]int)
+	tracked := make(map[string]bool)
+	trackeddirs := make(map[string]bool)
+	for _, entry := range ind.Entries {
+		tracked[entry.Name] = true
+		for dirname := path.Dir(entry.Name); dirname != "."; dirname = path.Dir(dirname) {
+			trackeddirs[dirname] = true
+		}
+
+		if entry.Flagstage != 0 {
+			stages[entry.Name] = append(stages[entry.Name], entry.Flagstage)
+			continue
+		}
+
+		leaf, ok := headentries[entry.Name]
+		if !ok {
+			report.Staged[entry.Name] = "A"
+		} else if leaf.Sha != entry.Sha || leaf.Mode != indexEntryMode(entry) {
+			if leaf.Mode[:2] != indexEntryMode(entry)[:2] {
+				report.Staged[entry.Name] = "T"
+			} else {
+				report.Staged[entry.Name] = "M"
+			}
+		}
+
+		state, err := worktreeState(repository, entry, indexinfo)
+		if err != nil {
+			return nil, err
+		}
+		if state != "" {
+			report.Unstaged[entry.Name] = state
+		}
+	}
+
+	for name, entrystages := range stages {
+		report.Unmerged[name] = statusUnmergedCode(entrystages)
+	}
+
+	for name := range headentries {
+		if !tracked[name] {
+			report.Staged[name] = "D"
+		}
+	}
+
+	rules, err := ignore.IgnoreRead(repository)
+	if err != nil {
+		return nil, err
+	}
+
+	untracked := make(map[string]bool)
+	err = filepath.WalkDir(repository.Worktree, func(walkpath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+
+		name, err := worktreeRelative(repository, walkpath)
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			return nil
+		}
+		if d.IsDir() && tracked[name] {
+			return filepath.SkipDir
+		}
+		if tracked[name] {
+			return nil
+		}
+
+		ignored, err := ignore.IgnoreCheck(rules, name, d.IsDir())
+		if err != nil {
+			return err
+		}
+		if ignored && d.IsDir() {
+			return filepath.SkipDir
+		}
+		if ignored || d.IsDir() {
+			return nil
+		}
+
+		display := name
+		for dirname := path.Dir(name); dirname != "."; dirname = path.Dir(dirname) {
+			if !trackeddirs[dirname] {
+				display = dirname + "/"
+			}
+		}
+		untracked[display] = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for name := range untracked {
+		report.Untracked = append(report.Untracked, name)
+	}
+	sort.Strings(report.Untracked)
+
+	return report, nil
+}
+
+func statusUnmergedCode(stages []int) string {
+	present := [4]bool{}
+	for _, stage := range stages {
+		present[stage] = true
+	}
+
+	switch {
+	case present[1] && present[2] && present[3]:
+		return "UU"
+	case present[1] && present[2]:
+		return "UD"
+	case present[1] && present[3]:
+		return "DU"
+	case present[2] && present[3]:
+		return "AA"
+	case present[2]:
+		return "AU"
+	case present[3]:
+		return "UA"
+	default:
+		return "DD"
+	}
+}
+
+func statusPorcelain(report *statusReport) {
+	names := []string{}
+	seen := make(map[string]bool)
+	for _, group := range []map[string]string{report.Staged, report.Unstaged, report.Unmerged} {
+		for name := range group {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if code, ok := report.Unmerged[name]; ok {
+			fmt.Printf("%s %s\n", code, name)
+			continue
+		}
+
+		x, y := report.Staged[name], report.Unstaged[name]
+		if x == "" {
+			x = " "
+		}
+		if y == "" {
+			y = " "
+		}
+		fmt.Printf("%s%s %s\n", x, y, name)
+	}
+
+	for _, name := range report.Untracked {
+		fmt.Printf("?? %s\n", name)
+	}
+}
+
+func statusLong(repository *repo.Repository, report *statusReport) error {
+	if report.Branch != "" {
+		fmt.Printf("On branch %s\n", report.Branch)
+	} else {
+		fmt.Printf("HEAD detached at %s\n", report.Head[:7])
+	}
+	if report.Head == "" {
+		fmt.Printf("\nNo commits yet\n\n")
+	}
+
+	labels := map[string]string{
+		"A":  "new file",
+		"M":  "modified",
+		"D":  "deleted",
+		"T":  "typechange",
+		"UU": "both modified",
+		"AA": "both added",
+		"DD": "both deleted",
+		"UD": "deleted by them",
+		"DU": "deleted by us",
+		"AU": "added by us",
+		"UA": "added by them",
+	}
+	// Like git, labels are padded to the widest one their section can show,
+	// single letter codes being plain changes and pairs unmerged ones.
+	widths := make(map[int]int)
+	for code, label := range labels {
+		widths[len(code)] = max(widths[len(code)], len(label)+2)
+	}
+
+	sections := []struct {
+		title   string
+		entries map[string]string
+	}{
+		{"Changes to be committed:", report.Staged},
+		{"Unmerged paths:", report.Unmerged},
+		{"Changes not staged for commit:", report.Unstaged},
+	}
+
+	for _, section := range sections {
+		if len(section.entries) == 0 {
+			continue
+		}
+
+		names := []string{}
+		for name := range section.entries {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		fmt.Printf("%s\n", section.title)
+		for _, name := range names {
+			display, err := displayPath(repository, name)
+			if err != nil {
+				return err
+			}
+			code := section.entries[name]
+			fmt.Printf("\t%-*s%s\n", widths[len(code)], labels[code]+":", display)
+		}
+		fmt.Println()
+	}
+
+	if len(report.Untracked) > 0 {
+		fmt.Printf("Untracked files:\n")
+		for _, name := range report.Untracked {
+			display, err := displayPath(repository, name)
+			if err != nil {
+				return err
+			}
+			if strings.HasSuffix(name, "/") {
+				display += "/"
+			}
+			fmt.Printf("\t%s\n", display)
+		}
+		fmt.Println()
+	}
+
+	switch {
+	case len(report.Staged) > 0 || len(report.Unmerged) > 0:
+	case len(report.Unstaged) > 0:
+		fmt.Println("no changes added to commit")
+	case len(report.Untracked) > 0:
+		fmt.Println("nothing added to commit but untracked files present")
+	default:
+		fmt.Println("nothing to commit, working tree clean")
+	}
+
+	return nil
+}
+
+func commitTreeEntries(repository *repo.Repository, commit string) (map[string]*obj.TreeLeaf, error) {
+	if commit == "" {
+		return make(map[string]*obj.TreeLeaf), nil
+	}
+
+	treename, err := obj.ObjectFind(repository, commit, "tree", true)
+	if err != nil {
+		return nil, err
+	}
+	return obj.TreeFlatten(repository, treename, "")
+}
+
+func indexEntryMode(entry index.IndexEntry) string {
+	return fmt.Sprintf("%02o%04o", entry.Modetype, entry.Modeperms)
+}
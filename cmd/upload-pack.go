@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/Jcho114/go-git/repo"
+	"github.com/Jcho114/go-git/transport"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(uploadPackCmd)
+}
+
+var uploadPackCmd = &cobra.Command{
+	Use:   "upload-pack",
+	Short: "a very attempt at sending objects packed back to a fetch",
+	Long:  "a very very bad attempt at sending objects packed back to a fetch from scratch",
+	Args:  cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	RunE:  runUploadPack,
+}
+
+func runUploadPack(cmd *cobra.Command, args []string) error {
+	if !transport.UploadPackVersion(os.Getenv("GIT_PROTOCOL")) {
+		return transport.UploadPackRefuse(os.Stdout)
+	}
+	repository, err := repo.FindRepository(args[0], true)
+	if err != nil {
+		return err
+	}
+	err = transport.UploadPackAdvertise(os.Stdout)
+	if err != nil {
+		return err
+	}
+	return transport.UploadPack(repository, os.Stdin, os.Stdout)
+}
@@ -0,0 +1,452 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Jcho114/go-git/index"
+	"github.com/Jcho114/go-git/obj"
+	"github.com/Jcho114/go-git/repo"
+)
+
+func worktreeRead(repository *repo.Repository, name string, info os.FileInfo) ([]byte, error) {
+	path := worktreeAbsolute(repository, name)
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(target), nil
+	}
+	return os.ReadFile(path)
+}
+
+func worktreeRelative(repository *repo.Repository, path string) (string, error) {
+	worktree, err := filepath.Abs(repository.Worktree)
+	if err != nil {
+		return "", err
+	}
+	abspath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := filepath.Rel(worktree, abspath)
+	if err != nil {
+		return "", err
+	}
+	if rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", fmt.Errorf("path %s is outside repository at %s", path, worktree)
+	}
+	if rel == "." {
+		return "", nil
+	}
+
+	return filepath.ToSlash(rel), nil
+}
+
+func worktreeAbsolute(repository *repo.Repository, name string) string {
+	return filepath.Join(repository.Worktree, filepath.FromSlash(name))
+}
+
+func pathWithin(name string, prefix string) bool {
+	return prefix == "" || name == prefix || strings.HasPrefix(name, prefix+"/")
+}
+
+// pathspecMatch reports whether name lies within any of paths, where no paths
+// at all matches everything.
+func pathspecMatch(name string, paths []string) bool {
+	if len(paths) == 0 {
+		return true
+	}
+	for _, path := range paths {
+		if pathWithin(name, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// The stat data recorded in the index lets us skip hashing a file as long as
+// the file was not touched in the same second the index was written.
+func worktreeState(repository *repo.Repository, entry index.IndexEntry, indexinfo os.FileInfo) (string, error) {
+	if entry.Modetype == 0b1110 {
+		return "", nil
+	}
+
+	info, err := os.Lstat(worktreeAbsolute(repository, entry.Name))
+	if errors.Is(err, os.ErrNotExist) {
+		return "D", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "D", nil
+	}
+
+	issymlink := info.Mode()&os.ModeSymlink != 0
+	if issymlink != (entry.Modetype == 0b1010) {
+		return "T", nil
+	}
+
+	if !issymlink && repository.Config.Core.FileMode {
+		executable := info.Mode().Perm()&0o100 != 0
+		if executable != (entry.Modeperms == 0o755) {
+			return "M", nil
+		}
+	}
+
+	current := index.NewIndexEntry(entry.Name, entry.Sha, info)
+	racy := indexinfo == nil || !info.ModTime().Before(indexinfo.ModTime())
+	samestat := uint32(current.Mtime.Seconds) == uint32(entry.Mtime.Seconds) &&
+		current.Mtime.Nanoseconds == entry.Mtime.Nanoseconds &&
+		uint32(current.Fsize) == uint32(entry.Fsize) &&
+		uint32(current.Ino) == uint32(entry.Ino)
+	if !racy && samestat {
+		return "", nil
+	}
+
+	sha, err := worktreeHash(repository, entry.Name, info)
+	if err != nil {
+		return "", err
+	}
+	if sha != entry.Sha {
+		return "M", nil
+	}
+	return "", nil
+}
+
+func worktreeHash(repository *repo.Repository, name string, info os.FileInfo) (string, error) {
+	data, err := worktreeRead(repository, name, info)
+	if err != nil {
+		return "", err
+	}
+	return obj.ObjectWrite(nil, obj.NewBlob(data))
+}
+
+func displayPath(repository *repo.Repository, name string) (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	abspath, err := filepath.Abs(worktreeAbsolute(repository, name))
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(cwd, abspath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+func worktreeCheckout(repository *repo.Repository, leaf *obj.TreeLeaf) (index.IndexEntry, error) {
+	path := worktreeAbsolute(repository, leaf.Path)
+	mode, err := strconv.ParseInt(leaf.Mode, 8, 32)
+	if err != nil {
+		return index.IndexEntry{}, err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return index.IndexEntry{}, err
+	}
+
+	_, err = os.Lstat(path)
+	if err == nil {
+		err = os.Remove(path)
+		if err != nil {
+			return index.IndexEntry{}, err
+		}
+	}
+
+	if leaf.Mode[:2] == "16" {
+		err := os.MkdirAll(path, 0755)
+		if err != nil {
+			return index.IndexEntry{}, err
+		}
+	} else {
+		object, err := obj.ObjectRead(repository, leaf.Sha)
+		if err != nil {
+			return index.IndexEntry{}, err
+		}
+		blob, ok := object.(*obj.Blob)
+		if !ok {
+			return index.IndexEntry{}, fmt.Errorf("object %s is not a blob", leaf.Sha)
+		}
+
+		if leaf.Mode[:2] == "12" {
+			err = os.Symlink(string(blob.Data), path)
+		} else {
+			perm := os.FileMode(0644)
+			if mode&0o100 != 0 {
+				perm = 0755
+			}
+			err = os.WriteFile(path, blob.Data, perm)
+			if err == nil {
+				err = os.Chmod(path, perm)
+			}
+		}
+		if err != nil {
+			return index.IndexEntry{}, err
+		}
+	}
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		return index.IndexEntry{}, err
+	}
+	entry := index.NewIndexEntry(leaf.Path, leaf.Sha, info)
+	entry.Modetype = int(mode >> 12)
+	entry.Modeperms = int(mode & 0o777)
+	return entry, nil
+}
+
+func worktreeRemove(repository *repo.Repository, name string) error {
+	path := worktreeAbsolute(repository, name)
+	info, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() || info.Mode()&os.ModeSymlink != 0 {
+		err = os.Remove(path)
+		if err != nil {
+			return err
+		}
+	}
+
+	worktree, err := filepath.Abs(repository.Worktree)
+	if err != nil {
+		return err
+	}
+	dirname, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	for dirname != worktree && strings.HasPrefix(dirname, worktree) {
+		if os.Remove(dirname) != nil {
+			break
+		}
+		dirname = filepath.Dir(dirname)
+	}
+	return nil
+}
+
+// worktreeBlocked reports whether writing a file at name would lose untracked
+// files, either one in place of a leading directory or anything inside a
+// directory in its place, other than the paths about to be removed.
+func worktreeBlocked(repository *repo.Repository, name string, removed map[string]bool) (bool, error) {
+	parts := strings.Split(name, "/")
+	for i := 1; i < len(parts); i++ {
+		prefix := strings.Join(parts[:i], "/")
+		info, err := os.Lstat(worktreeAbsolute(repository, prefix))
+		if errors.Is(err, os.ErrNotExist) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		if !info.IsDir() {
+			return !removed[prefix], nil
+		}
+	}
+
+	path := worktreeAbsolute(repository, name)
+	info, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil || !info.IsDir() {
+		return false, err
+	}
+	blocked := false
+	err = filepath.WalkDir(path, func(sub string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := worktreeRelative(repository, sub)
+		if err != nil {
+			return err
+		}
+		if !removed[rel] {
+			blocked = true
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return blocked, err
+}
+
+func leafMatchesEntry(leaf *obj.TreeLeaf, entry index.IndexEntry) bool {
+	return leaf != nil && leaf.Sha == entry.Sha && leaf.Mode == indexEntryMode(entry)
+}
+
+func leafEqual(a *obj.TreeLeaf, b *obj.TreeLeaf) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Sha == b.Sha && a.Mode == b.Mode
+}
+
+// Moves the index and worktree from the current tree to the target one the
+// way a two-tree read-tree does: paths that are the same in both trees keep
+// whatever local changes they have, and anything that would lose local
+// changes aborts the whole switch unless force is set.
+func treeSwitch(repository *repo.Repository, current map[string]*obj.TreeLeaf, target map[string]*obj.TreeLeaf, force bool, operation string) error {
+	ind, err := index.IndexRead(repository)
+	if err != nil {
+		return err
+	}
+	indexinfo, err := os.Stat(filepath.Join(repository.Gitdir, "index"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	entries := make(map[string]index.IndexEntry)
+	indexed := make(map[string]bool)
+	paths := make(map[string]bool)
+	for _, entry := range ind.Entries {
+		indexed[entry.Name] = true
+		if entry.Flagstage != 0 {
+			if !force {
+				return fmt.Errorf("you need to resolve your current index first")
+			}
+			paths[entry.Name] = true
+			continue
+		}
+		entries[entry.Name] = entry
+		if force {
+			paths[entry.Name] = true
+		}
+	}
+	for name := range current {
+		paths[name] = true
+	}
+	for name := range target {
+		paths[name] = true
+	}
+
+	changed := []string{}
+	overwritten := []string{}
+	untracked := []string{}
+	for name := range paths {
+		c, t := current[name], target[name]
+		if force {
+			changed = append(changed, name)
+			continue
+		}
+		if leafEqual(c, t) {
+			continue
+		}
+
+		entry, tracked := entries[name]
+		if tracked && leafMatchesEntry(t, entry) {
+			continue
+		}
+
+		if tracked {
+			if !leafMatchesEntry(c, entry) {
+				overwritten = append(overwritten, name)
+				continue
+			}
+			state, err := worktreeState(repository, entry, indexinfo)
+			if err != nil {
+				return err
+			}
+			if state != "" && state != "D" {
+				overwritten = append(overwritten, name)
+				continue
+			}
+		} else if c != nil {
+			if t != nil {
+				overwritten = append(overwritten, name)
+				continue
+			}
+		} else {
+			info, err := os.Lstat(worktreeAbsolute(repository, name))
+			if err == nil && !info.IsDir() {
+				sha, err := worktreeHash(repository, name, info)
+				if err != nil {
+					return err
+				}
+				if sha != t.Sha {
+					untracked = append(untracked, name)
+					continue
+				}
+			}
+		}
+
+		changed = append(changed, name)
+	}
+
+	// Only what the index tracks is removed from disk, and no file is written
+	// where that would lose untracked files in its way.
+	removed := make(map[string]bool)
+	for _, name := range changed {
+		if target[name] == nil && indexed[name] {
+			removed[name] = true
+		}
+	}
+	for _, name := range changed {
+		if t := target[name]; t != nil && t.Mode != "160000" {
+			blocked, err := worktreeBlocked(repository, name, removed)
+			if err != nil {
+				return err
+			}
+			if blocked {
+				untracked = append(untracked, name)
+			}
+		}
+	}
+
+	if len(overwritten) > 0 || len(untracked) > 0 {
+		sort.Strings(overwritten)
+		sort.Strings(untracked)
+		message := ""
+		if len(overwritten) > 0 {
+			message += fmt.Sprintf("your local changes to the following files would be overwritten by %s:\n\t%s\n", operation, strings.Join(overwritten, "\n\t"))
+		}
+		if len(untracked) > 0 {
+			message += fmt.Sprintf("the following untracked working tree files would be overwritten by %s:\n\t%s\n", operation, strings.Join(untracked, "\n\t"))
+		}
+		message += "please commit your changes or stash them before you " + operation
+		return errors.New(message)
+	}
+
+	sort.Strings(changed)
+	for _, name := range changed {
+		if removed[name] {
+			err := worktreeRemove(repository, name)
+			if err != nil {
+				return err
+			}
+			delete(entries, name)
+		}
+	}
+	for _, name := range changed {
+		if t := target[name]; t != nil {
+			entry, err := worktreeCheckout(repository, t)
+			if err != nil {
+				return err
+			}
+			entries[name] = entry
+		}
+	}
+
+	ind.Entries = []index.IndexEntry{}
+	for _, entry := range entries {
+		ind.Entries = append(ind.Entries, entry)
+	}
+	ind.InvalidateCaches()
+	return index.IndexWrite(repository, ind)
+}
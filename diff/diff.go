@@ -0,0 +1,345 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+type EditType int
+
+const (
+	EditEqual EditType = iota
+	EditInsert
+	EditDelete
+)
+
+type Edit struct {
+	Type EditType
+	Line string
+}
+
+type Hunk struct {
+	OldStart int
+	OldLines int
+	NewStart int
+	NewLines int
+	Edits    []Edit
+}
+
+const binaryCheckSize = 8000
+
+func DiffIsBinary(data []byte) bool {
+	if len(data) > binaryCheckSize {
+		data = data[:binaryCheckSize]
+	}
+	return bytes.IndexByte(data, 0x00) != -1
+}
+
+func DiffSplitLines(data []byte) []string {
+	lines := []string{}
+	content := string(data)
+	for content != "" {
+		index := strings.IndexByte(content, '\n')
+		if index == -1 {
+			lines = append(lines, content)
+			break
+		}
+		lines = append(lines, content[:index+1])
+		content = content[index+1:]
+	}
+	return lines
+}
+
+func DiffLines(a []string, b []string) []Edit {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	edits := []Edit{}
+	for _, line := range a[:prefix] {
+		edits = append(edits, Edit{Type: EditEqual, Line: line})
+	}
+	budget := diffMaxCost
+	edits = append(edits, diffMyers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix], &budget)...)
+	for _, line := range a[len(a)-suffix:] {
+		edits = append(edits, Edit{Type: EditEqual, Line: line})
+	}
+	return edits
+}
+
+// Past this many diagonals the whole file is shown as replaced.
+const diffMaxCost = 1 << 24
+
+// Linear space Myers. Lines found on one side only are left out of the search.
+func diffMyers(a []string, b []string, budget *int) []Edit {
+	ina, inb := make(map[string]bool), make(map[string]bool)
+	for _, line := range a {
+		ina[line] = true
+	}
+	for _, line := range b {
+		inb[line] = true
+	}
+	keepa, keepb := []int{}, []int{}
+	for i, line := range a {
+		if inb[line] {
+			keepa = append(keepa, i)
+		}
+	}
+	for i, line := range b {
+		if ina[line] {
+			keepb = append(keepb, i)
+		}
+	}
+	reduceda, reducedb := make([]string, len(keepa)), make([]string, len(keepb))
+	for i, index := range keepa {
+		reduceda[i] = a[index]
+	}
+	for i, index := range keepb {
+		reducedb[i] = b[index]
+	}
+
+	reduced := []Edit{}
+	if !diffMyersSplit(reduceda, reducedb, &reduced, budget) {
+		return diffReplace(a, b)
+	}
+
+	edits := make([]Edit, 0, len(a)+len(b))
+	x, y, i, j := 0, 0, 0, 0
+	upto := func(nexta int, nextb int) {
+		for ; x < nexta; x++ {
+			edits = append(edits, Edit{Type: EditDelete, Line: a[x]})
+		}
+		for ; y < nextb; y++ {
+			edits = append(edits, Edit{Type: EditInsert, Line: b[y]})
+		}
+	}
+	for _, edit := range reduced {
+		switch edit.Type {
+		case EditEqual:
+			upto(keepa[i], keepb[j])
+			edits = append(edits, edit)
+			x, y, i, j = x+1, y+1, i+1, j+1
+		case EditDelete:
+			upto(keepa[i], y)
+			edits = append(edits, edit)
+			x, i = x+1, i+1
+		case EditInsert:
+			upto(x, keepb[j])
+			edits = append(edits, edit)
+			y, j = y+1, j+1
+		}
+	}
+	upto(len(a), len(b))
+	return diffGroup(edits)
+}
+
+func diffGroup(edits []Edit) []Edit {
+	res := make([]Edit, 0, len(edits))
+	inserts := []Edit{}
+	for _, edit := range edits {
+		switch edit.Type {
+		case EditInsert:
+			inserts = append(inserts, edit)
+		case EditDelete:
+			res = append(res, edit)
+		default:
+			res = append(res, inserts...)
+			res = append(res, edit)
+			inserts = inserts[:0]
+		}
+	}
+	return append(res, inserts...)
+}
+
+func diffReplace(a []string, b []string) []Edit {
+	edits := make([]Edit, 0, len(a)+len(b))
+	for _, line := range a {
+		edits = append(edits, Edit{Type: EditDelete, Line: line})
+	}
+	for _, line := range b {
+		edits = append(edits, Edit{Type: EditInsert, Line: line})
+	}
+	return edits
+}
+
+func diffMyersSplit(a []string, b []string, edits *[]Edit, budget *int) bool {
+	for len(a) > 0 && len(b) > 0 && a[0] == b[0] {
+		*edits = append(*edits, Edit{Type: EditEqual, Line: a[0]})
+		a, b = a[1:], b[1:]
+	}
+	suffix := 0
+	for suffix < len(a) && suffix < len(b) && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	tail := a[len(a)-suffix:]
+	a, b = a[:len(a)-suffix], b[:len(b)-suffix]
+
+	switch {
+	case len(a) == 0:
+		for _, line := range b {
+			*edits = append(*edits, Edit{Type: EditInsert, Line: line})
+		}
+	case len(b) == 0:
+		for _, line := range a {
+			*edits = append(*edits, Edit{Type: EditDelete, Line: line})
+		}
+	default:
+		x, y, ok := diffMiddleSnake(a, b, budget)
+		if !ok {
+			return false
+		}
+		if !diffMyersSplit(a[:x], b[:y], edits, budget) || !diffMyersSplit(a[x:], b[y:], edits, budget) {
+			return false
+		}
+	}
+
+	for _, line := range tail {
+		*edits = append(*edits, Edit{Type: EditEqual, Line: line})
+	}
+	return true
+}
+
+func diffMiddleSnake(a []string, b []string, budget *int) (int, int, bool) {
+	n, m := len(a), len(b)
+	delta := n - m
+	limit := (n + m + 1) / 2
+	offset := limit + 1
+	forward := make([]int, 2*limit+3)
+	backward := make([]int, 2*limit+3)
+
+	for d := 0; d <= limit; d++ {
+		*budget -= 2 * (d + 1)
+		if *budget < 0 {
+			return 0, 0, false
+		}
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && forward[offset+k-1] < forward[offset+k+1]) {
+				x = forward[offset+k+1]
+			} else {
+				x = forward[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			forward[offset+k] = x
+
+			c := delta - k
+			if delta%2 != 0 && c >= -(d-1) && c <= d-1 && x+backward[offset+c] >= n {
+				return x, y, true
+			}
+		}
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && backward[offset+k-1] < backward[offset+k+1]) {
+				x = backward[offset+k+1]
+			} else {
+				x = backward[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[n-1-x] == b[m-1-y] {
+				x++
+				y++
+			}
+			backward[offset+k] = x
+
+			c := delta - k
+			if delta%2 == 0 && c >= -d && c <= d && x+forward[offset+c] >= n {
+				return n - x, m - y, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+func DiffHunks(edits []Edit, context int) []Hunk {
+	if context < 0 {
+		context = 0
+	}
+
+	oldpositions := make([]int, len(edits)+1)
+	newpositions := make([]int, len(edits)+1)
+	changes := []int{}
+	for i, edit := range edits {
+		oldpositions[i+1], newpositions[i+1] = oldpositions[i], newpositions[i]
+		if edit.Type != EditInsert {
+			oldpositions[i+1]++
+		}
+		if edit.Type != EditDelete {
+			newpositions[i+1]++
+		}
+		if edit.Type != EditEqual {
+			changes = append(changes, i)
+		}
+	}
+
+	hunks := []Hunk{}
+	for i := 0; i < len(changes); {
+		start := max(changes[i]-context, 0)
+		end := changes[i]
+		for i < len(changes) && changes[i]-end <= 2*context+1 {
+			end = changes[i]
+			i++
+		}
+		end = min(end+context, len(edits)-1)
+
+		hunk := Hunk{
+			OldStart: oldpositions[start] + 1,
+			OldLines: oldpositions[end+1] - oldpositions[start],
+			NewStart: newpositions[start] + 1,
+			NewLines: newpositions[end+1] - newpositions[start],
+			Edits:    edits[start : end+1],
+		}
+		if hunk.OldLines == 0 {
+			hunk.OldStart--
+		}
+		if hunk.NewLines == 0 {
+			hunk.NewStart--
+		}
+		hunks = append(hunks, hunk)
+	}
+
+	return hunks
+}
+
+func diffRange(start int, lines int) string {
+	if lines == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, lines)
+}
+
+func DiffUnified(a []byte, b []byte, context int) string {
+	edits := DiffLines(DiffSplitLines(a), DiffSplitLines(b))
+	hunks := DiffHunks(edits, context)
+
+	var builder strings.Builder
+	for _, hunk := range hunks {
+		fmt.Fprintf(&builder, "@@ -%s +%s @@\n", diffRange(hunk.OldStart, hunk.OldLines), diffRange(hunk.NewStart, hunk.NewLines))
+		for _, edit := range hunk.Edits {
+			switch edit.Type {
+			case EditEqual:
+				builder.WriteString(" ")
+			case EditInsert:
+				builder.WriteString("+")
+			case EditDelete:
+				builder.WriteString("-")
+			}
+			builder.WriteString(edit.Line)
+			if !strings.HasSuffix(edit.Line, "\n") {
+				builder.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+	}
+	return builder.String()
+}
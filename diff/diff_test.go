@@ -0,0 +1,137 @@
+package diff
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// diffApply rebuilds both sides from an edit script.
+func diffApply(edits []Edit) ([]string, []string) {
+	a, b := []string{}, []string{}
+	for _, edit := range edits {
+		if edit.Type != EditInsert {
+			a = append(a, edit.Line)
+		}
+		if edit.Type != EditDelete {
+			b = append(b, edit.Line)
+		}
+	}
+	return a, b
+}
+
+func diffLCS(a []string, b []string) int {
+	table := make([][]int, len(a)+1)
+	for i := range table {
+		table[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				table[i][j] = table[i+1][j+1] + 1
+			} else {
+				table[i][j] = max(table[i+1][j], table[i][j+1])
+			}
+		}
+	}
+	return table[0][0]
+}
+
+func diffEqual(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestDiffLinesMinimal(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for i := range 2000 {
+		a := make([]string, random.Intn(30))
+		for j := range a {
+			a[j] = fmt.Sprint(random.Intn(4))
+		}
+		b := make([]string, random.Intn(30))
+		for j := range b {
+			b[j] = fmt.Sprint(random.Intn(4))
+		}
+
+		edits := DiffLines(a, b)
+		gota, gotb := diffApply(edits)
+		if !diffEqual(gota, a) || !diffEqual(gotb, b) {
+			t.Fatalf("case %d: edits do not rebuild %v and %v", i, a, b)
+		}
+		equal := 0
+		for _, edit := range edits {
+			if edit.Type == EditEqual {
+				equal++
+			}
+		}
+		if want := diffLCS(a, b); equal != want {
+			t.Fatalf("case %d: %d equal lines between %v and %v, want %d", i, equal, a, b, want)
+		}
+	}
+}
+
+func TestDiffLinesRewrite(t *testing.T) {
+	a := make([]string, 12000)
+	b := make([]string, 12000)
+	for i := range a {
+		a[i] = fmt.Sprintf("old %d\n", i)
+		b[i] = fmt.Sprintf("new %d\n", i)
+	}
+
+	// No line is on both sides, so there is nothing to search.
+	budget := diffMaxCost
+	edits := diffMyers(a, b, &budget)
+	if budget != diffMaxCost {
+		t.Errorf("rewriting every line cost %d", diffMaxCost-budget)
+	}
+	gota, gotb := diffApply(edits)
+	if !diffEqual(gota, a) || !diffEqual(gotb, b) {
+		t.Fatal("edits do not rebuild both sides")
+	}
+	if len(edits) != len(a)+len(b) {
+		t.Errorf("%d edits, want %d", len(edits), len(a)+len(b))
+	}
+}
+
+func TestDiffLinesShuffled(t *testing.T) {
+	a := make([]string, 12000)
+	for i := range a {
+		a[i] = fmt.Sprintf("line %d\n", i)
+	}
+	b := append([]string{}, a...)
+	rand.New(rand.NewSource(1)).Shuffle(len(b), func(i, j int) { b[i], b[j] = b[j], b[i] })
+
+	// Every line is on both sides in another order, which is too costly to
+	// search and falls back to replacing the whole file.
+	budget := diffMaxCost
+	edits := diffMyers(a, b, &budget)
+	if budget >= 0 {
+		t.Errorf("shuffling 12000 lines cost only %d", diffMaxCost-budget)
+	}
+	if !slices.Equal(edits, diffReplace(a, b)) {
+		t.Error("a search over budget did not replace the whole file")
+	}
+	gota, gotb := diffApply(DiffLines(a, b))
+	if !diffEqual(gota, a) || !diffEqual(gotb, b) {
+		t.Fatal("edits do not rebuild both sides")
+	}
+
+	budget = diffMaxCost
+	edits = diffMyers(a[:1000], b[:1000], &budget)
+	if budget < 0 {
+		t.Error("1000 shuffled lines ran over budget")
+	}
+	gota, gotb = diffApply(edits)
+	if !diffEqual(gota, a[:1000]) || !diffEqual(gotb, b[:1000]) {
+		t.Fatal("edits do not rebuild both sides")
+	}
+}
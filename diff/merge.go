@@ -0,0 +1,145 @@
+package diff
+
+import (
+	"slices"
+	"strings"
+)
+
+type mergeChange struct {
+	Start int
+	End   int
+	Lines []string
+}
+
+// diffChanges turns an edit script into the ranges of base lines that were
+// replaced and what they were replaced with.
+func diffChanges(edits []Edit) []mergeChange {
+	changes := []mergeChange{}
+	curr := 0
+	var change *mergeChange
+	for _, edit := range edits {
+		if edit.Type == EditEqual {
+			if change != nil {
+				changes = append(changes, *change)
+				change = nil
+			}
+			curr++
+			continue
+		}
+
+		if change == nil {
+			change = &mergeChange{Start: curr, End: curr, Lines: []string{}}
+		}
+		if edit.Type == EditDelete {
+			curr++
+			change.End = curr
+		} else {
+			change.Lines = append(change.Lines, edit.Line)
+		}
+	}
+	if change != nil {
+		changes = append(changes, *change)
+	}
+	return changes
+}
+
+func mergeApply(base []string, start int, end int, changes []mergeChange) []string {
+	res := []string{}
+	curr := start
+	for _, change := range changes {
+		res = append(res, base[curr:change.Start]...)
+		res = append(res, change.Lines...)
+		curr = change.End
+	}
+	return append(res, base[curr:end]...)
+}
+
+func mergeSection(builder *strings.Builder, lines []string) {
+	for _, line := range lines {
+		builder.WriteString(line)
+	}
+	if len(lines) > 0 && !strings.HasSuffix(lines[len(lines)-1], "\n") {
+		builder.WriteString("\n")
+	}
+}
+
+// DiffMerge performs a three-way line merge of ours and theirs against their
+// common base. Changes to overlapping or adjacent base lines that do not agree
+// are written between conflict markers, and the number of such conflicts is
+// returned alongside the merged content.
+func DiffMerge(base []byte, ours []byte, theirs []byte, ourlabel string, theirlabel string) ([]byte, int) {
+	baselines := DiffSplitLines(base)
+	ourlines, theirlines := DiffSplitLines(ours), DiffSplitLines(theirs)
+	ourchanges := diffChanges(DiffLines(baselines, ourlines))
+	theirchanges := diffChanges(DiffLines(baselines, theirlines))
+
+	var builder strings.Builder
+	conflicts := 0
+	curr := 0
+	i, j := 0, 0
+	for i < len(ourchanges) || j < len(theirchanges) {
+		start := 0
+		if j >= len(theirchanges) || (i < len(ourchanges) && ourchanges[i].Start <= theirchanges[j].Start) {
+			start = ourchanges[i].Start
+		} else {
+			start = theirchanges[j].Start
+		}
+		end := start
+
+		ourset, theirset := []mergeChange{}, []mergeChange{}
+		for {
+			if i < len(ourchanges) && ourchanges[i].Start <= end {
+				end = max(end, ourchanges[i].End)
+				ourset = append(ourset, ourchanges[i])
+				i++
+				continue
+			}
+			if j < len(theirchanges) && theirchanges[j].Start <= end {
+				end = max(end, theirchanges[j].End)
+				theirset = append(theirset, theirchanges[j])
+				j++
+				continue
+			}
+			break
+		}
+
+		for _, line := range baselines[curr:start] {
+			builder.WriteString(line)
+		}
+		curr = end
+
+		ourresult := mergeApply(baselines, start, end, ourset)
+		theirresult := mergeApply(baselines, start, end, theirset)
+		switch {
+		case len(theirset) == 0:
+			builder.WriteString(strings.Join(ourresult, ""))
+		case len(ourset) == 0 || slices.Equal(ourresult, theirresult):
+			builder.WriteString(strings.Join(theirresult, ""))
+		default:
+			prefix := 0
+			for prefix < len(ourresult) && prefix < len(theirresult) && ourresult[prefix] == theirresult[prefix] {
+				prefix++
+			}
+			suffix := 0
+			for suffix < len(ourresult)-prefix && suffix < len(theirresult)-prefix && ourresult[len(ourresult)-1-suffix] == theirresult[len(theirresult)-1-suffix] {
+				suffix++
+			}
+
+			builder.WriteString(strings.Join(ourresult[:prefix], ""))
+			builder.WriteString("<<<<<<< " + ourlabel + "\n")
+			mergeSection(&builder, ourresult[prefix:len(ourresult)-suffix])
+			builder.WriteString("=======\n")
+			mergeSection(&builder, theirresult[prefix:len(theirresult)-suffix])
+			builder.WriteString(">>>>>>> " + theirlabel + "\n")
+			for _, line := range ourresult[len(ourresult)-suffix:] {
+				builder.WriteString(line)
+			}
+			conflicts++
+		}
+	}
+	for _, line := range baselines[curr:] {
+		builder.WriteString(line)
+	}
+
+	return []byte(builder.String()), conflicts
+}
@@ -2,9 +2,14 @@ module github.com/Jcho114/go-git
 
 go 1.23.4
 
+require (
+	github.com/spf13/cobra v1.9.1
+	golang.org/x/crypto v0.41.0
+	golang.org/x/crypto v0.41.0
+	gopkg.in/ini.v1 v1.67.0
+)
+
 require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
-	github.com/spf13/cobra v1.9.1 // indirect
 	github.com/spf13/pflag v1.0.6 // indirect
-	gopkg.in/ini.v1 v1.67.0 // indirect
 )
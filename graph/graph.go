@@ -0,0 +1,335 @@
+package graph
+
+import (
+	"container/heap"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/Jcho114/go-git/obj"
+	"github.com/Jcho114/go-git/repo"
+)
+
+type graphCommit struct {
+	Sha     string
+	Parents []string
+	Date    int64
+	Flags   int
+}
+
+const (
+	flagParent1 = 1 << iota
+	flagParent2
+	flagStale
+	flagResult
+)
+
+// Graph caches the parents and committer dates of commits as they are read.
+type Graph struct {
+	repository *repo.Repository
+	commits    map[string]*graphCommit
+}
+
+func NewGraph(repository *repo.Repository) *Graph {
+	return &Graph{
+		repository: repository,
+		commits:    make(map[string]*graphCommit),
+	}
+}
+
+func (g *Graph) commit(sha string) (*graphCommit, error) {
+	if commit, ok := g.commits[sha]; ok {
+		return commit, nil
+	}
+
+	object, err := obj.ObjectRead(g.repository, sha)
+	if err != nil {
+		return nil, err
+	}
+	commitobj, ok := object.(*obj.Commit)
+	if !ok {
+		return nil, fmt.Errorf("object %s is not a commit", sha)
+	}
+
+	commit := &graphCommit{Sha: sha, Parents: commitobj.Kvlm["parent"]}
+	if committer := commitobj.Kvlm["committer"]; len(committer) > 0 {
+		commit.Date = GraphIdentDate(committer[0])
+	}
+	g.commits[sha] = commit
+	return commit, nil
+}
+
+func (g *Graph) Parents(sha string) ([]string, error) {
+	commit, err := g.commit(sha)
+	if err != nil {
+		return nil, err
+	}
+	return commit.Parents, nil
+}
+
+func (g *Graph) Date(sha string) (int64, error) {
+	commit, err := g.commit(sha)
+	if err != nil {
+		return 0, err
+	}
+	return commit.Date, nil
+}
+
+func GraphIdentDate(ident string) int64 {
+	close := strings.LastIndex(ident, "> ")
+	if close == -1 {
+		return 0
+	}
+	fields := strings.Fields(ident[close+2:])
+	if len(fields) == 0 {
+		return 0
+	}
+	date, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return date
+}
+
+type graphQueue []*graphCommit
+
+func (q graphQueue) Len() int           { return len(q) }
+func (q graphQueue) Less(i, j int) bool { return q[i].Date > q[j].Date }
+func (q graphQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *graphQueue) Push(x any)        { *q = append(*q, x.(*graphCommit)) }
+func (q *graphQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+func (q graphQueue) interesting() bool {
+	for _, commit := range q {
+		if commit.Flags&flagStale == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (g *Graph) clearFlags() {
+	for _, commit := range g.commits {
+		commit.Flags = 0
+	}
+}
+
+// Same as paint_down_to_common in git's commit-reach.c.
+func (g *Graph) paintDownToCommon(one string, twos []string) ([]string, error) {
+	g.clearFlags()
+	defer g.clearFlags()
+
+	queue := &graphQueue{}
+	first, err := g.commit(one)
+	if err != nil {
+		return nil, err
+	}
+	first.Flags |= flagParent1
+	heap.Push(queue, first)
+	for _, two := range twos {
+		commit, err := g.commit(two)
+		if err != nil {
+			return nil, err
+		}
+		commit.Flags |= flagParent2
+		heap.Push(queue, commit)
+	}
+
+	res := []string{}
+	for queue.interesting() {
+		commit := heap.Pop(queue).(*graphCommit)
+		flags := commit.Flags & (flagParent1 | flagParent2 | flagStale)
+		if flags == flagParent1|flagParent2 {
+			if commit.Flags&flagResult == 0 {
+				commit.Flags |= flagResult
+				res = append(res, commit.Sha)
+			}
+			flags |= flagStale
+		}
+
+		for _, sha := range commit.Parents {
+			parent, err := g.commit(sha)
+			if err != nil {
+				return nil, err
+			}
+			if parent.Flags&flags == flags {
+				continue
+			}
+			parent.Flags |= flags
+			heap.Push(queue, parent)
+		}
+	}
+
+	return res, nil
+}
+
+func (g *Graph) removeRedundant(shas []string) ([]string, error) {
+	res := []string{}
+	for i, candidate := range shas {
+		redundant := false
+		for j, other := range shas {
+			if i == j || other == candidate {
+				continue
+			}
+			isancestor, err := g.IsAncestor(candidate, other)
+			if err != nil {
+				return nil, err
+			}
+			if isancestor {
+				redundant = true
+				break
+			}
+		}
+		if !redundant && !slices.Contains(res, candidate) {
+			res = append(res, candidate)
+		}
+	}
+	return res, nil
+}
+
+func (g *Graph) MergeBaseMany(one string, twos []string) ([]string, error) {
+	if slices.Contains(twos, one) {
+		return []string{one}, nil
+	}
+	common, err := g.paintDownToCommon(one, twos)
+	if err != nil {
+		return nil, err
+	}
+	return g.removeRedundant(common)
+}
+
+func (g *Graph) MergeBaseAll(a string, b string) ([]string, error) {
+	return g.MergeBaseMany(a, []string{b})
+}
+
+// MergeBase returns "" when the histories are unrelated.
+func (g *Graph) MergeBase(a string, b string) (string, error) {
+	bases, err := g.MergeBaseAll(a, b)
+	if err != nil || len(bases) == 0 {
+		return "", err
+	}
+	return bases[0], nil
+}
+
+func (g *Graph) MergeBaseOctopus(shas []string) ([]string, error) {
+	if len(shas) == 0 {
+		return []string{}, nil
+	}
+
+	res := []string{shas[0]}
+	for _, next := range shas[1:] {
+		bases := []string{}
+		for _, current := range res {
+			found, err := g.MergeBaseAll(current, next)
+			if err != nil {
+				return nil, err
+			}
+			for _, base := range found {
+				if !slices.Contains(bases, base) {
+					bases = append(bases, base)
+				}
+			}
+		}
+		res = bases
+	}
+	return g.removeRedundant(res)
+}
+
+func (g *Graph) IsAncestor(ancestor string, descendant string) (bool, error) {
+	seen := make(map[string]bool)
+	queue := []string{descendant}
+	for len(queue) > 0 {
+		sha := queue[0]
+		queue = queue[1:]
+		if sha == ancestor {
+			return true, nil
+		}
+		if seen[sha] {
+			continue
+		}
+		seen[sha] = true
+
+		commit, err := g.commit(sha)
+		if err != nil {
+			return false, err
+		}
+		queue = append(queue, commit.Parents...)
+	}
+	return false, nil
+}
+
+func MergeBase(repository *repo.Repository, a string, b string) (string, error) {
+	return NewGraph(repository).MergeBase(a, b)
+}
+
+func MergeBaseAll(repository *repo.Repository, a string, b string) ([]string, error) {
+	return NewGraph(repository).MergeBaseAll(a, b)
+}
+
+func MergeBaseOctopus(repository *repo.Repository, shas []string) ([]string, error) {
+	return NewGraph(repository).MergeBaseOctopus(shas)
+}
+
+func IsAncestor(repository *repo.Repository, ancestor string, descendant string) (bool, error) {
+	return NewGraph(repository).IsAncestor(ancestor, descendant)
+}
+
+func (g *Graph) Range(include []string, exclude []string) ([]string, error) {
+	excluded := make(map[string]bool)
+	queue := slices.Clone(exclude)
+	for len(queue) > 0 {
+		sha := queue[0]
+		queue = queue[1:]
+		if excluded[sha] {
+			continue
+		}
+		excluded[sha] = true
+		parents, err := g.Parents(sha)
+		if err != nil {
+			return nil, err
+		}
+		queue = append(queue, parents...)
+	}
+
+	type frame struct {
+		Sha  string
+		Next int
+	}
+
+	order := []string{}
+	visited := make(map[string]bool)
+	for _, start := range include {
+		if visited[start] || excluded[start] {
+			continue
+		}
+		visited[start] = true
+		stack := []frame{{Sha: start}}
+		for len(stack) > 0 {
+			top := &stack[len(stack)-1]
+			parents, err := g.Parents(top.Sha)
+			if err != nil {
+				return nil, err
+			}
+			if top.Next < len(parents) {
+				parent := parents[top.Next]
+				top.Next++
+				if !visited[parent] && !excluded[parent] {
+					visited[parent] = true
+					stack = append(stack, frame{Sha: parent})
+				}
+				continue
+			}
+			order = append(order, top.Sha)
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	slices.Reverse(order)
+	return order, nil
+}
@@ -0,0 +1,125 @@
+package graph
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Jcho114/go-git/obj"
+	"github.com/Jcho114/go-git/repo"
+)
+
+func testRepository(t *testing.T) *repo.Repository {
+	t.Helper()
+	repository, err := repo.NewRepository(t.TempDir(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.MkdirAll(filepath.Join(repository.Gitdir, "objects"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repository
+}
+
+// testCommit writes a commit with an empty tree, dated by seconds.
+func testCommit(t *testing.T, repository *repo.Repository, message string, seconds int, parents ...string) string {
+	t.Helper()
+	tree, err := obj.ObjectWrite(repository, &obj.Tree{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var content strings.Builder
+	fmt.Fprintf(&content, "tree %s\n", tree)
+	for _, parent := range parents {
+		fmt.Fprintf(&content, "parent %s\n", parent)
+	}
+	fmt.Fprintf(&content, "author Tester <t@example.com> %d +0000\n", seconds)
+	fmt.Fprintf(&content, "committer Tester <t@example.com> %d +0000\n", seconds)
+	fmt.Fprintf(&content, "\n%s\n", message)
+	sha, err := obj.ObjectWriteRaw(repository, "commit", []byte(content.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sha
+}
+
+func TestMergeBaseCrissCross(t *testing.T) {
+	// base - a1 - a2
+	//     \    X
+	//      b1 - b2
+	repository := testRepository(t)
+	base := testCommit(t, repository, "base", 1)
+	a1 := testCommit(t, repository, "a1", 2, base)
+	b1 := testCommit(t, repository, "b1", 3, base)
+	a2 := testCommit(t, repository, "a2", 4, a1, b1)
+	b2 := testCommit(t, repository, "b2", 5, b1, a1)
+	other := testCommit(t, repository, "other", 6)
+
+	tests := []struct {
+		a, b  string
+		bases []string
+	}{
+		{a2, b2, []string{b1, a1}},
+		{a1, b1, []string{base}},
+		{a2, a1, []string{a1}},
+		{b2, base, []string{base}},
+		{a2, a2, []string{a2}},
+		{a2, other, []string{}},
+	}
+	for _, test := range tests {
+		bases, err := MergeBaseAll(repository, test.a, test.b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		slices.Sort(bases)
+		slices.Sort(test.bases)
+		if !slices.Equal(bases, test.bases) {
+			t.Errorf("MergeBaseAll(%s, %s) = %v, want %v", test.a[:7], test.b[:7], bases, test.bases)
+		}
+	}
+
+	single, err := MergeBase(repository, a2, other)
+	if err != nil || single != "" {
+		t.Errorf("MergeBase of unrelated commits = %q, %v", single, err)
+	}
+	octopus, err := MergeBaseOctopus(repository, []string{a2, b2, a1})
+	if err != nil || !slices.Equal(octopus, []string{a1}) {
+		t.Errorf("MergeBaseOctopus = %v, %v, want %s", octopus, err, a1)
+	}
+}
+
+func TestIsAncestor(t *testing.T) {
+	repository := testRepository(t)
+	base := testCommit(t, repository, "base", 1)
+	a1 := testCommit(t, repository, "a1", 2, base)
+	b1 := testCommit(t, repository, "b1", 3, base)
+	a2 := testCommit(t, repository, "a2", 4, a1, b1)
+	b2 := testCommit(t, repository, "b2", 5, b1, a1)
+
+	tests := []struct {
+		ancestor, descendant string
+		want                 bool
+	}{
+		{base, a2, true},
+		{a1, b2, true},
+		{b1, a2, true},
+		{a2, b2, false},
+		{b2, a2, false},
+		{a1, b1, false},
+		{a2, base, false},
+		{a2, a2, true},
+	}
+	for _, test := range tests {
+		got, err := IsAncestor(repository, test.ancestor, test.descendant)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != test.want {
+			t.Errorf("IsAncestor(%s, %s) = %v, want %v", test.ancestor[:7], test.descendant[:7], got, test.want)
+		}
+	}
+}
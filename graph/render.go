@@ -0,0 +1,490 @@
+package graph
+
+import (
+	"slices"
+	"strings"
+)
+
+// Port of the state machine in git's graph.c.
+
+const (
+	statePadding = iota
+	stateSkip
+	statePreCommit
+	stateCommit
+	statePostMerge
+	stateCollapsing
+)
+
+type Renderer struct {
+	commit          string
+	mark            string
+	parents         []string
+	state           int
+	prevState       int
+	commitIndex     int
+	prevCommitIndex int
+	mergeLayout     int
+	edgesAdded      int
+	prevEdgesAdded  int
+	expansionRow    int
+	width           int
+
+	columns     []string
+	newColumns  []string
+	mapping     []int
+	oldMapping  []int
+	mappingSize int
+}
+
+func NewRenderer() *Renderer {
+	return &Renderer{state: statePadding, prevState: statePadding}
+}
+
+func (r *Renderer) setState(state int) {
+	r.prevState = r.state
+	r.state = state
+}
+
+func (r *Renderer) ensureCapacity(columns int) {
+	if len(r.mapping) >= 2*columns {
+		return
+	}
+	size := max(2*columns, 2*len(r.mapping))
+	mapping := make([]int, size)
+	copy(mapping, r.mapping)
+	oldmapping := make([]int, size)
+	copy(oldmapping, r.oldMapping)
+	r.mapping, r.oldMapping = mapping, oldmapping
+}
+
+func mappingAt(mapping []int, i int) int {
+	if i >= len(mapping) {
+		return -1
+	}
+	return mapping[i]
+}
+
+func (r *Renderer) findNewColumn(sha string) int {
+	return slices.Index(r.newColumns, sha)
+}
+
+func (r *Renderer) insertIntoNewColumns(sha string, idx int) {
+	i := r.findNewColumn(sha)
+	if i < 0 {
+		i = len(r.newColumns)
+		r.newColumns = append(r.newColumns, sha)
+	}
+
+	mappingidx := 0
+	if len(r.parents) > 1 && idx > -1 && r.mergeLayout == -1 {
+		dist := idx - i
+		shift := 1
+		if dist > 1 {
+			shift = 2*dist - 3
+		}
+		r.mergeLayout = 1
+		if dist > 0 {
+			r.mergeLayout = 0
+		}
+		r.edgesAdded = len(r.parents) + r.mergeLayout - 2
+		mappingidx = r.width + (r.mergeLayout-1)*shift
+		r.width += 2 * r.mergeLayout
+	} else if r.edgesAdded > 0 && r.width >= 2 && i == r.mapping[r.width-2] {
+		mappingidx = r.width - 2
+		r.edgesAdded = -1
+	} else {
+		mappingidx = r.width
+		r.width += 2
+	}
+	r.mapping[mappingidx] = i
+}
+
+func (r *Renderer) updateColumns() {
+	r.columns, r.newColumns = r.newColumns, r.columns[:0]
+
+	maxcolumns := len(r.columns) + len(r.parents)
+	r.ensureCapacity(maxcolumns)
+	r.mappingSize = 2 * maxcolumns
+	for i := 0; i < r.mappingSize; i++ {
+		r.mapping[i] = -1
+	}
+
+	r.width = 0
+	r.prevEdgesAdded = r.edgesAdded
+	r.edgesAdded = 0
+
+	seen := false
+	for i := 0; i <= len(r.columns); i++ {
+		sha := r.commit
+		if i < len(r.columns) {
+			sha = r.columns[i]
+		} else if seen {
+			break
+		}
+
+		if sha == r.commit {
+			seen = true
+			r.commitIndex = i
+			r.mergeLayout = -1
+			for _, parent := range r.parents {
+				r.insertIntoNewColumns(parent, i)
+			}
+			if len(r.parents) == 0 {
+				r.width += 2
+			}
+		} else {
+			r.insertIntoNewColumns(sha, -1)
+		}
+	}
+
+	for r.mappingSize > 1 && r.mapping[r.mappingSize-1] < 0 {
+		r.mappingSize--
+	}
+}
+
+func (r *Renderer) dashedParents() int {
+	return len(r.parents) + r.mergeLayout - 3
+}
+
+func (r *Renderer) needsPreCommitLine() bool {
+	return len(r.parents) >= 3 && r.commitIndex < len(r.columns)-1 && r.expansionRow < r.dashedParents()*2
+}
+
+func (r *Renderer) Update(sha string, parents []string, mark string) {
+	r.commit = sha
+	r.mark = mark
+	if mark == "" {
+		r.mark = "*"
+	}
+	r.parents = parents
+	r.prevCommitIndex = r.commitIndex
+	r.updateColumns()
+	r.expansionRow = 0
+
+	switch {
+	case r.state != statePadding:
+		r.state = stateSkip
+	case r.needsPreCommitLine():
+		r.state = statePreCommit
+	default:
+		r.state = stateCommit
+	}
+}
+
+func (r *Renderer) mappingCorrect() bool {
+	for i := 0; i < r.mappingSize; i++ {
+		target := r.mapping[i]
+		if target >= 0 && target != i/2 {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Renderer) pad(line *strings.Builder) string {
+	if line.Len() < r.width {
+		line.WriteString(strings.Repeat(" ", r.width-line.Len()))
+	}
+	return line.String()
+}
+
+func (r *Renderer) paddingLine(line *strings.Builder) {
+	for range r.newColumns {
+		line.WriteString("| ")
+	}
+}
+
+func (r *Renderer) skipLine(line *strings.Builder) {
+	line.WriteString("...")
+	if r.needsPreCommitLine() {
+		r.setState(statePreCommit)
+	} else {
+		r.setState(stateCommit)
+	}
+}
+
+func (r *Renderer) preCommitLine(line *strings.Builder) {
+	seen := false
+	for i, sha := range r.columns {
+		switch {
+		case sha == r.commit:
+			seen = true
+			line.WriteString("|")
+			line.WriteString(strings.Repeat(" ", r.expansionRow))
+		case seen && r.expansionRow == 0:
+			if r.prevState == statePostMerge && r.prevCommitIndex < i {
+				line.WriteString("\\")
+			} else {
+				line.WriteString("|")
+			}
+		case seen && r.expansionRow > 0:
+			line.WriteString("\\")
+		default:
+			line.WriteString("|")
+		}
+		line.WriteString(" ")
+	}
+
+	r.expansionRow++
+	if !r.needsPreCommitLine() {
+		r.setState(stateCommit)
+	}
+}
+
+func (r *Renderer) commitLine(line *strings.Builder) {
+	seen := false
+	for i := 0; i <= len(r.columns); i++ {
+		sha := r.commit
+		if i < len(r.columns) {
+			sha = r.columns[i]
+		} else if seen {
+			break
+		}
+
+		switch {
+		case sha == r.commit:
+			seen = true
+			line.WriteString(r.mark)
+			if len(r.parents) > 2 {
+				dashed := r.dashedParents()
+				for j := 0; j < dashed; j++ {
+					line.WriteString("-")
+					if j == dashed-1 {
+						line.WriteString(".")
+					} else {
+						line.WriteString("-")
+					}
+				}
+			}
+		case seen && r.edgesAdded > 1:
+			line.WriteString("\\")
+		case seen && r.edgesAdded == 1:
+			if r.prevState == statePostMerge && r.prevEdgesAdded > 0 && r.prevCommitIndex < i {
+				line.WriteString("\\")
+			} else {
+				line.WriteString("|")
+			}
+		case r.prevState == stateCollapsing && mappingAt(r.oldMapping, 2*i+1) == i && mappingAt(r.mapping, 2*i) < i:
+			line.WriteString("/")
+		default:
+			line.WriteString("|")
+		}
+		line.WriteString(" ")
+	}
+
+	switch {
+	case len(r.parents) > 1:
+		r.setState(statePostMerge)
+	case r.mappingCorrect():
+		r.setState(statePadding)
+	default:
+		r.setState(stateCollapsing)
+	}
+}
+
+func (r *Renderer) postMergeLine(line *strings.Builder) {
+	mergechars := []string{"/", "|", "\\"}
+	seen := false
+	parentcol := false
+	for i := 0; i <= len(r.columns); i++ {
+		sha := r.commit
+		if i < len(r.columns) {
+			sha = r.columns[i]
+		} else if seen {
+			break
+		}
+
+		switch {
+		case sha == r.commit:
+			seen = true
+			idx := r.mergeLayout
+			for j := range r.parents {
+				line.WriteString(mergechars[idx])
+				if idx == 2 {
+					if r.edgesAdded > 0 || j < len(r.parents)-1 {
+						line.WriteString(" ")
+					}
+				} else {
+					idx++
+				}
+			}
+			if r.edgesAdded == 0 {
+				line.WriteString(" ")
+			}
+		case seen:
+			if r.edgesAdded > 0 {
+				line.WriteString("\\")
+			} else {
+				line.WriteString("|")
+			}
+			line.WriteString(" ")
+		default:
+			line.WriteString("|")
+			if r.mergeLayout != 0 || i != r.commitIndex-1 {
+				if parentcol {
+					line.WriteString("_")
+				} else {
+					line.WriteString(" ")
+				}
+			}
+		}
+
+		if sha == r.parents[0] {
+			parentcol = true
+		}
+	}
+
+	if r.mappingCorrect() {
+		r.setState(statePadding)
+	} else {
+		r.setState(stateCollapsing)
+	}
+}
+
+func (r *Renderer) collapsingLine(line *strings.Builder) {
+	usedhorizontal := false
+	horizontaledge := -1
+	horizontaltarget := -1
+
+	r.mapping, r.oldMapping = r.oldMapping, r.mapping
+	for i := 0; i < r.mappingSize; i++ {
+		r.mapping[i] = -1
+	}
+
+	for i := 0; i < r.mappingSize; i++ {
+		target := r.oldMapping[i]
+		if target < 0 {
+			continue
+		}
+
+		switch {
+		case target*2 == i:
+			r.mapping[i] = target
+		case r.mapping[i-1] < 0:
+			r.mapping[i-1] = target
+			if horizontaledge == -1 {
+				horizontaledge = i
+				horizontaltarget = target
+				for j := target*2 + 3; j < i-2; j += 2 {
+					r.mapping[j] = target
+				}
+			}
+		case r.mapping[i-1] == target:
+		default:
+			r.mapping[i-2] = target
+			if horizontaledge == -1 {
+				horizontaltarget = target
+				horizontaledge = i - 1
+				for j := target*2 + 3; j < i-2; j += 2 {
+					r.mapping[j] = target
+				}
+			}
+		}
+	}
+
+	copy(r.oldMapping, r.mapping[:r.mappingSize])
+
+	if r.mapping[r.mappingSize-1] < 0 {
+		r.mappingSize--
+	}
+
+	for i := 0; i < r.mappingSize; i++ {
+		target := r.mapping[i]
+		switch {
+		case target < 0:
+			line.WriteString(" ")
+		case target*2 == i:
+			line.WriteString("|")
+		case target == horizontaltarget && i != horizontaledge-1:
+			if i != target*2+3 {
+				r.mapping[i] = -1
+			}
+			usedhorizontal = true
+			line.WriteString("_")
+		default:
+			if usedhorizontal && i < horizontaledge {
+				r.mapping[i] = -1
+			}
+			line.WriteString("/")
+		}
+	}
+
+	if r.mappingCorrect() {
+		r.setState(statePadding)
+	}
+}
+
+func (r *Renderer) NextLine() (string, bool) {
+	if r.commit == "" {
+		return "", false
+	}
+
+	var line strings.Builder
+	commitline := false
+	switch r.state {
+	case statePadding:
+		r.paddingLine(&line)
+	case stateSkip:
+		r.skipLine(&line)
+	case statePreCommit:
+		r.preCommitLine(&line)
+	case stateCommit:
+		r.commitLine(&line)
+		commitline = true
+	case statePostMerge:
+		r.postMergeLine(&line)
+	case stateCollapsing:
+		r.collapsingLine(&line)
+	}
+	return r.pad(&line), commitline
+}
+
+func (r *Renderer) PaddingLine() string {
+	if r.state != stateCommit {
+		line, _ := r.NextLine()
+		return line
+	}
+
+	var line strings.Builder
+	for _, sha := range r.columns {
+		line.WriteString("|")
+		if sha == r.commit && len(r.parents) > 2 {
+			line.WriteString(strings.Repeat(" ", (len(r.parents)-2)*2))
+		} else {
+			line.WriteString(" ")
+		}
+	}
+	r.prevState = statePadding
+	return r.pad(&line)
+}
+
+func (r *Renderer) Finished() bool {
+	return r.state == statePadding
+}
+
+func (r *Renderer) ShowCommit() string {
+	if r.Finished() {
+		return r.PaddingLine()
+	}
+
+	var out strings.Builder
+	for !r.Finished() {
+		line, commitline := r.NextLine()
+		out.WriteString(line)
+		if commitline {
+			break
+		}
+		out.WriteString("\n")
+	}
+	return out.String()
+}
+
+func (r *Renderer) ShowRemainder() string {
+	lines := []string{}
+	for !r.Finished() {
+		line, _ := r.NextLine()
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,146 @@
+package graph
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+type testRendered struct {
+	sha     string
+	parents []string
+}
+
+// testRender draws commits the way log --oneline --graph does, with each
+// commit's name as its text.
+func testRender(commits []testRendered) []string {
+	r := NewRenderer()
+	var out strings.Builder
+	for _, commit := range commits {
+		r.Update(commit.sha, commit.parents, "")
+		out.WriteString(r.ShowCommit() + commit.sha + "\n")
+		if !r.Finished() {
+			out.WriteString(r.ShowRemainder() + "\n")
+		}
+	}
+	return strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+}
+
+func TestRenderer(t *testing.T) {
+	tests := []struct {
+		name    string
+		commits []testRendered
+		want    []string
+	}{
+		{
+			name: "linear",
+			commits: []testRendered{
+				{"B", []string{"A"}},
+				{"A", nil},
+			},
+			want: []string{
+				"* B",
+				"* A",
+			},
+		},
+		{
+			name: "merge",
+			commits: []testRendered{
+				{"M", []string{"A", "B"}},
+				{"B", []string{"base"}},
+				{"A", []string{"base"}},
+				{"base", nil},
+			},
+			want: []string{
+				"*   M",
+				"|\\  ",
+				"| * B",
+				"* | A",
+				"|/  ",
+				"* base",
+			},
+		},
+		{
+			name: "octopus",
+			commits: []testRendered{
+				{"OCT", []string{"M", "O1", "O2", "O3"}},
+				{"O3", []string{"base"}},
+				{"O2", []string{"base"}},
+				{"O1", []string{"base"}},
+				{"M", []string{"A", "B"}},
+				{"B", []string{"base"}},
+				{"A", []string{"base"}},
+				{"base", nil},
+			},
+			want: []string{
+				"*---.   OCT",
+				"|\\ \\ \\  ",
+				"| | | * O3",
+				"| | * | O2",
+				"| | |/  ",
+				"| * / O1",
+				"| |/  ",
+				"* |   M",
+				"|\\ \\  ",
+				"| * | B",
+				"| |/  ",
+				"* / A",
+				"|/  ",
+				"* base",
+			},
+		},
+		{
+			name: "branch-off",
+			commits: []testRendered{
+				{"Z2", []string{"Z1"}},
+				{"Z1", []string{"B"}},
+				{"Y1", []string{"B"}},
+				{"X2", []string{"X1"}},
+				{"X1", []string{"B"}},
+				{"B", []string{"base"}},
+				{"base", nil},
+			},
+			want: []string{
+				"* Z2",
+				"* Z1",
+				"| * Y1",
+				"|/  ",
+				"| * X2",
+				"| * X1",
+				"|/  ",
+				"* B",
+				"* base",
+			},
+		},
+		{
+			name: "three columns",
+			commits: []testRendered{
+				{"Y2", []string{"Y1"}},
+				{"X2", []string{"X1"}},
+				{"Z1", []string{"base"}},
+				{"Y1", []string{"base"}},
+				{"X1", []string{"base"}},
+				{"base", nil},
+			},
+			want: []string{
+				"* Y2",
+				"| * X2",
+				"| | * Z1",
+				"* | | Y1",
+				"| |/  ",
+				"|/|   ",
+				"| * X1",
+				"|/  ",
+				"* base",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := testRender(test.commits)
+			if !slices.Equal(got, test.want) {
+				t.Errorf("rendered\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(test.want, "\n"))
+			}
+		})
+	}
+}
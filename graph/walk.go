@@ -0,0 +1,208 @@
+package graph
+
+import (
+	"container/heap"
+	"slices"
+)
+
+// Simplify decides, for a commit reached by a walk, which of its parents the
+// walk continues through and whether the commit itself is shown.
+type Simplify func(sha string, parents []string) ([]string, bool, error)
+
+type walkItem struct {
+	commit *graphCommit
+	seq    int
+}
+
+// walkQueue orders commits newest committer date first, and in the order they
+// were queued when dates are equal.
+type walkQueue []walkItem
+
+func (q walkQueue) Len() int { return len(q) }
+func (q walkQueue) Less(i, j int) bool {
+	if q[i].commit.Date != q[j].commit.Date {
+		return q[i].commit.Date > q[j].commit.Date
+	}
+	return q[i].seq < q[j].seq
+}
+func (q walkQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *walkQueue) Push(x any)   { *q = append(*q, x.(walkItem)) }
+func (q *walkQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+func (g *Graph) reachable(shas []string) (map[string]bool, error) {
+	seen := make(map[string]bool)
+	queue := slices.Clone(shas)
+	for len(queue) > 0 {
+		sha := queue[0]
+		queue = queue[1:]
+		if seen[sha] {
+			continue
+		}
+		seen[sha] = true
+		parents, err := g.Parents(sha)
+		if err != nil {
+			return nil, err
+		}
+		queue = append(queue, parents...)
+	}
+	return seen, nil
+}
+
+// Walk lists the commits reachable from include but not from exclude, newest
+// committer date first, or in topological order when topo is set. With a
+// simplify function only the commits it shows are returned, and the parents
+// reported for them are rewritten to their nearest shown ancestors so the
+// result still forms a graph. Without topo, the walk stops once limit commits
+// are shown, unless limit is negative.
+func (g *Graph) Walk(include []string, exclude []string, simplify Simplify, topo bool, limit int) ([]string, map[string][]string, error) {
+	excluded, err := g.reachable(exclude)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	followed := make(map[string][]string)
+	shown := make(map[string]bool)
+	walked := []string{}
+	queue := &walkQueue{}
+	seq := 0
+	push := func(sha string) error {
+		if excluded[sha] {
+			return nil
+		}
+		if _, ok := followed[sha]; ok {
+			return nil
+		}
+		commit, err := g.commit(sha)
+		if err != nil {
+			return err
+		}
+		followed[sha] = nil
+		heap.Push(queue, walkItem{commit: commit, seq: seq})
+		seq++
+		return nil
+	}
+
+	for _, sha := range include {
+		err := push(sha)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	count := 0
+	for queue.Len() > 0 {
+		if !topo && limit >= 0 && count >= limit {
+			break
+		}
+		commit := heap.Pop(queue).(walkItem).commit
+		parents, show := commit.Parents, true
+		if simplify != nil {
+			parents, show, err = simplify(commit.Sha, commit.Parents)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		followed[commit.Sha] = parents
+		shown[commit.Sha] = show
+		walked = append(walked, commit.Sha)
+		if show {
+			count++
+		}
+		for _, parent := range parents {
+			err := push(parent)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	// Hidden commits still take part in the sort, so lines of history are
+	// kept together the same way whether or not every commit is shown.
+	if topo {
+		walked = topoSort(walked, followed)
+	}
+	order := []string{}
+	for _, sha := range walked {
+		if shown[sha] {
+			order = append(order, sha)
+		}
+	}
+
+	rewritten := make(map[string][]string)
+	var rewrite func(sha string) []string
+	rewrite = func(sha string) []string {
+		if res, ok := rewritten[sha]; ok {
+			return res
+		}
+		rewritten[sha] = []string{}
+		res := []string{}
+		for _, parent := range followed[sha] {
+			if excluded[parent] {
+				continue
+			}
+			// A parent still queued when the walk stopped is kept as is.
+			candidates := []string{parent}
+			if show, ok := shown[parent]; ok && !show {
+				candidates = rewrite(parent)
+			}
+			for _, candidate := range candidates {
+				if !slices.Contains(res, candidate) {
+					res = append(res, candidate)
+				}
+			}
+		}
+		rewritten[sha] = res
+		return res
+	}
+
+	parents := make(map[string][]string)
+	for _, sha := range order {
+		parents[sha] = rewrite(sha)
+	}
+	return order, parents, nil
+}
+
+// topoSort reorders commits so that no commit comes before all of its
+// children, keeping lines of history together the way git's --topo-order
+// does: the most recently reached branch is finished before moving on.
+func topoSort(order []string, parents map[string][]string) []string {
+	indegree := make(map[string]int)
+	for _, sha := range order {
+		indegree[sha] = 1
+	}
+	for _, sha := range order {
+		for _, parent := range parents[sha] {
+			if indegree[parent] > 0 {
+				indegree[parent]++
+			}
+		}
+	}
+
+	stack := []string{}
+	for i := len(order) - 1; i >= 0; i-- {
+		if indegree[order[i]] == 1 {
+			stack = append(stack, order[i])
+		}
+	}
+
+	res := []string{}
+	for len(stack) > 0 {
+		sha := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, parent := range parents[sha] {
+			if indegree[parent] == 0 {
+				continue
+			}
+			indegree[parent]--
+			if indegree[parent] == 1 {
+				stack = append(stack, parent)
+			}
+		}
+		res = append(res, sha)
+	}
+	return res
+}
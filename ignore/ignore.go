@@ -3,12 +3,12 @@ package ignore
 import (
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	pathpkg "path"
 	"path/filepath"
 	"strings"
 
-	"github.com/Jcho114/go-git/index"
-	"github.com/Jcho114/go-git/obj"
 	"github.com/Jcho114/go-git/repo"
 )
 
@@ -42,14 +42,14 @@ func IgnoreRead(repository *repo.Repository) (*Ignore, error) {
 	var confighome string
 	if val := os.Getenv("XDG_CONFIG_HOME"); val != "" {
 		confighome = val
-	} else {
-		confighome = "~/.config"
+	} else if home, err := os.UserHomeDir(); err == nil {
+		confighome = filepath.Join(home, ".config")
 	}
 
 	globalfile := filepath.Join(confighome, "git/ignore")
 	_, err = os.Stat(globalfile)
 	pathexists = !errors.Is(err, os.ErrNotExist)
-	if pathexists {
+	if confighome != "" && pathexists {
 		content, err := os.ReadFile(globalfile)
 		if err != nil {
 			return nil, err
@@ -59,39 +59,194 @@ func IgnoreRead(repository *repo.Repository) (*Ignore, error) {
 		absolute = append(absolute, parsed)
 	}
 
-	index, err := index.IndexRead(repository)
+	ignore := &Ignore{
+		Absolute: absolute,
+		Scoped:   scoped,
+	}
+
+	err = filepath.WalkDir(repository.Worktree, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(repository.Worktree, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			if rel == "." {
+				return nil
+			}
+			ignored, err := IgnoreCheck(ignore, rel, true)
+			if err != nil {
+				return err
+			}
+			if ignored {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if d.Name() != ".gitignore" || !d.Type().IsRegular() {
+			return nil
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		lines := strings.Split(string(content), "\n")
+		scoped[pathpkg.Dir(rel)] = ignoreParse(lines)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	for _, entry := range index.Entries {
-		if entry.Name == ".gitignore" || strings.HasSuffix(entry.Name, "/.gitignore") {
-			dirname := filepath.Dir(entry.Name)
-			content, err := obj.ObjectRead(repository, entry.Sha)
+	return ignore, nil
+}
+
+func IgnoreCheck(rules *Ignore, path string, isdir bool) (bool, error) {
+	if filepath.IsAbs(path) {
+		return false, fmt.Errorf("path %s is not relative to the worktree", path)
+	}
+
+	path = filepath.ToSlash(path)
+	parts := strings.Split(path, "/")
+	for i := 1; i < len(parts); i++ {
+		res, err := ignoreCheckPath(rules, strings.Join(parts[:i], "/"), true)
+		if err != nil {
+			return false, err
+		}
+		if res {
+			return true, nil
+		}
+	}
+
+	return ignoreCheckPath(rules, path, isdir)
+}
+
+func ignoreCheckPath(rules *Ignore, path string, isdir bool) (bool, error) {
+	dirname := pathpkg.Dir(path)
+	for {
+		if scoped, ok := rules.Scoped[dirname]; ok {
+			rel := path
+			if dirname != "." {
+				rel = strings.TrimPrefix(path, dirname+"/")
+			}
+			res, err := ignoreMatch(scoped, rel, isdir)
 			if err != nil {
-				return nil, err
+				return false, err
 			}
-			blob, ok := content.(*obj.Blob)
-			if !ok {
-				return nil, fmt.Errorf("object %s is not a blob type", entry.Name)
+			if res != nil {
+				return *res, nil
 			}
-			lines := strings.Split(string(blob.Data), "\n")
-			parsed := ignoreParse(lines)
-			scoped[dirname] = parsed
 		}
+
+		if dirname == "." {
+			break
+		}
+		dirname = pathpkg.Dir(dirname)
 	}
 
-	ignore := &Ignore{
-		Absolute: absolute,
-		Scoped:   scoped,
+	for _, ruleset := range rules.Absolute {
+		res, err := ignoreMatch(ruleset, path, isdir)
+		if err != nil {
+			return false, err
+		}
+		if res != nil {
+			return *res, nil
+		}
 	}
-	return ignore, nil
+
+	return false, nil
+}
+
+func ignoreMatch(rules []IgnoreRule, path string, isdir bool) (*bool, error) {
+	var res *bool
+	for _, rule := range rules {
+		pattern := rule.Pattern
+		if strings.HasSuffix(pattern, "/") {
+			if !isdir {
+				continue
+			}
+			pattern = strings.TrimSuffix(pattern, "/")
+		}
+
+		target := path
+		if strings.Contains(pattern, "/") {
+			pattern = strings.TrimPrefix(pattern, "/")
+		} else {
+			target = pathpkg.Base(path)
+		}
+
+		matched, err := ignoreGlob(pattern, target)
+		if err != nil {
+			return nil, err
+		}
+		if matched {
+			value := rule.Ignore
+			res = &value
+		}
+	}
+	return res, nil
+}
+
+func ignoreGlob(pattern string, name string) (bool, error) {
+	if pattern == "**" {
+		return true, nil
+	}
+
+	segments := strings.Split(name, "/")
+
+	if strings.HasPrefix(pattern, "**/") {
+		for i := range segments {
+			matched, err := ignoreGlob(pattern[3:], strings.Join(segments[i:], "/"))
+			if err != nil || matched {
+				return matched, err
+			}
+		}
+		return false, nil
+	}
+
+	if index := strings.Index(pattern, "/**/"); index != -1 {
+		for i := 1; i < len(segments); i++ {
+			matched, err := pathpkg.Match(pattern[:index], strings.Join(segments[:i], "/"))
+			if err != nil {
+				return false, err
+			}
+			if !matched {
+				continue
+			}
+			matched, err = ignoreGlob(pattern[index+1:], strings.Join(segments[i:], "/"))
+			if err != nil || matched {
+				return matched, err
+			}
+		}
+		return false, nil
+	}
+
+	if strings.HasSuffix(pattern, "/**") {
+		for i := 1; i < len(segments); i++ {
+			matched, err := pathpkg.Match(strings.TrimSuffix(pattern, "/**"), strings.Join(segments[:i], "/"))
+			if err != nil || matched {
+				return matched, err
+			}
+		}
+		return false, nil
+	}
+
+	return pathpkg.Match(pattern, name)
 }
 
 func ignoreParseLine(content string) *IgnoreRule {
 	content = strings.TrimSpace(content)
 
-	if len(content) == 0 || content == "#" {
+	if len(content) == 0 || content[0] == '#' {
 		return nil
 	}
 
@@ -2,6 +2,7 @@ package index
 
 import (
 	"bytes"
+	"crypto/sha1"
 	"encoding/binary"
 	"encoding/hex"
 	"errors"
@@ -9,6 +10,7 @@ import (
 	"math"
 	"os"
 	"path/filepath"
+	"sort"
 
 	"github.com/Jcho114/go-git/repo"
 )
@@ -19,24 +21,32 @@ type IndexTimestamp struct {
 }
 
 type IndexEntry struct {
-	Ctime     IndexTimestamp
-	Mtime     IndexTimestamp
-	Dev       int
-	Ino       int
-	Modetype  int
-	Modeperms int
-	Uid       int
-	Gid       int
-	Fsize     int
-	Sha       string
-	Flagvalid bool
-	Flagstage int
-	Name      string
+	Ctime            IndexTimestamp
+	Mtime            IndexTimestamp
+	Dev              int
+	Ino              int
+	Modetype         int
+	Modeperms        int
+	Uid              int
+	Gid              int
+	Fsize            int
+	Sha              string
+	Flagvalid        bool
+	Flagstage        int
+	Flagintenttoadd  bool
+	Flagskipworktree bool
+	Name             string
+}
+
+type IndexExtension struct {
+	Signature string
+	Data      []byte
 }
 
 type Index struct {
-	Version int
-	Entries []IndexEntry
+	Version    int
+	Entries    []IndexEntry
+	Extensions []IndexExtension
 }
 
 const DEFAULT_VERSION = 2
@@ -61,22 +71,34 @@ func IndexRead(repository *repo.Repository) (*Index, error) {
 		return nil, err
 	}
 
+	if len(content) < 12+20 {
+		return nil, fmt.Errorf("provided index is too short")
+	}
+	checksum := sha1.Sum(content[:len(content)-20])
+	if !bytes.Equal(checksum[:], content[len(content)-20:]) {
+		return nil, fmt.Errorf("provided index has an invalid checksum")
+	}
+
 	header := content[:12]
 	signature := string(header[:4])
-	if err != nil {
-		return nil, err
-	}
 	if signature != "DIRC" {
 		return nil, fmt.Errorf("provided index has an invalid signature")
 	}
 	version := int(binary.BigEndian.Uint32(header[4:8]))
+	if version != 2 && version != 3 {
+		return nil, fmt.Errorf("unsupported index version %d", version)
+	}
 	count := int(binary.BigEndian.Uint32(header[8:12]))
 
 	entries := []IndexEntry{}
-	content = content[12:]
+	content = content[12 : len(content)-20]
 	curr := 0
 
 	for range count {
+		if curr+62 > len(content) {
+			return nil, fmt.Errorf("provided index is truncated")
+		}
+
 		ctimeseconds := int(binary.BigEndian.Uint32(content[curr : curr+4]))
 		ctimenanoseconds := int(binary.BigEndian.Uint32(content[curr+4 : curr+8]))
 
@@ -111,25 +133,37 @@ func IndexRead(repository *repo.Repository) (*Index, error) {
 		flags := int(binary.BigEndian.Uint16(content[curr+60 : curr+62]))
 		flagvalid := (flags & 0b1000000000000000) != 0
 		flagextended := (flags & 0b0100000000000000) != 0
-		if flagextended {
-			return nil, fmt.Errorf("flag is extended in an entry of the provided index file")
-		}
-		flagstage := flags & 0b0011000000000000
+		flagstage := (flags & 0b0011000000000000) >> 12
 
 		namelength := flags & 0b0000111111111111
 		curr += 62
 
+		flagintenttoadd, flagskipworktree := false, false
+		if flagextended {
+			if version < 3 {
+				return nil, fmt.Errorf("flag is extended in an entry of the provided version %d index file", version)
+			}
+			extendedflags := int(binary.BigEndian.Uint16(content[curr : curr+2]))
+			flagintenttoadd = (extendedflags & 0b0010000000000000) != 0
+			flagskipworktree = (extendedflags & 0b0100000000000000) != 0
+			curr += 2
+		}
+
 		var nameraw []byte
 		if namelength < 0xFFF {
-			if content[curr+namelength] != 0x00 {
+			if curr+namelength >= len(content) || content[curr+namelength] != 0x00 {
 				return nil, fmt.Errorf("invalid name in an entry of the provided index file")
 			}
 			nameraw = content[curr : curr+namelength]
 			curr += namelength + 1
 		} else {
-			fmt.Printf("notice: name is 0x%X bytes long\n", namelength)
-			nullindex := bytes.Index(content[curr+namelength:], []byte{'\x00'}) + curr + namelength
-			nameraw = content[curr : curr+nullindex]
+			nullindex := bytes.IndexByte(content[curr+namelength:], 0x00)
+			if nullindex == -1 {
+				return nil, fmt.Errorf("invalid name in an entry of the provided index file")
+			}
+			nullindex += curr + namelength
+			nameraw = content[curr:nullindex]
+			curr = nullindex + 1
 		}
 		name := string(nameraw)
 
@@ -148,13 +182,187 @@ func IndexRead(repository *repo.Repository) (*Index, error) {
 		entry.Sha = sha
 		entry.Flagvalid = flagvalid
 		entry.Flagstage = flagstage
+		entry.Flagintenttoadd = flagintenttoadd
+		entry.Flagskipworktree = flagskipworktree
 		entry.Name = name
 		entries = append(entries, entry)
 	}
 
+	extensions := []IndexExtension{}
+	for curr < len(content) {
+		if curr+8 > len(content) {
+			return nil, fmt.Errorf("provided index has a truncated extension")
+		}
+		signature := string(content[curr : curr+4])
+		size := int(binary.BigEndian.Uint32(content[curr+4 : curr+8]))
+		curr += 8
+		if curr+size > len(content) {
+			return nil, fmt.Errorf("provided index has a truncated %s extension", signature)
+		}
+		if signature[0] < 'A' || signature[0] > 'Z' {
+			return nil, fmt.Errorf("provided index requires unsupported %s extension", signature)
+		}
+
+		data := make([]byte, size)
+		copy(data, content[curr:curr+size])
+		extensions = append(extensions, IndexExtension{Signature: signature, Data: data})
+		curr += size
+	}
+
 	index := &Index{
-		Version: version,
-		Entries: entries,
+		Version:    version,
+		Entries:    entries,
+		Extensions: extensions,
 	}
 	return index, nil
 }
+
+func NewIndexEntry(name string, sha string, info os.FileInfo) IndexEntry {
+	entry := IndexEntry{}
+	entry.Mtime = IndexTimestamp{Seconds: info.ModTime().Unix(), Nanoseconds: int64(info.ModTime().Nanosecond())}
+	entry.Ctime = entry.Mtime
+	entry.Fsize = int(info.Size())
+	entry.Sha = sha
+	entry.Name = name
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		entry.Modetype = 0b1010
+		entry.Modeperms = 0
+	} else {
+		entry.Modetype = 0b1000
+		if info.Mode().Perm()&0o100 != 0 {
+			entry.Modeperms = 0o755
+		} else {
+			entry.Modeperms = 0o644
+		}
+	}
+
+	statFill(&entry, info)
+	return entry
+}
+
+func IndexWrite(repository *repo.Repository, index *Index) error {
+	sort.SliceStable(index.Entries, func(i, j int) bool {
+		if index.Entries[i].Name != index.Entries[j].Name {
+			return index.Entries[i].Name < index.Entries[j].Name
+		}
+		return index.Entries[i].Flagstage < index.Entries[j].Flagstage
+	})
+
+	var buffer bytes.Buffer
+	buffer.WriteString("DIRC")
+	buffer.Write(binary.BigEndian.AppendUint32(nil, uint32(index.Version)))
+	buffer.Write(binary.BigEndian.AppendUint32(nil, uint32(len(index.Entries))))
+
+	for _, entry := range index.Entries {
+		start := buffer.Len()
+
+		buffer.Write(binary.BigEndian.AppendUint32(nil, uint32(entry.Ctime.Seconds)))
+		buffer.Write(binary.BigEndian.AppendUint32(nil, uint32(entry.Ctime.Nanoseconds)))
+		buffer.Write(binary.BigEndian.AppendUint32(nil, uint32(entry.Mtime.Seconds)))
+		buffer.Write(binary.BigEndian.AppendUint32(nil, uint32(entry.Mtime.Nanoseconds)))
+		buffer.Write(binary.BigEndian.AppendUint32(nil, uint32(entry.Dev)))
+		buffer.Write(binary.BigEndian.AppendUint32(nil, uint32(entry.Ino)))
+
+		mode := entry.Modetype<<12 | entry.Modeperms
+		buffer.Write(binary.BigEndian.AppendUint32(nil, uint32(mode)))
+
+		buffer.Write(binary.BigEndian.AppendUint32(nil, uint32(entry.Uid)))
+		buffer.Write(binary.BigEndian.AppendUint32(nil, uint32(entry.Gid)))
+		buffer.Write(binary.BigEndian.AppendUint32(nil, uint32(entry.Fsize)))
+
+		sharaw, err := hex.DecodeString(entry.Sha)
+		if err != nil {
+			return err
+		}
+		if len(sharaw) != 20 {
+			return fmt.Errorf("invalid sha %s for index entry %s", entry.Sha, entry.Name)
+		}
+		buffer.Write(sharaw)
+
+		flags := 0
+		if entry.Flagvalid {
+			flags |= 0b1000000000000000
+		}
+		flags |= entry.Flagstage << 12
+		namelength := len(entry.Name)
+		if namelength >= 0xFFF {
+			namelength = 0xFFF
+		}
+		flags |= namelength
+		extended := entry.Flagintenttoadd || entry.Flagskipworktree
+		if extended {
+			if index.Version < 3 {
+				return fmt.Errorf("index entry %s needs extended flags which version %d cannot store", entry.Name, index.Version)
+			}
+			flags |= 0b0100000000000000
+		}
+		buffer.Write(binary.BigEndian.AppendUint16(nil, uint16(flags)))
+
+		if extended {
+			extendedflags := 0
+			if entry.Flagintenttoadd {
+				extendedflags |= 0b0010000000000000
+			}
+			if entry.Flagskipworktree {
+				extendedflags |= 0b0100000000000000
+			}
+			buffer.Write(binary.BigEndian.AppendUint16(nil, uint16(extendedflags)))
+		}
+
+		buffer.WriteString(entry.Name)
+		length := buffer.Len() - start
+		padding := 8 - length%8
+		buffer.Write(make([]byte, padding))
+	}
+
+	for _, extension := range index.Extensions {
+		if len(extension.Signature) != 4 {
+			return fmt.Errorf("invalid index extension signature %s", extension.Signature)
+		}
+		buffer.WriteString(extension.Signature)
+		buffer.Write(binary.BigEndian.AppendUint32(nil, uint32(len(extension.Data))))
+		buffer.Write(extension.Data)
+	}
+
+	checksum := sha1.Sum(buffer.Bytes())
+	buffer.Write(checksum[:])
+
+	indexpath := filepath.Join(repository.Gitdir, "index")
+	lockpath := indexpath + ".lock"
+	file, err := os.OpenFile(lockpath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("unable to create %s: index is locked by another process", lockpath)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(buffer.Bytes())
+	if err != nil {
+		file.Close()
+		os.Remove(lockpath)
+		return err
+	}
+	err = file.Close()
+	if err != nil {
+		os.Remove(lockpath)
+		return err
+	}
+
+	return os.Rename(lockpath, indexpath)
+}
+
+// Extensions such as the cache tree and untracked cache describe the entries
+// they were written alongside, so they have to go once the entries change.
+func (index *Index) InvalidateCaches() {
+	extensions := []IndexExtension{}
+	for _, extension := range index.Extensions {
+		switch extension.Signature {
+		case "TREE", "UNTR", "FSMN", "EOIE", "IEOT":
+			continue
+		}
+		extensions = append(extensions, extension)
+	}
+	index.Extensions = extensions
+}
@@ -0,0 +1,124 @@
+package index
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Jcho114/go-git/repo"
+)
+
+// The fixtures in testdata were written by real git, see generate.sh.
+func TestIndexWriteRoundTrip(t *testing.T) {
+	tests := []struct {
+		fixture    string
+		version    int
+		entries    int
+		extensions []string
+	}{
+		{fixture: "v2.index", version: 2, entries: 5},
+		{fixture: "v3-extended.index", version: 3, entries: 3},
+		{fixture: "long-name.index", version: 2, entries: 2},
+		{fixture: "tree-reuc.index", version: 2, entries: 2, extensions: []string{"TREE", "REUC"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.fixture, func(t *testing.T) {
+			original, err := os.ReadFile(filepath.Join("testdata", test.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			repository := &repo.Repository{Gitdir: t.TempDir()}
+			indexpath := filepath.Join(repository.Gitdir, "index")
+			err = os.WriteFile(indexpath, original, 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			index, err := IndexRead(repository)
+			if err != nil {
+				t.Fatalf("IndexRead: %v", err)
+			}
+			if index.Version != test.version {
+				t.Errorf("version = %d, want %d", index.Version, test.version)
+			}
+			if len(index.Entries) != test.entries {
+				t.Errorf("%d entries, want %d", len(index.Entries), test.entries)
+			}
+			signatures := []string{}
+			for _, extension := range index.Extensions {
+				signatures = append(signatures, extension.Signature)
+			}
+			if len(signatures) != len(test.extensions) {
+				t.Errorf("extensions = %v, want %v", signatures, test.extensions)
+			}
+			for i := range min(len(signatures), len(test.extensions)) {
+				if signatures[i] != test.extensions[i] {
+					t.Errorf("extensions = %v, want %v", signatures, test.extensions)
+				}
+			}
+
+			// Remove the original so the comparison is against what was written.
+			err = os.Remove(indexpath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = IndexWrite(repository, index)
+			if err != nil {
+				t.Fatalf("IndexWrite: %v", err)
+			}
+			written, err := os.ReadFile(indexpath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			body := written[:len(written)-20]
+			checksum := sha1.Sum(body)
+			if !bytes.Equal(checksum[:], written[len(written)-20:]) {
+				t.Errorf("written index has checksum %x, want %x", written[len(written)-20:], checksum)
+			}
+			if !bytes.Equal(written, original) {
+				t.Errorf("written index differs from the git fixture: %d bytes, want %d", len(written), len(original))
+				for i := range min(len(written), len(original)) {
+					if written[i] != original[i] {
+						t.Errorf("first difference at byte %d", i)
+						break
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestIndexReadFlags(t *testing.T) {
+	original, err := os.ReadFile(filepath.Join("testdata", "v3-extended.index"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	repository := &repo.Repository{Gitdir: t.TempDir()}
+	err = os.WriteFile(filepath.Join(repository.Gitdir, "index"), original, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	index, err := IndexRead(repository)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	flags := make(map[string][2]bool)
+	for _, entry := range index.Entries {
+		flags[entry.Name] = [2]bool{entry.Flagintenttoadd, entry.Flagskipworktree}
+	}
+	want := map[string][2]bool{
+		"one.txt":    {false, false},
+		"sparse.txt": {false, true},
+		"two.txt":    {true, false},
+	}
+	for name, value := range want {
+		if flags[name] != value {
+			t.Errorf("%s has intent-to-add, skip-worktree = %v, want %v", name, flags[name], value)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+//go:build linux
+
+package index
+
+import (
+	"os"
+	"syscall"
+)
+
+func statFill(entry *IndexEntry, info os.FileInfo) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return
+	}
+
+	entry.Ctime = IndexTimestamp{Seconds: int64(stat.Ctim.Sec), Nanoseconds: int64(stat.Ctim.Nsec)}
+	entry.Mtime = IndexTimestamp{Seconds: int64(stat.Mtim.Sec), Nanoseconds: int64(stat.Mtim.Nsec)}
+	entry.Dev = int(stat.Dev)
+	entry.Ino = int(stat.Ino)
+	entry.Uid = int(stat.Uid)
+	entry.Gid = int(stat.Gid)
+}
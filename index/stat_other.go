@@ -0,0 +1,10 @@
+//go:build !linux
+
+package index
+
+import (
+	"os"
+)
+
+func statFill(entry *IndexEntry, info os.FileInfo) {
+}
@@ -0,0 +1,37 @@
+#!/bin/sh
+# Regenerates the fixture indexes with real git. Run from this directory.
+set -e
+out=$(pwd)
+tmp=$(mktemp -d)
+trap 'rm -rf "$tmp"' EXIT
+export GIT_AUTHOR_NAME=fixture GIT_AUTHOR_EMAIL=fixture@example.com
+export GIT_COMMITTER_NAME=fixture GIT_COMMITTER_EMAIL=fixture@example.com
+export GIT_AUTHOR_DATE="2024-01-01T00:00:00Z" GIT_COMMITTER_DATE="2024-01-01T00:00:00Z"
+
+git init -q "$tmp/v2" && cd "$tmp/v2"
+echo one > one.txt && echo two > two.txt && mkdir -p dir && echo three > dir/three.txt
+printf '#!/bin/sh\n' > run.sh && chmod +x run.sh && ln -s one.txt link
+git add . && cp .git/index "$out/v2.index"
+
+git init -q "$tmp/v3" && cd "$tmp/v3"
+echo one > one.txt && echo two > two.txt && echo sparse > sparse.txt
+git add one.txt sparse.txt && git add -N two.txt
+git update-index --skip-worktree sparse.txt
+cp .git/index "$out/v3-extended.index"
+
+git init -q "$tmp/long" && cd "$tmp/long"
+blob=$(echo long | git hash-object -w --stdin)
+name=$(printf 'd%.0s' $(seq 1 200))
+long="$name/$name/$name/$name/$name/$name/$name/$name/$name/$name/$name/$name/$name/$name/$name/$name/$name/$name/$name/$name/$name/$name/file.txt"
+git update-index --add --cacheinfo 100644,"$blob",short.txt
+git update-index --add --cacheinfo 100644,"$blob","$long"
+cp .git/index "$out/long-name.index"
+
+git init -q -b main "$tmp/ext" && cd "$tmp/ext"
+echo base > file.txt && mkdir -p sub && echo sub > sub/file.txt
+git add . && git commit -qm base
+git checkout -qb side && echo side > file.txt && git commit -qam side
+git checkout -q main && echo main > file.txt && git commit -qam main
+git merge side >/dev/null 2>&1 || true
+echo resolved > file.txt && git add file.txt && git write-tree >/dev/null
+cp .git/index "$out/tree-reuc.index"
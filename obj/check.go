@@ -0,0 +1,188 @@
+package obj
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var treeModes = []string{"100644", "100755", "120000", "40000", "160000"}
+
+func treeParseChecked(data []byte) ([]*TreeLeaf, error) {
+	res := []*TreeLeaf{}
+	curr := 0
+	for curr < len(data) {
+		spaceindex := bytes.IndexByte(data[curr:], ' ')
+		if spaceindex <= 0 {
+			return nil, fmt.Errorf("malformed mode in tree entry")
+		}
+		mode := string(data[curr : curr+spaceindex])
+		curr += spaceindex + 1
+
+		nulindex := bytes.IndexByte(data[curr:], 0)
+		if nulindex == -1 {
+			return nil, fmt.Errorf("malformed name in tree entry")
+		}
+		name := string(data[curr : curr+nulindex])
+		curr += nulindex + 1
+
+		if curr+20 > len(data) {
+			return nil, fmt.Errorf("truncated sha in tree entry")
+		}
+		sha := hex.EncodeToString(data[curr : curr+20])
+		curr += 20
+
+		res = append(res, NewTreeLeaf(mode, name, sha))
+	}
+	return res, nil
+}
+
+// ObjectLinks lists the objects directly referenced by an object, without
+// following them. Tree entries keep their names in Path.
+func ObjectLinks(format string, data []byte) ([]WalkEntry, error) {
+	res := []WalkEntry{}
+	switch format {
+	case "commit":
+		kvlm := parseKVLM(data, kvlmap{})
+		for _, tree := range kvlm["tree"] {
+			res = append(res, WalkEntry{Sha: tree, Format: "tree"})
+		}
+		for _, parent := range kvlm["parent"] {
+			res = append(res, WalkEntry{Sha: parent, Format: "commit"})
+		}
+	case "tag":
+		kvlm := parseKVLM(data, kvlmap{})
+		objtype := ""
+		if len(kvlm["type"]) > 0 {
+			objtype = kvlm["type"][0]
+		}
+		for _, object := range kvlm["object"] {
+			res = append(res, WalkEntry{Sha: object, Format: objtype})
+		}
+	case "tree":
+		leaves, err := treeParseChecked(data)
+		if err != nil {
+			return nil, err
+		}
+		for _, leaf := range leaves {
+			if strings.HasPrefix(leaf.Mode, "16") {
+				continue
+			}
+			format := "blob"
+			if leaf.IsTree() {
+				format = "tree"
+			}
+			res = append(res, WalkEntry{Sha: leaf.Sha, Format: format, Path: leaf.Path})
+		}
+	}
+	return res, nil
+}
+
+func checkSha(value string) bool {
+	return len(value) == 40 && hashRegex.MatchString(value) && strings.ToLower(value) == value
+}
+
+func checkIdent(value string) error {
+	open, close := strings.Index(value, " <"), strings.Index(value, "> ")
+	if open == -1 || close == -1 || close < open {
+		return fmt.Errorf("bad email")
+	}
+	fields := strings.Fields(value[close+2:])
+	if len(fields) != 2 || strings.Trim(fields[0], "0123456789") != "" {
+		return fmt.Errorf("bad date")
+	}
+	zone := fields[1]
+	if len(zone) != 5 || (zone[0] != '+' && zone[0] != '-') || strings.Trim(zone[1:], "0123456789") != "" {
+		return fmt.Errorf("bad time zone")
+	}
+	return nil
+}
+
+func checkHeaders(data []byte, order []string) (kvlmap, error) {
+	kvlm := parseKVLM(data, kvlmap{})
+	for i, key := range order {
+		if len(kvlm[key]) == 0 {
+			return nil, fmt.Errorf("missing %s header", key)
+		}
+		prefix := key + " "
+		if i == 0 && !bytes.HasPrefix(data, []byte(prefix)) {
+			return nil, fmt.Errorf("%s header is not first", key)
+		}
+	}
+	return kvlm, nil
+}
+
+// ObjectCheck validates the structure of an object's content the way
+// git fsck does, returning the first problem found.
+func ObjectCheck(format string, data []byte) error {
+	switch format {
+	case "blob":
+		return nil
+	case "commit":
+		kvlm, err := checkHeaders(data, []string{"tree", "author", "committer"})
+		if err != nil {
+			return err
+		}
+		if len(kvlm["tree"]) != 1 || !checkSha(kvlm["tree"][0]) {
+			return fmt.Errorf("invalid tree")
+		}
+		for _, parent := range kvlm["parent"] {
+			if !checkSha(parent) {
+				return fmt.Errorf("invalid parent %s", parent)
+			}
+		}
+		for _, key := range []string{"author", "committer"} {
+			if len(kvlm[key]) != 1 {
+				return fmt.Errorf("multiple %s headers", key)
+			}
+			if err := checkIdent(kvlm[key][0]); err != nil {
+				return fmt.Errorf("invalid %s: %w", key, err)
+			}
+		}
+		return nil
+	case "tag":
+		kvlm, err := checkHeaders(data, []string{"object", "type", "tag"})
+		if err != nil {
+			return err
+		}
+		if !checkSha(kvlm["object"][0]) {
+			return fmt.Errorf("invalid object")
+		}
+		if !slices.Contains([]string{"commit", "tree", "blob", "tag"}, kvlm["type"][0]) {
+			return fmt.Errorf("invalid type %s", kvlm["type"][0])
+		}
+		if len(kvlm["tagger"]) > 0 {
+			if err := checkIdent(kvlm["tagger"][0]); err != nil {
+				return fmt.Errorf("invalid tagger: %w", err)
+			}
+		}
+		return nil
+	case "tree":
+		leaves, err := treeParseChecked(data)
+		if err != nil {
+			return err
+		}
+		for i, leaf := range leaves {
+			if !slices.Contains(treeModes, strings.TrimLeft(leaf.Mode, "0")) {
+				return fmt.Errorf("bad mode %s for %s", leaf.Mode, leaf.Path)
+			}
+			if leaf.Path == "" || leaf.Path == "." || leaf.Path == ".." || leaf.Path == ".git" || strings.Contains(leaf.Path, "/") {
+				return fmt.Errorf("bad name '%s'", leaf.Path)
+			}
+			if i > 0 {
+				prev := leaves[i-1]
+				if prev.Path == leaf.Path {
+					return fmt.Errorf("duplicate entry '%s'", leaf.Path)
+				}
+				if prev.Key() > leaf.Key() {
+					return fmt.Errorf("entries not sorted at '%s'", leaf.Path)
+				}
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown type %s", format)
+	}
+}
@@ -2,28 +2,42 @@ package obj
 
 import (
 	"bytes"
+	"slices"
 	"strings"
 )
 
 type kvlmap = map[string][]string
 
+var kvlmOrder = []string{"tree", "parent", "object", "type", "tag", "author", "committer", "tagger", "encoding", "mergetag", "gpgsig"}
+
 func parseKVLM(content []byte, dct kvlmap) kvlmap {
 	start := 0
-	for {
-		spaceindex := bytes.IndexByte(content[start:], ' ') + start
-		newlineindex := bytes.IndexByte(content[start:], '\n') + start
+	for start < len(content) {
+		spaceindex := bytes.IndexByte(content[start:], ' ')
+		newlineindex := bytes.IndexByte(content[start:], '\n')
 
-		if spaceindex == -1 || newlineindex < spaceindex {
-			message := string(content[start+1:])
+		if spaceindex == -1 || newlineindex == -1 || newlineindex < spaceindex {
+			message := ""
+			if newlineindex == 0 {
+				message = string(content[start+1:])
+			} else {
+				message = string(content[start:])
+			}
 			dct[""] = []string{message}
-			break
+			return dct
 		}
+		spaceindex += start
 
 		key := string(content[start:spaceindex])
 		end := start
 		for {
-			end = bytes.IndexByte(content[end+1:], '\n') + end + 1
-			if content[end+1] != ' ' {
+			nextindex := bytes.IndexByte(content[end+1:], '\n')
+			if nextindex == -1 {
+				end = len(content)
+				break
+			}
+			end = nextindex + end + 1
+			if end+1 >= len(content) || content[end+1] != ' ' {
 				break
 			}
 		}
@@ -44,16 +58,25 @@ func parseKVLM(content []byte, dct kvlmap) kvlmap {
 func serializeKVLM(kvlm kvlmap) string {
 	res := ""
 
+	keys := []string{}
 	for key := range kvlm {
-		if key == "" {
-			continue
+		if key != "" && !slices.Contains(kvlmOrder, key) {
+			keys = append(keys, key)
 		}
+	}
+	slices.Sort(keys)
+	keys = append(slices.Clone(kvlmOrder), keys...)
 
+	for _, key := range keys {
 		values := kvlm[key]
 		for _, value := range values {
 			res += key + " " + strings.ReplaceAll(value, "\n", "\n ") + "\n"
 		}
 	}
 
+	if message, ok := kvlm[""]; ok && len(message) > 0 {
+		res += "\n" + message[0]
+	}
+
 	return res
 }
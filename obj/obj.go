@@ -3,14 +3,17 @@ package obj
 import (
 	"bytes"
 	"compress/zlib"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
+	"syscall"
 
 	"crypto/sha1"
 
@@ -26,18 +29,21 @@ type Object interface {
 
 var hashRegex = regexp.MustCompile("^[0-9A-Fa-f]{4,40}$")
 
+var pseudoRefRegex = regexp.MustCompile("^[A-Z_]*HEAD$")
+
+// ObjectFind resolves a revision in git's notation, see objectRevision, and
+// peels it to an object of the given format, or returns it as is for "any".
 func ObjectFind(repository *repo.Repository, name string, format string, follow bool) (string, error) {
-	objnames, err := objectResolve(repository, name)
+	objname, err := objectRevision(repository, name)
 	if err != nil {
 		return "", err
 	}
+	return objectPeel(repository, name, objname, format)
+}
 
-	if len(objnames) == 0 || len(objnames) > 1 {
-		return "", fmt.Errorf("%s is an ambiguous reference", name)
-	}
-
-	objname := objnames[0]
-
+// objectPeel follows commits to their trees and tags to their objects until
+// an object of the given format is reached.
+func objectPeel(repository *repo.Repository, name string, objname string, format string) (string, error) {
 	if format == "any" {
 		return objname, nil
 	}
@@ -45,7 +51,7 @@ func ObjectFind(repository *repo.Repository, name string, format string, follow
 	for {
 		object, err := ObjectRead(repository, objname)
 		if err != nil {
-			return "", nil
+			return "", err
 		}
 
 		switch format {
@@ -104,121 +110,215 @@ func objectResolve(repository *repo.Repository, name string) ([]string, error) {
 	}
 
 	if hashRegex.MatchString(name) {
-		prefix := name[:2]
+		hexname := strings.ToLower(name)
+		prefix := hexname[:2]
 		path := filepath.Join(repository.Gitdir, "objects", prefix)
 		info, err := os.Stat(path)
 		pathexists := !errors.Is(err, os.ErrNotExist)
 		if pathexists && info.Mode().IsDir() {
-			rem := prefix[2:]
+			rem := hexname[2:]
 			files, err := os.ReadDir(path)
 			if err != nil {
 				return nil, err
 			}
 			for _, file := range files {
 				if strings.HasPrefix(file.Name(), rem) {
-					candidates = append(candidates, file.Name())
+					candidates = append(candidates, prefix+file.Name())
 				}
 			}
 		}
-	}
 
-	tagname, err := ref.RefResolve(repository, "refs/tags/"+name)
-	pathexists := errors.Is(err, os.ErrNotExist)
-	if pathexists {
-		return nil, err
+		packed, err := packPrefix(repository, hexname)
+		if err != nil {
+			return nil, err
+		}
+		for _, sha := range packed {
+			if !slices.Contains(candidates, sha) {
+				candidates = append(candidates, sha)
+			}
+		}
 	}
-	if err == nil {
-		candidates = append(candidates, tagname)
+
+	// Full ref names and pseudo refs such as ORIG_HEAD are taken as they are.
+	if (strings.HasPrefix(name, "refs/") && ref.RefCheckFormat(name)) || pseudoRefRegex.MatchString(name) {
+		sha, err := objectRef(repository, name)
+		if err != nil {
+			return nil, err
+		}
+		if sha != "" && !slices.Contains(candidates, sha) {
+			candidates = append(candidates, sha)
+		}
 	}
 
-	branchname, err := ref.RefResolve(repository, "refs/heads/"+name)
-	pathexists = errors.Is(err, os.ErrNotExist)
-	if pathexists {
-		return nil, err
+	for _, prefix := range []string{"refs/tags/", "refs/heads/"} {
+		sha, err := objectRef(repository, prefix+name)
+		if err != nil {
+			return nil, err
+		}
+		if sha != "" && !slices.Contains(candidates, sha) {
+			candidates = append(candidates, sha)
+		}
 	}
-	if err == nil {
-		candidates = append(candidates, branchname)
+	if len(candidates) > 0 {
+		return candidates, nil
 	}
 
+	// Remote-tracking branches are only considered when nothing local
+	// matches, and a remote's name stands for its HEAD.
+	for _, refname := range []string{"refs/remotes/" + name, "refs/remotes/" + name + "/HEAD"} {
+		sha, err := objectRef(repository, refname)
+		if err != nil {
+			return nil, err
+		}
+		if sha != "" {
+			return []string{sha}, nil
+		}
+	}
 	return candidates, nil
 }
 
+// objectRef resolves a ref, returning an empty name when it does not exist.
+func objectRef(repository *repo.Repository, name string) (string, error) {
+	if info, err := os.Stat(filepath.Join(repository.Gitdir, name)); err == nil && info.IsDir() {
+		return "", nil
+	}
+	sha, err := ref.RefResolve(repository, name)
+	if errors.Is(err, os.ErrNotExist) || errors.Is(err, syscall.ENOTDIR) {
+		return "", nil
+	}
+	return sha, err
+}
+
 func ObjectRead(repository *repo.Repository, sha string) (Object, error) {
+	format, data, err := ObjectReadRaw(repository, sha)
+	if err != nil {
+		return nil, err
+	}
+
+	object, err := ObjectNew(format, data)
+	if err != nil {
+		return nil, fmt.Errorf("object %s: %w", sha, err)
+	}
+	return object, nil
+}
+
+func ObjectNew(format string, data []byte) (Object, error) {
+	switch format {
+	case "commit":
+		return NewCommit(data), nil
+	case "tree":
+		return NewTree(data), nil
+	case "tag":
+		return NewTag(data), nil
+	case "blob":
+		return NewBlob(data), nil
+	default:
+		return nil, fmt.Errorf("unknown type %s", format)
+	}
+}
+
+func ObjectReadRaw(repository *repo.Repository, sha string) (string, []byte, error) {
+	if len(sha) != 40 {
+		return "", nil, fmt.Errorf("invalid object name %s", sha)
+	}
+
 	objfilepath := filepath.Join(repository.Gitdir, "objects", sha[0:2], sha[2:])
 	info, err := os.Stat(objfilepath)
+	if errors.Is(err, os.ErrNotExist) {
+		format, data, packerr := packRead(repository, sha)
+		if packerr == nil {
+			return format, data, nil
+		}
+		if !errors.Is(packerr, os.ErrNotExist) {
+			return "", nil, packerr
+		}
+	}
 	if err != nil {
-		return nil, err
+		return "", nil, err
 	}
 
 	if !info.Mode().IsRegular() {
-		return nil, fmt.Errorf("object does not exist")
+		return "", nil, fmt.Errorf("object does not exist")
 	}
 
 	file, err := os.ReadFile(objfilepath)
 	if err != nil {
-		return nil, err
+		return "", nil, err
 	}
 	buffer := bytes.NewBuffer(file)
 	reader, err := zlib.NewReader(buffer)
 	if err != nil {
-		return nil, err
+		return "", nil, err
 	}
 	defer reader.Close()
 
 	_, err = io.Copy(buffer, reader)
 	if err != nil {
-		return nil, err
+		return "", nil, err
 	}
 
 	wsindex := bytes.Index(buffer.Bytes(), []byte(" "))
+	if wsindex == -1 {
+		return "", nil, fmt.Errorf("malformed object %s: missing header", sha)
+	}
 	format := buffer.Bytes()[:wsindex]
 
-	nulindex := wsindex + bytes.Index(buffer.Bytes()[wsindex:], []byte("\x00"))
+	nulindex := bytes.Index(buffer.Bytes()[wsindex:], []byte("\x00"))
+	if nulindex == -1 {
+		return "", nil, fmt.Errorf("malformed object %s: missing header", sha)
+	}
+	nulindex += wsindex
 	size, err := strconv.Atoi(string(buffer.Bytes()[wsindex+1 : nulindex]))
 	if err != nil {
-		return nil, err
+		return "", nil, err
 	}
 
 	if size != buffer.Len()-nulindex-1 {
-		return nil, fmt.Errorf("malformed object %s: bad length", sha)
+		return "", nil, fmt.Errorf("malformed object %s: bad length", sha)
 	}
 
-	switch string(format) {
-	case "commit":
-		return NewCommit(buffer.Bytes()[nulindex+1:]), nil
-	case "tree":
-		return NewTree(buffer.Bytes()[nulindex+1:]), nil
-	case "tag":
-		return NewTag(buffer.Bytes()[nulindex+1:]), nil
-	case "blob":
-		return NewBlob(buffer.Bytes()[nulindex+1:]), nil
-	default:
-		return nil, fmt.Errorf("unknown type %s for object %s", format, sha)
-	}
+	return string(format), buffer.Bytes()[nulindex+1:], nil
 }
 
 func ObjectWrite(repository *repo.Repository, object Object) (string, error) {
-	data := object.Serialize(repository)
-	result := object.Type() + " " + strconv.Itoa(len(data)) + "\x00" + data
-	hash := sha1.New()
-	sha := hash.Sum([]byte(result))
+	return ObjectWriteRaw(repository, object.Type(), []byte(object.Serialize(repository)))
+}
+
+func ObjectWriteRaw(repository *repo.Repository, format string, data []byte) (string, error) {
+	result := format + " " + strconv.Itoa(len(data)) + "\x00" + string(data)
+	rawsha := sha1.Sum([]byte(result))
+	sha := hex.EncodeToString(rawsha[:])
 
 	if repository != nil {
-		objectfilepath := filepath.Join(repository.Gitdir, "objects", string(sha[:2]), string(sha[2:]))
+		objectdir := filepath.Join(repository.Gitdir, "objects", sha[:2])
+		objectfilepath := filepath.Join(objectdir, sha[2:])
+		_, err := os.Stat(objectfilepath)
+		pathexists := !errors.Is(err, os.ErrNotExist)
+		if pathexists {
+			return sha, nil
+		}
+
+		err = os.MkdirAll(objectdir, 0755)
+		if err != nil {
+			return "", err
+		}
 
 		var buffer bytes.Buffer
 		writer := zlib.NewWriter(&buffer)
-		_, err := writer.Write(sha)
+		_, err = writer.Write([]byte(result))
+		if err != nil {
+			return "", err
+		}
+		err = writer.Close()
 		if err != nil {
 			return "", err
 		}
-		writer.Close()
 
-		err = os.WriteFile(objectfilepath, buffer.Bytes(), 0644)
+		err = os.WriteFile(objectfilepath, buffer.Bytes(), 0444)
 		if err != nil {
 			return "", err
 		}
 	}
 
-	return string(sha), nil
+	return sha, nil
 }
@@ -0,0 +1,608 @@
+package obj
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Jcho114/go-git/repo"
+)
+
+const (
+	packTypeCommit   = 1
+	packTypeTree     = 2
+	packTypeBlob     = 3
+	packTypeTag      = 4
+	packTypeOfsDelta = 6
+	packTypeRefDelta = 7
+)
+
+var packTypeNames = map[int]string{
+	packTypeCommit: "commit",
+	packTypeTree:   "tree",
+	packTypeBlob:   "blob",
+	packTypeTag:    "tag",
+}
+
+type packIndex struct {
+	Packpath string
+	Fanout   [256]uint32
+	Shas     []byte
+	Crcs     []byte
+	Offsets  []uint64
+}
+
+type packCacheEntry struct {
+	Format string
+	Data   []byte
+}
+
+type packStore struct {
+	Mutex   sync.Mutex
+	Indexes map[string]*packIndex
+	Cache   map[string]map[uint64]packCacheEntry
+}
+
+// Packs never change once written, so they are parsed once per process and
+// the directory is only rescanned when a lookup misses.
+var packStores = map[string]*packStore{}
+var packStoresMutex sync.Mutex
+
+const packCacheLimit = 256
+
+func packStoreGet(repository *repo.Repository) *packStore {
+	packStoresMutex.Lock()
+	defer packStoresMutex.Unlock()
+
+	gitdir, err := filepath.Abs(repository.Gitdir)
+	if err != nil {
+		gitdir = repository.Gitdir
+	}
+	store, ok := packStores[gitdir]
+	if !ok {
+		store = &packStore{
+			Indexes: make(map[string]*packIndex),
+			Cache:   make(map[string]map[uint64]packCacheEntry),
+		}
+		packStores[gitdir] = store
+	}
+	return store
+}
+
+func packStoreScan(repository *repo.Repository, store *packStore) (bool, error) {
+	packdir := filepath.Join(repository.Gitdir, "objects", "pack")
+	entries, err := os.ReadDir(packdir)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	found := false
+	current := make(map[string]bool)
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".idx") {
+			continue
+		}
+		idxpath := filepath.Join(packdir, entry.Name())
+		current[idxpath] = true
+		if _, ok := store.Indexes[idxpath]; ok {
+			continue
+		}
+
+		packpath := strings.TrimSuffix(idxpath, ".idx") + ".pack"
+		if _, err := os.Stat(packpath); err != nil {
+			continue
+		}
+
+		idx, err := packIndexRead(idxpath, packpath)
+		if err != nil {
+			return false, err
+		}
+		store.Indexes[idxpath] = idx
+		found = true
+	}
+
+	for idxpath := range store.Indexes {
+		if !current[idxpath] {
+			delete(store.Cache, store.Indexes[idxpath].Packpath)
+			delete(store.Indexes, idxpath)
+		}
+	}
+
+	return found, nil
+}
+
+func packIndexRead(idxpath string, packpath string) (*packIndex, error) {
+	content, err := os.ReadFile(idxpath)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(content) < 8+256*4 || !bytes.Equal(content[:4], []byte{0xff, 't', 'O', 'c'}) {
+		return nil, fmt.Errorf("pack index %s is not a version 2 index", idxpath)
+	}
+	version := binary.BigEndian.Uint32(content[4:8])
+	if version != 2 {
+		return nil, fmt.Errorf("pack index %s has unsupported version %d", idxpath, version)
+	}
+
+	idx := &packIndex{Packpath: packpath}
+	curr := 8
+	for i := range 256 {
+		idx.Fanout[i] = binary.BigEndian.Uint32(content[curr : curr+4])
+		curr += 4
+	}
+
+	count := int(idx.Fanout[255])
+	if len(content) < curr+count*(20+4+4)+40 {
+		return nil, fmt.Errorf("pack index %s is truncated", idxpath)
+	}
+
+	idx.Shas = content[curr : curr+count*20]
+	curr += count * 20
+	idx.Crcs = content[curr : curr+count*4]
+	curr += count * 4
+
+	offsets := content[curr : curr+count*4]
+	curr += count * 4
+	largeoffsets := content[curr : len(content)-40]
+
+	idx.Offsets = make([]uint64, count)
+	for i := range count {
+		offset := binary.BigEndian.Uint32(offsets[i*4 : i*4+4])
+		if offset&0x80000000 == 0 {
+			idx.Offsets[i] = uint64(offset)
+			continue
+		}
+		large := int(offset&0x7fffffff) * 8
+		if large+8 > len(largeoffsets) {
+			return nil, fmt.Errorf("pack index %s has an invalid large offset", idxpath)
+		}
+		idx.Offsets[i] = binary.BigEndian.Uint64(largeoffsets[large : large+8])
+	}
+
+	return idx, nil
+}
+
+func (idx *packIndex) sha(i int) []byte {
+	return idx.Shas[i*20 : i*20+20]
+}
+
+func (idx *packIndex) find(rawsha []byte) (uint64, bool) {
+	lo := 0
+	if rawsha[0] > 0 {
+		lo = int(idx.Fanout[rawsha[0]-1])
+	}
+	hi := int(idx.Fanout[rawsha[0]])
+
+	i := lo + sort.Search(hi-lo, func(i int) bool {
+		return bytes.Compare(idx.sha(lo+i), rawsha) >= 0
+	})
+	if i < hi && bytes.Equal(idx.sha(i), rawsha) {
+		return idx.Offsets[i], true
+	}
+	return 0, false
+}
+
+func (idx *packIndex) prefix(hexprefix string) []string {
+	res := []string{}
+	if len(hexprefix) < 2 {
+		return res
+	}
+	first, err := hex.DecodeString(hexprefix[:2])
+	if err != nil {
+		return res
+	}
+
+	lo := 0
+	if first[0] > 0 {
+		lo = int(idx.Fanout[first[0]-1])
+	}
+	hi := int(idx.Fanout[first[0]])
+	for i := lo; i < hi; i++ {
+		sha := hex.EncodeToString(idx.sha(i))
+		if strings.HasPrefix(sha, hexprefix) {
+			res = append(res, sha)
+		}
+	}
+	return res
+}
+
+func packLookup(repository *repo.Repository, sha string) (*packStore, *packIndex, uint64, error) {
+	rawsha, err := hex.DecodeString(sha)
+	if err != nil || len(rawsha) != 20 {
+		return nil, nil, 0, fmt.Errorf("invalid object name %s", sha)
+	}
+
+	store := packStoreGet(repository)
+	store.Mutex.Lock()
+	defer store.Mutex.Unlock()
+
+	for attempt := 0; attempt < 2; attempt++ {
+		for _, idx := range store.Indexes {
+			if offset, ok := idx.find(rawsha); ok {
+				return store, idx, offset, nil
+			}
+		}
+		found, err := packStoreScan(repository, store)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		if !found {
+			break
+		}
+	}
+
+	return nil, nil, 0, os.ErrNotExist
+}
+
+func packRead(repository *repo.Repository, sha string) (string, []byte, error) {
+	store, idx, offset, err := packLookup(repository, sha)
+	if err != nil {
+		return "", nil, err
+	}
+
+	file, err := os.Open(idx.Packpath)
+	if err != nil {
+		return "", nil, err
+	}
+	defer file.Close()
+
+	return packReadAt(repository, store, idx.Packpath, file, offset, 0)
+}
+
+func packPrefix(repository *repo.Repository, hexprefix string) ([]string, error) {
+	store := packStoreGet(repository)
+	store.Mutex.Lock()
+	defer store.Mutex.Unlock()
+
+	_, err := packStoreScan(repository, store)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []string{}
+	for _, idx := range store.Indexes {
+		res = append(res, idx.prefix(hexprefix)...)
+	}
+	return res, nil
+}
+
+const packMaxDeltaDepth = 10000
+
+func packReadAt(repository *repo.Repository, store *packStore, packpath string, file *os.File, offset uint64, depth int) (string, []byte, error) {
+	if depth > packMaxDeltaDepth {
+		return "", nil, fmt.Errorf("delta chain in %s is too deep", packpath)
+	}
+
+	store.Mutex.Lock()
+	cached, ok := store.Cache[packpath][offset]
+	store.Mutex.Unlock()
+	if ok {
+		return cached.Format, cached.Data, nil
+	}
+
+	reader := io.NewSectionReader(file, int64(offset), 1<<62)
+	header := make([]byte, 1)
+	_, err := io.ReadFull(reader, header)
+	if err != nil {
+		return "", nil, err
+	}
+
+	objtype := int(header[0]>>4) & 0b111
+	size := uint64(header[0] & 0b1111)
+	shift := 4
+	for header[0]&0x80 != 0 {
+		_, err := io.ReadFull(reader, header)
+		if err != nil {
+			return "", nil, err
+		}
+		size |= uint64(header[0]&0x7f) << shift
+		shift += 7
+	}
+
+	var baseformat string
+	var basedata []byte
+	switch objtype {
+	case packTypeOfsDelta:
+		_, err := io.ReadFull(reader, header)
+		if err != nil {
+			return "", nil, err
+		}
+		distance := uint64(header[0] & 0x7f)
+		for header[0]&0x80 != 0 {
+			_, err := io.ReadFull(reader, header)
+			if err != nil {
+				return "", nil, err
+			}
+			distance = ((distance + 1) << 7) | uint64(header[0]&0x7f)
+		}
+		if distance == 0 || distance > offset {
+			return "", nil, fmt.Errorf("invalid delta base offset in %s", packpath)
+		}
+		baseformat, basedata, err = packReadAt(repository, store, packpath, file, offset-distance, depth+1)
+		if err != nil {
+			return "", nil, err
+		}
+	case packTypeRefDelta:
+		rawsha := make([]byte, 20)
+		_, err := io.ReadFull(reader, rawsha)
+		if err != nil {
+			return "", nil, err
+		}
+		baseformat, basedata, err = ObjectReadRaw(repository, hex.EncodeToString(rawsha))
+		if err != nil {
+			return "", nil, err
+		}
+	default:
+		if _, ok := packTypeNames[objtype]; !ok {
+			return "", nil, fmt.Errorf("unknown object type %d in %s", objtype, packpath)
+		}
+	}
+
+	zreader, err := zlib.NewReader(reader)
+	if err != nil {
+		return "", nil, err
+	}
+	defer zreader.Close()
+
+	data := make([]byte, size)
+	_, err = io.ReadFull(zreader, data)
+	if err != nil {
+		return "", nil, err
+	}
+
+	format := packTypeNames[objtype]
+	if basedata != nil {
+		format = baseformat
+		data, err = PackDeltaApply(basedata, data)
+		if err != nil {
+			return "", nil, err
+		}
+	}
+
+	store.Mutex.Lock()
+	if store.Cache[packpath] == nil || len(store.Cache[packpath]) >= packCacheLimit {
+		store.Cache[packpath] = make(map[uint64]packCacheEntry)
+	}
+	store.Cache[packpath][offset] = packCacheEntry{Format: format, Data: data}
+	store.Mutex.Unlock()
+
+	return format, data, nil
+}
+
+func packDeltaSize(delta []byte, curr int) (uint64, int, error) {
+	size := uint64(0)
+	shift := 0
+	for {
+		if curr >= len(delta) {
+			return 0, 0, fmt.Errorf("truncated delta header")
+		}
+		b := delta[curr]
+		curr++
+		size |= uint64(b&0x7f) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			return size, curr, nil
+		}
+	}
+}
+
+func PackDeltaApply(base []byte, delta []byte) ([]byte, error) {
+	basesize, curr, err := packDeltaSize(delta, 0)
+	if err != nil {
+		return nil, err
+	}
+	if basesize != uint64(len(base)) {
+		return nil, fmt.Errorf("delta base size mismatch")
+	}
+	resultsize, curr, err := packDeltaSize(delta, curr)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]byte, 0, resultsize)
+	for curr < len(delta) {
+		op := delta[curr]
+		curr++
+
+		if op&0x80 == 0 {
+			if op == 0 {
+				return nil, fmt.Errorf("invalid delta opcode 0")
+			}
+			if curr+int(op) > len(delta) {
+				return nil, fmt.Errorf("truncated delta insert")
+			}
+			result = append(result, delta[curr:curr+int(op)]...)
+			curr += int(op)
+			continue
+		}
+
+		copyoffset, copysize := uint64(0), uint64(0)
+		for i := range 4 {
+			if op&(1<<i) != 0 {
+				if curr >= len(delta) {
+					return nil, fmt.Errorf("truncated delta copy")
+				}
+				copyoffset |= uint64(delta[curr]) << (8 * i)
+				curr++
+			}
+		}
+		for i := range 3 {
+			if op&(1<<(4+i)) != 0 {
+				if curr >= len(delta) {
+					return nil, fmt.Errorf("truncated delta copy")
+				}
+				copysize |= uint64(delta[curr]) << (8 * i)
+				curr++
+			}
+		}
+		if copysize == 0 {
+			copysize = 0x10000
+		}
+		if copyoffset+copysize > uint64(len(base)) {
+			return nil, fmt.Errorf("delta copy out of bounds")
+		}
+		result = append(result, base[copyoffset:copyoffset+copysize]...)
+	}
+
+	if uint64(len(result)) != resultsize {
+		return nil, fmt.Errorf("delta result size mismatch")
+	}
+	return result, nil
+}
+
+func ObjectExists(repository *repo.Repository, sha string) bool {
+	if len(sha) != 40 {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(repository.Gitdir, "objects", sha[0:2], sha[2:]))
+	if err == nil {
+		return true
+	}
+	_, _, _, err = packLookup(repository, sha)
+	return err == nil
+}
+
+// PackVerify checks the trailing checksums of a pack and its index and that
+// both agree on the number of objects.
+func PackVerify(packpath string) error {
+	idxpath := strings.TrimSuffix(packpath, ".pack") + ".idx"
+	idx, err := packIndexRead(idxpath, packpath)
+	if err != nil {
+		return err
+	}
+	idxcontent, err := os.ReadFile(idxpath)
+	if err != nil {
+		return err
+	}
+	idxsum := sha1.Sum(idxcontent[:len(idxcontent)-20])
+	if !bytes.Equal(idxsum[:], idxcontent[len(idxcontent)-20:]) {
+		return fmt.Errorf("index checksum mismatch for %s", idxpath)
+	}
+
+	file, err := os.Open(packpath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() < 32 {
+		return fmt.Errorf("pack %s is truncated", packpath)
+	}
+
+	hash := sha1.New()
+	_, err = io.CopyN(hash, file, info.Size()-20)
+	if err != nil {
+		return err
+	}
+	trailer := make([]byte, 20)
+	_, err = io.ReadFull(file, trailer)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(hash.Sum(nil), trailer) {
+		return fmt.Errorf("pack checksum mismatch for %s", packpath)
+	}
+	if !bytes.Equal(idxcontent[len(idxcontent)-40:len(idxcontent)-20], trailer) {
+		return fmt.Errorf("index %s does not belong to pack %s", idxpath, packpath)
+	}
+
+	header := make([]byte, 12)
+	_, err = file.ReadAt(header, 0)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(header[:4], []byte("PACK")) {
+		return fmt.Errorf("pack %s has a bad signature", packpath)
+	}
+	if binary.BigEndian.Uint32(header[8:12]) != idx.Fanout[255] {
+		return fmt.Errorf("pack %s and its index disagree on the object count", packpath)
+	}
+	return nil
+}
+
+// PackCheck verifies every object of a pack on its own, so that damage to
+// one object does not hide the others. An object is corrupt when its bytes
+// do not match the crc its index records, or when it cannot be inflated or
+// hashes to another name. It returns why each corrupt object failed.
+func PackCheck(repository *repo.Repository, packpath string) (map[string]error, error) {
+	idx, err := packIndexRead(strings.TrimSuffix(packpath, ".pack")+".idx", packpath)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(packpath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	count := int(idx.Fanout[255])
+	order := make([]int, count)
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return idx.Offsets[order[i]] < idx.Offsets[order[j]]
+	})
+
+	store := packStoreGet(repository)
+	res := make(map[string]error)
+	for position, i := range order {
+		sha := hex.EncodeToString(idx.sha(i))
+		start := idx.Offsets[i]
+		end := uint64(info.Size() - 20)
+		if position+1 < count {
+			end = idx.Offsets[order[position+1]]
+		}
+		if start >= end || end > uint64(info.Size()-20) {
+			res[sha] = fmt.Errorf("invalid offset %d", start)
+			continue
+		}
+
+		raw := make([]byte, end-start)
+		_, err := file.ReadAt(raw, int64(start))
+		if err != nil {
+			res[sha] = err
+			continue
+		}
+		if crc32.ChecksumIEEE(raw) != binary.BigEndian.Uint32(idx.Crcs[i*4:i*4+4]) {
+			res[sha] = fmt.Errorf("crc mismatch at offset %d", start)
+			continue
+		}
+
+		format, data, err := packReadAt(repository, store, packpath, file, start, 0)
+		if err != nil {
+			res[sha] = fmt.Errorf("cannot unpack at offset %d: %v", start, err)
+			continue
+		}
+		header := format + " " + strconv.Itoa(len(data)) + "\x00"
+		sum := sha1.Sum(append([]byte(header), data...))
+		if actual := hex.EncodeToString(sum[:]); actual != sha {
+			res[sha] = fmt.Errorf("hashes to %s", actual)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,282 @@
+package obj
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/Jcho114/go-git/repo"
+)
+
+type packReceived struct {
+	Object  *packObject
+	Type    int
+	Base    uint64
+	BaseSha string
+}
+
+// PackReceive stores a pack sent by another repository, such as the one
+// upload-pack answers a fetch with, and builds its index. A thin pack, whose
+// deltas may be against objects that are only in this repository, is
+// completed by appending those bases so the stored pack stands on its own.
+// Nothing is stored for an empty pack.
+func PackReceive(repository *repo.Repository, reader io.Reader) (string, error) {
+	capture := &packCapture{Reader: bufio.NewReader(reader)}
+	header := make([]byte, 12)
+	_, err := io.ReadFull(capture, header)
+	if err != nil {
+		return "", fmt.Errorf("invalid pack header")
+	}
+	if string(header[:4]) != "PACK" {
+		return "", fmt.Errorf("invalid pack header")
+	}
+	version := binary.BigEndian.Uint32(header[4:8])
+	if version != 2 && version != 3 {
+		return "", fmt.Errorf("unsupported pack version %d", version)
+	}
+
+	count := binary.BigEndian.Uint32(header[8:12])
+	entries, err := packReceiveParse(capture, count)
+	if err != nil {
+		return "", err
+	}
+	body := capture.Data
+	checksum := sha1.Sum(body)
+	trailer := make([]byte, 20)
+	_, err = io.ReadFull(capture.Reader, trailer)
+	if err != nil {
+		return "", fmt.Errorf("truncated pack")
+	}
+	if !bytes.Equal(checksum[:], trailer) {
+		return "", fmt.Errorf("pack checksum mismatch")
+	}
+	if count == 0 {
+		return "", nil
+	}
+	content := append(body, trailer...)
+
+	bases, err := packReceiveResolve(repository, entries)
+	if err != nil {
+		return "", err
+	}
+
+	objects := []*packObject{}
+	for _, entry := range entries {
+		objects = append(objects, entry.Object)
+	}
+	if len(bases) > 0 {
+		pw := &packWriter{Writer: &bytes.Buffer{}, Hash: sha1.New(), Offset: uint64(len(body))}
+		written := make(map[*packObject]bool)
+		for _, base := range bases {
+			err := packEncodeOne(pw, base, written)
+			if err != nil {
+				return "", err
+			}
+		}
+		objects = append(objects, bases...)
+
+		completed := make([]byte, 0, len(body)+pw.Writer.(*bytes.Buffer).Len()+20)
+		completed = append(completed, body...)
+		completed = append(completed, pw.Writer.(*bytes.Buffer).Bytes()...)
+		binary.BigEndian.PutUint32(completed[8:12], uint32(len(objects)))
+		checksum = sha1.Sum(completed)
+		content = append(completed, checksum[:]...)
+	}
+
+	packdir := filepath.Join(repository.Gitdir, "objects", "pack")
+	err = os.MkdirAll(packdir, 0755)
+	if err != nil {
+		return "", err
+	}
+	tmppack, err := os.CreateTemp(packdir, "tmp_pack_")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmppack.Name())
+	_, err = tmppack.Write(content)
+	if err != nil {
+		tmppack.Close()
+		return "", err
+	}
+	err = tmppack.Close()
+	if err != nil {
+		return "", err
+	}
+	return packInstall(repository, tmppack.Name(), objects, checksum[:])
+}
+
+// packCapture keeps a copy of everything read from a pack, so the pack can
+// be stored once it is parsed. It reads no further than asked, which lets
+// the pack be followed by other data on the same stream.
+type packCapture struct {
+	Reader *bufio.Reader
+	Data   []byte
+}
+
+func (c *packCapture) Read(p []byte) (int, error) {
+	n, err := c.Reader.Read(p)
+	c.Data = append(c.Data, p[:n]...)
+	return n, err
+}
+
+func (c *packCapture) ReadByte() (byte, error) {
+	b, err := c.Reader.ReadByte()
+	if err == nil {
+		c.Data = append(c.Data, b)
+	}
+	return b, err
+}
+
+// packReceiveParse reads the header and compressed data of every object in
+// the pack, leaving deltas to be resolved once all of them are known.
+func packReceiveParse(capture *packCapture, count uint32) ([]*packReceived, error) {
+	entries := []*packReceived{}
+	for range count {
+		offset := uint64(len(capture.Data))
+		b, err := capture.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("truncated pack")
+		}
+		objtype := int(b>>4) & 0b111
+		size := uint64(b & 0b1111)
+		for shift := 4; b&0x80 != 0; shift += 7 {
+			b, err = capture.ReadByte()
+			if err != nil {
+				return nil, fmt.Errorf("truncated pack")
+			}
+			size |= uint64(b&0x7f) << shift
+		}
+
+		entry := &packReceived{Object: &packObject{Offset: offset}, Type: objtype}
+		switch objtype {
+		case packTypeOfsDelta:
+			b, err := capture.ReadByte()
+			if err != nil {
+				return nil, fmt.Errorf("truncated pack")
+			}
+			distance := uint64(b & 0x7f)
+			for b&0x80 != 0 {
+				b, err = capture.ReadByte()
+				if err != nil {
+					return nil, fmt.Errorf("truncated pack")
+				}
+				distance = ((distance + 1) << 7) | uint64(b&0x7f)
+			}
+			if distance == 0 || distance > offset {
+				return nil, fmt.Errorf("invalid delta base offset at %d", offset)
+			}
+			entry.Base = offset - distance
+		case packTypeRefDelta:
+			rawsha := make([]byte, 20)
+			_, err := io.ReadFull(capture, rawsha)
+			if err != nil {
+				return nil, fmt.Errorf("truncated pack")
+			}
+			entry.BaseSha = hex.EncodeToString(rawsha)
+		default:
+			if _, ok := packTypeNames[objtype]; !ok {
+				return nil, fmt.Errorf("unknown object type %d at %d", objtype, offset)
+			}
+			entry.Object.Format = packTypeNames[objtype]
+		}
+
+		zreader, err := zlib.NewReader(capture)
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(zreader)
+		if err != nil {
+			return nil, err
+		}
+		if uint64(len(data)) != size {
+			return nil, fmt.Errorf("object at %d has the wrong size", offset)
+		}
+		entry.Object.Crc = crc32.ChecksumIEEE(capture.Data[offset:])
+		if entry.Object.Format != "" {
+			entry.Object.Data = data
+		} else {
+			entry.Object.Delta = data
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// packReceiveResolve names every object, applying deltas once their bases
+// are known. Bases that are not in the pack are read from the repository and
+// returned so they can be appended to it.
+func packReceiveResolve(repository *repo.Repository, entries []*packReceived) ([]*packObject, error) {
+	byoffset := make(map[uint64]*packObject)
+	bysha := make(map[string]*packObject)
+	name := func(object *packObject) {
+		header := fmt.Sprintf("%s %d\x00", object.Format, len(object.Data))
+		sum := sha1.Sum(append([]byte(header), object.Data...))
+		object.Sha = hex.EncodeToString(sum[:])
+		bysha[object.Sha] = object
+	}
+	for _, entry := range entries {
+		byoffset[entry.Object.Offset] = entry.Object
+		if entry.Object.Format != "" {
+			name(entry.Object)
+		}
+	}
+
+	bases := []*packObject{}
+	pending := entries
+	for len(pending) > 0 {
+		remaining := []*packReceived{}
+		for _, entry := range pending {
+			if entry.Object.Format != "" {
+				continue
+			}
+			var base *packObject
+			if entry.Type == packTypeOfsDelta {
+				base = byoffset[entry.Base]
+			} else {
+				base = bysha[entry.BaseSha]
+			}
+			if base == nil || base.Format == "" {
+				remaining = append(remaining, entry)
+				continue
+			}
+			data, err := PackDeltaApply(base.Data, entry.Object.Delta)
+			if err != nil {
+				return nil, err
+			}
+			entry.Object.Format, entry.Object.Data, entry.Object.Delta = base.Format, data, nil
+			name(entry.Object)
+		}
+		if len(remaining) < len(pending) {
+			pending = remaining
+			continue
+		}
+
+		found := false
+		for _, entry := range remaining {
+			if entry.Type != packTypeRefDelta || bysha[entry.BaseSha] != nil {
+				continue
+			}
+			format, data, err := ObjectReadRaw(repository, entry.BaseSha)
+			if err != nil {
+				continue
+			}
+			base := &packObject{Sha: entry.BaseSha, Format: format, Data: data}
+			bysha[base.Sha] = base
+			bases = append(bases, base)
+			found = true
+		}
+		if !found {
+			return nil, fmt.Errorf("pack has %d unresolved deltas", len(remaining))
+		}
+		pending = remaining
+	}
+	return bases, nil
+}
@@ -0,0 +1,500 @@
+package obj
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Jcho114/go-git/repo"
+)
+
+const (
+	packDeltaWindow   = 10
+	packDeltaDepth    = 50
+	packDeltaBlock    = 16
+	packDeltaMaxCopy  = 0x10000
+	packDeltaMaxMatch = 64
+)
+
+var packTypeNumbers = map[string]int{
+	"commit": packTypeCommit,
+	"tree":   packTypeTree,
+	"blob":   packTypeBlob,
+	"tag":    packTypeTag,
+}
+
+type packObject struct {
+	Sha    string
+	Format string
+	Path   string
+	Data   []byte
+	Base   *packObject
+	Delta  []byte
+	Depth  int
+	Offset uint64
+	Crc    uint32
+	// External objects are only there to be delta bases for a thin pack,
+	// and are left out of it.
+	External bool
+}
+
+type packWriter struct {
+	Writer io.Writer
+	Hash   hash.Hash
+	Offset uint64
+}
+
+func (w *packWriter) Write(data []byte) (int, error) {
+	n, err := w.Writer.Write(data)
+	w.Hash.Write(data[:n])
+	w.Offset += uint64(n)
+	return n, err
+}
+
+// packEncode writes the given objects as a version 2 packfile, storing
+// similar blobs and trees as deltas against each other. Objects in bases
+// are not written, but may still serve as delta bases for the others. It
+// returns the pack checksum along with the offset and crc of every object
+// written so the caller can build an index.
+func packEncode(repository *repo.Repository, writer io.Writer, entries []WalkEntry, bases []WalkEntry) ([]byte, []*packObject, error) {
+	objects := []*packObject{}
+	for _, entry := range entries {
+		format, data, err := ObjectReadRaw(repository, entry.Sha)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to read object %s: %w", entry.Sha, err)
+		}
+		objects = append(objects, &packObject{Sha: entry.Sha, Format: format, Path: entry.Path, Data: data})
+	}
+	candidates := objects
+	for _, entry := range bases {
+		format, data, err := ObjectReadRaw(repository, entry.Sha)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to read object %s: %w", entry.Sha, err)
+		}
+		candidates = append(candidates, &packObject{Sha: entry.Sha, Format: format, Path: entry.Path, Data: data, External: true})
+	}
+
+	packDeltaSearch(candidates)
+
+	pw := &packWriter{Writer: writer, Hash: sha1.New()}
+	header := make([]byte, 12)
+	copy(header, "PACK")
+	binary.BigEndian.PutUint32(header[4:8], 2)
+	binary.BigEndian.PutUint32(header[8:12], uint32(len(objects)))
+	_, err := pw.Write(header)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	written := make(map[*packObject]bool)
+	for _, object := range objects {
+		err := packEncodeOne(pw, object, written)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	checksum := pw.Hash.Sum(nil)
+	_, err = writer.Write(checksum)
+	if err != nil {
+		return nil, nil, err
+	}
+	return checksum, objects, nil
+}
+
+// Offset deltas can only point backwards, so a base is always written before
+// anything that depends on it.
+func packEncodeOne(pw *packWriter, object *packObject, written map[*packObject]bool) error {
+	if written[object] {
+		return nil
+	}
+	if object.Base != nil && !object.Base.External {
+		err := packEncodeOne(pw, object.Base, written)
+		if err != nil {
+			return err
+		}
+	}
+	written[object] = true
+
+	var entry bytes.Buffer
+	objtype, data := packTypeNumbers[object.Format], object.Data
+	if object.Base != nil && object.Base.External {
+		objtype, data = packTypeRefDelta, object.Delta
+	} else if object.Base != nil {
+		objtype, data = packTypeOfsDelta, object.Delta
+	}
+
+	size := uint64(len(data))
+	b := byte(objtype<<4) | byte(size&0x0f)
+	size >>= 4
+	for size > 0 {
+		entry.WriteByte(b | 0x80)
+		b = byte(size & 0x7f)
+		size >>= 7
+	}
+	entry.WriteByte(b)
+
+	if objtype == packTypeRefDelta {
+		rawsha, err := hex.DecodeString(object.Base.Sha)
+		if err != nil {
+			return err
+		}
+		entry.Write(rawsha)
+	} else if object.Base != nil {
+		distance := pw.Offset - object.Base.Offset
+		encoded := []byte{byte(distance & 0x7f)}
+		for distance >>= 7; distance > 0; distance >>= 7 {
+			distance--
+			encoded = append([]byte{0x80 | byte(distance&0x7f)}, encoded...)
+		}
+		entry.Write(encoded)
+	}
+
+	zwriter := zlib.NewWriter(&entry)
+	_, err := zwriter.Write(data)
+	if err != nil {
+		return err
+	}
+	err = zwriter.Close()
+	if err != nil {
+		return err
+	}
+
+	object.Offset = pw.Offset
+	object.Crc = crc32.ChecksumIEEE(entry.Bytes())
+	_, err = pw.Write(entry.Bytes())
+	return err
+}
+
+// Objects are grouped by type and file name and sorted largest first, so that
+// each one is compared against a small window of likely bases. Only deltas
+// that save at least half of the object are kept.
+func packDeltaSearch(objects []*packObject) {
+	candidates := []*packObject{}
+	for _, object := range objects {
+		if object.Format == "blob" || object.Format == "tree" {
+			candidates = append(candidates, object)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Format != b.Format {
+			return a.Format < b.Format
+		}
+		if path.Base(a.Path) != path.Base(b.Path) {
+			return path.Base(a.Path) < path.Base(b.Path)
+		}
+		return len(a.Data) > len(b.Data)
+	})
+
+	for i, target := range candidates {
+		if target.External {
+			continue
+		}
+		maxsize := len(target.Data)/2 - 20
+		for j := max(0, i-packDeltaWindow); j < i && maxsize > 0; j++ {
+			base := candidates[j]
+			if base.Format != target.Format || base.Depth >= packDeltaDepth {
+				continue
+			}
+			delta := packDeltaCreate(base.Data, target.Data, maxsize)
+			if delta == nil {
+				continue
+			}
+			target.Base, target.Delta, target.Depth = base, delta, base.Depth+1
+			maxsize = len(delta) - 1
+		}
+	}
+}
+
+func packDeltaVarint(out []byte, size int) []byte {
+	for size >= 0x80 {
+		out = append(out, byte(size&0x7f)|0x80)
+		size >>= 7
+	}
+	return append(out, byte(size))
+}
+
+// packDeltaCreate builds a git delta turning base into target, or returns nil
+// if the delta would be larger than maxsize.
+func packDeltaCreate(base []byte, target []byte, maxsize int) []byte {
+	blocks := make(map[string][]int)
+	for i := 0; i+packDeltaBlock <= len(base); i += packDeltaBlock {
+		key := string(base[i : i+packDeltaBlock])
+		if len(blocks[key]) < packDeltaMaxMatch {
+			blocks[key] = append(blocks[key], i)
+		}
+	}
+
+	out := packDeltaVarint(nil, len(base))
+	out = packDeltaVarint(out, len(target))
+	insert := []byte{}
+
+	flush := func() {
+		for len(insert) > 0 {
+			n := min(len(insert), 0x7f)
+			out = append(out, byte(n))
+			out = append(out, insert[:n]...)
+			insert = insert[n:]
+		}
+	}
+
+	curr := 0
+	for curr < len(target) {
+		if len(out)+len(insert) > maxsize {
+			return nil
+		}
+
+		matchoffset, matchsize, matchback := 0, 0, 0
+		if curr+packDeltaBlock <= len(target) {
+			for _, offset := range blocks[string(target[curr:curr+packDeltaBlock])] {
+				size := packDeltaBlock
+				for offset+size < len(base) && curr+size < len(target) && base[offset+size] == target[curr+size] {
+					size++
+				}
+				back := 0
+				for back < len(insert) && offset-back > 0 && base[offset-back-1] == target[curr-back-1] {
+					back++
+				}
+				if size+back > matchsize+matchback {
+					matchoffset, matchsize, matchback = offset, size, back
+				}
+			}
+		}
+
+		if matchsize == 0 {
+			insert = append(insert, target[curr])
+			curr++
+			continue
+		}
+
+		insert = insert[:len(insert)-matchback]
+		flush()
+		copyoffset, copysize := matchoffset-matchback, matchsize+matchback
+		for copysize > 0 {
+			n := min(copysize, packDeltaMaxCopy)
+			op := byte(0x80)
+			args := []byte{}
+			for i := range 4 {
+				if b := byte(copyoffset >> (8 * i)); b != 0 {
+					op |= 1 << i
+					args = append(args, b)
+				}
+			}
+			if n != packDeltaMaxCopy {
+				for i := range 3 {
+					if b := byte(n >> (8 * i)); b != 0 {
+						op |= 1 << (4 + i)
+						args = append(args, b)
+					}
+				}
+			}
+			out = append(out, op)
+			out = append(out, args...)
+			copyoffset += n
+			copysize -= n
+		}
+		curr += matchsize
+	}
+	flush()
+
+	if len(out) > maxsize {
+		return nil
+	}
+	return out
+}
+
+func packIndexEncode(objects []*packObject, checksum []byte) ([]byte, error) {
+	sorted := make([]*packObject, len(objects))
+	copy(sorted, objects)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Sha < sorted[j].Sha
+	})
+
+	var buffer bytes.Buffer
+	buffer.Write([]byte{0xff, 't', 'O', 'c'})
+	binary.Write(&buffer, binary.BigEndian, uint32(2))
+
+	var fanout [256]uint32
+	rawshas := [][]byte{}
+	for _, object := range sorted {
+		rawsha, err := hex.DecodeString(object.Sha)
+		if err != nil || len(rawsha) != 20 {
+			return nil, fmt.Errorf("invalid object name %s", object.Sha)
+		}
+		rawshas = append(rawshas, rawsha)
+		fanout[rawsha[0]]++
+	}
+	for i := 1; i < 256; i++ {
+		fanout[i] += fanout[i-1]
+	}
+	binary.Write(&buffer, binary.BigEndian, fanout)
+
+	for _, rawsha := range rawshas {
+		buffer.Write(rawsha)
+	}
+	for _, object := range sorted {
+		binary.Write(&buffer, binary.BigEndian, object.Crc)
+	}
+	largeoffsets := []uint64{}
+	for _, object := range sorted {
+		if object.Offset < 0x80000000 {
+			binary.Write(&buffer, binary.BigEndian, uint32(object.Offset))
+			continue
+		}
+		binary.Write(&buffer, binary.BigEndian, uint32(0x80000000|len(largeoffsets)))
+		largeoffsets = append(largeoffsets, object.Offset)
+	}
+	for _, offset := range largeoffsets {
+		binary.Write(&buffer, binary.BigEndian, offset)
+	}
+
+	buffer.Write(checksum)
+	idxchecksum := sha1.Sum(buffer.Bytes())
+	buffer.Write(idxchecksum[:])
+	return buffer.Bytes(), nil
+}
+
+// PackWrite stores the given objects as a new pack and index under
+// objects/pack and returns the path of the pack.
+func PackWrite(repository *repo.Repository, entries []WalkEntry) (string, int, error) {
+	packdir := filepath.Join(repository.Gitdir, "objects", "pack")
+	err := os.MkdirAll(packdir, 0755)
+	if err != nil {
+		return "", 0, err
+	}
+
+	tmppack, err := os.CreateTemp(packdir, "tmp_pack_")
+	if err != nil {
+		return "", 0, err
+	}
+	defer os.Remove(tmppack.Name())
+
+	checksum, objects, err := packEncode(repository, tmppack, entries, nil)
+	if err != nil {
+		tmppack.Close()
+		return "", 0, err
+	}
+	err = tmppack.Close()
+	if err != nil {
+		return "", 0, err
+	}
+
+	name, err := packInstall(repository, tmppack.Name(), objects, checksum)
+	if err != nil {
+		return "", 0, err
+	}
+
+	deltas := 0
+	for _, object := range objects {
+		if object.Base != nil {
+			deltas++
+		}
+	}
+	return name, deltas, nil
+}
+
+// PackSend writes a pack of the given objects for another repository. The
+// receiver already has the objects in bases, so entries may be deltas
+// against them without the bases being sent, which makes a thin pack.
+func PackSend(repository *repo.Repository, writer io.Writer, entries []WalkEntry, bases []WalkEntry) error {
+	_, _, err := packEncode(repository, writer, entries, bases)
+	return err
+}
+
+// packInstall writes the index of a pack that was written under a temporary
+// name and moves both into place, the index last so readers never see a pack
+// without its index.
+func packInstall(repository *repo.Repository, tmppack string, objects []*packObject, checksum []byte) (string, error) {
+	packdir := filepath.Join(repository.Gitdir, "objects", "pack")
+	idx, err := packIndexEncode(objects, checksum)
+	if err != nil {
+		return "", err
+	}
+	tmpidx := filepath.Join(packdir, "tmp_idx_"+hex.EncodeToString(checksum))
+	err = os.WriteFile(tmpidx, idx, 0444)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmpidx)
+
+	name := filepath.Join(packdir, "pack-"+hex.EncodeToString(checksum))
+	err = os.Chmod(tmppack, 0444)
+	if err != nil {
+		return "", err
+	}
+	err = os.Rename(tmppack, name+".pack")
+	if err != nil {
+		return "", err
+	}
+	err = os.Rename(tmpidx, name+".idx")
+	if err != nil {
+		return "", err
+	}
+	return name + ".pack", nil
+}
+
+func PackList(repository *repo.Repository) ([]string, error) {
+	packdir := filepath.Join(repository.Gitdir, "objects", "pack")
+	entries, err := os.ReadDir(packdir)
+	if errors.Is(err, os.ErrNotExist) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := []string{}
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Name(), "pack-") || !strings.HasSuffix(entry.Name(), ".pack") {
+			continue
+		}
+		res = append(res, filepath.Join(packdir, entry.Name()))
+	}
+	return res, nil
+}
+
+func PackObjects(packpath string) ([]string, error) {
+	idx, err := packIndexRead(strings.TrimSuffix(packpath, ".pack")+".idx", packpath)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []string{}
+	for i := range int(idx.Fanout[255]) {
+		res = append(res, hex.EncodeToString(idx.sha(i)))
+	}
+	return res, nil
+}
+
+func PackRemove(repository *repo.Repository, packpath string) error {
+	store := packStoreGet(repository)
+	store.Mutex.Lock()
+	defer store.Mutex.Unlock()
+
+	idxpath := strings.TrimSuffix(packpath, ".pack") + ".idx"
+	delete(store.Indexes, idxpath)
+	delete(store.Cache, packpath)
+
+	err := os.Remove(idxpath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	err = os.Remove(packpath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package obj
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Jcho114/go-git/repo"
+)
+
+func TestPackSendThin(t *testing.T) {
+	repository := &repo.Repository{Gitdir: t.TempDir()}
+	var content strings.Builder
+	for i := range 200 {
+		fmt.Fprintf(&content, "line %d of a file big enough to be worth a delta\n", i)
+	}
+	// Bases are tried for objects no larger than them, as in git, so the
+	// target drops a line from the base.
+	base, err := ObjectWriteRaw(repository, "blob", []byte(content.String()+"one more line\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	target, err := ObjectWriteRaw(repository, "blob", []byte(content.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pack bytes.Buffer
+	err = PackSend(repository, &pack, []WalkEntry{{Sha: target, Format: "blob"}}, []WalkEntry{{Sha: base, Format: "blob"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := pack.Bytes()
+	if count := binary.BigEndian.Uint32(data[8:12]); count != 1 {
+		t.Fatalf("thin pack holds %d objects, want only the target", count)
+	}
+
+	curr := 12
+	objtype := int(data[curr]>>4) & 0b111
+	for data[curr]&0x80 != 0 {
+		curr++
+	}
+	curr++
+	if objtype != packTypeRefDelta {
+		t.Fatalf("target was written as type %d, want a ref delta", objtype)
+	}
+	if got := hex.EncodeToString(data[curr : curr+20]); got != base {
+		t.Errorf("delta is against %s, want the external base %s", got, base)
+	}
+
+	// Completing the pack needs the base, which only a receiver with it has.
+	empty := &repo.Repository{Gitdir: t.TempDir()}
+	_, err = PackReceive(empty, bytes.NewReader(data))
+	if err == nil {
+		t.Error("a receiver without the base accepted the thin pack")
+	}
+	receiver := &repo.Repository{Gitdir: t.TempDir()}
+	_, err = ObjectWriteRaw(receiver, "blob", []byte(content.String()+"one more line\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	packpath, err := PackReceive(receiver, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = PackVerify(packpath)
+	if err != nil {
+		t.Errorf("completed pack does not verify: %v", err)
+	}
+	shas, err := PackObjects(packpath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shas) != 2 {
+		t.Errorf("completed pack holds %d objects, want the target and its base", len(shas))
+	}
+}
@@ -0,0 +1,390 @@
+package obj
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Jcho114/go-git/index"
+	"github.com/Jcho114/go-git/ref"
+	"github.com/Jcho114/go-git/repo"
+)
+
+var (
+	revisionSuffix   = regexp.MustCompile(`^(?:~(\d*)|\^\{([^}]*)\}|\^(\d*))`)
+	revisionStage    = regexp.MustCompile(`^([0-3]):`)
+	revisionPrevious = regexp.MustCompile(`^-(\d+)$`)
+)
+
+// objectRevision resolves a name in the notation of gitrevisions: a name
+// followed by any number of ~N, ^N and ^{type} suffixes, <rev>:<path> for an
+// entry of a tree, :<path> and :<n>:<path> for an entry of the index, and
+// :/<regex> for the youngest reachable commit with a matching message.
+func objectRevision(repository *repo.Repository, name string) (string, error) {
+	if pattern, ok := strings.CutPrefix(name, ":/"); ok {
+		starts, err := revisionRefs(repository)
+		if err != nil {
+			return "", err
+		}
+		return revisionSearch(repository, name, starts, pattern)
+	}
+	if path, ok := strings.CutPrefix(name, ":"); ok {
+		return revisionIndex(repository, name, path)
+	}
+	if colon := revisionColon(name); colon > 0 {
+		sha, err := objectRevision(repository, name[:colon])
+		if err != nil {
+			return "", err
+		}
+		tree, err := objectPeel(repository, name, sha, "tree")
+		if err != nil {
+			return "", err
+		}
+		return revisionTreePath(repository, name, tree, name[colon+1:])
+	}
+
+	end := len(name)
+	depth := 0
+	for i, char := range name {
+		if char == '{' {
+			depth++
+		} else if char == '}' {
+			depth--
+		} else if depth == 0 && (char == '~' || char == '^') {
+			end = i
+			break
+		}
+	}
+
+	sha, err := revisionBase(repository, name, name[:end])
+	if err != nil {
+		return "", err
+	}
+	for rest := name[end:]; rest != ""; {
+		match := revisionSuffix.FindStringSubmatch(rest)
+		if match == nil {
+			return "", fmt.Errorf("unable to resolve %s as a valid reference", name)
+		}
+		rest = rest[len(match[0]):]
+
+		switch {
+		case strings.HasPrefix(match[0], "~"):
+			sha, err = revisionParent(repository, name, sha, 1, revisionCount(match[1]))
+		case strings.HasPrefix(match[0], "^{"):
+			sha, err = revisionPeel(repository, name, sha, match[2])
+		default:
+			sha, err = revisionParent(repository, name, sha, revisionCount(match[3]), 1)
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	return sha, nil
+}
+
+// revisionColon finds the colon separating a revision from a path, skipping
+// any inside braces such as those of ^{/message}.
+func revisionColon(name string) int {
+	depth := 0
+	for i, char := range name {
+		switch {
+		case char == '{':
+			depth++
+		case char == '}':
+			depth--
+		case char == ':' && depth == 0:
+			return i
+		}
+	}
+	return -1
+}
+
+func revisionCount(digits string) int {
+	if digits == "" {
+		return 1
+	}
+	count, _ := strconv.Atoi(digits)
+	return count
+}
+
+// revisionBase resolves the part of a revision before its suffixes: "@" for
+// HEAD, <branch>@{upstream} and @{-N} for the branch checked out N switches
+// ago, or a plain name.
+func revisionBase(repository *repo.Repository, name string, base string) (string, error) {
+	if base == "@" {
+		base = "HEAD"
+	}
+
+	if open := strings.LastIndex(base, "@{"); open >= 0 && strings.HasSuffix(base, "}") {
+		branch, selector := base[:open], base[open+2:len(base)-1]
+		if match := revisionPrevious.FindStringSubmatch(selector); match != nil && branch == "" {
+			n, _ := strconv.Atoi(match[1])
+			previous, err := ref.RefPrevious(repository, n)
+			if err != nil {
+				return "", err
+			}
+			return objectRevision(repository, previous)
+		}
+
+		switch strings.ToLower(selector) {
+		case "u", "upstream":
+			upstream, err := ObjectUpstream(repository, branch)
+			if err != nil {
+				return "", err
+			}
+			sha, err := objectRef(repository, upstream)
+			if err != nil {
+				return "", err
+			}
+			if sha == "" {
+				return "", fmt.Errorf("upstream branch '%s' not stored as a remote-tracking branch", upstream)
+			}
+			return sha, nil
+		}
+		return "", fmt.Errorf("unsupported revision selector '@{%s}' in %s", selector, name)
+	}
+
+	objnames, err := objectResolve(repository, base)
+	if err != nil {
+		return "", err
+	}
+	if len(objnames) == 0 {
+		return "", fmt.Errorf("unable to resolve %s as a valid reference", name)
+	}
+	if len(objnames) > 1 {
+		return "", fmt.Errorf("%s is an ambiguous reference", name)
+	}
+	return objnames[0], nil
+}
+
+// ObjectUpstream returns the remote-tracking ref a branch is configured to
+// track, the current branch when none is given.
+func ObjectUpstream(repository *repo.Repository, branch string) (string, error) {
+	if branch == "" {
+		current, err := ref.RefSymbolicRead(repository, "HEAD")
+		if err != nil {
+			return "", err
+		}
+		if current == "" {
+			return "", fmt.Errorf("HEAD does not point to a branch")
+		}
+		branch = current
+	}
+	branch = strings.TrimPrefix(branch, "refs/heads/")
+
+	config := repository.Config.Branches[branch]
+	if config == nil || config.Merge == "" {
+		return "", fmt.Errorf("no upstream configured for branch '%s'", branch)
+	}
+	if config.Remote == "" || config.Remote == "." {
+		return config.Merge, nil
+	}
+	return "refs/remotes/" + config.Remote + "/" + strings.TrimPrefix(config.Merge, "refs/heads/"), nil
+}
+
+// revisionParent follows the nth parent, then the first parent count times.
+func revisionParent(repository *repo.Repository, name string, sha string, nth int, count int) (string, error) {
+	for i := 0; i < count; i++ {
+		commit, err := objectPeel(repository, name, sha, "commit")
+		if err != nil {
+			return "", err
+		}
+		if nth == 0 {
+			sha = commit
+			continue
+		}
+
+		object, err := ObjectRead(repository, commit)
+		if err != nil {
+			return "", err
+		}
+		parents := object.(*Commit).Kvlm["parent"]
+		if nth > len(parents) {
+			return "", fmt.Errorf("unable to resolve %s as a valid reference", name)
+		}
+		sha = parents[nth-1]
+		nth = 1
+	}
+	return sha, nil
+}
+
+// revisionPeel handles ^{type}, ^{} for peeling tags and ^{/regex} for the
+// youngest commit reachable from sha whose message matches.
+func revisionPeel(repository *repo.Repository, name string, sha string, spec string) (string, error) {
+	if pattern, ok := strings.CutPrefix(spec, "/"); ok {
+		commit, err := objectPeel(repository, name, sha, "commit")
+		if err != nil {
+			return "", err
+		}
+		return revisionSearch(repository, name, []string{commit}, pattern)
+	}
+
+	switch spec {
+	case "":
+		for {
+			object, err := ObjectRead(repository, sha)
+			if err != nil {
+				return "", err
+			}
+			tag, ok := object.(*Tag)
+			if !ok || len(tag.Kvlm["object"]) == 0 {
+				return sha, nil
+			}
+			sha = tag.Kvlm["object"][0]
+		}
+	case "object":
+		_, _, err := ObjectReadRaw(repository, sha)
+		return sha, err
+	case "commit", "tree", "blob", "tag":
+		return objectPeel(repository, name, sha, spec)
+	}
+	return "", fmt.Errorf("invalid object type '%s' in %s", spec, name)
+}
+
+// revisionSearch walks back from the given commits, newest first, and returns
+// the first commit whose message matches the pattern.
+func revisionSearch(repository *repo.Repository, name string, starts []string, pattern string) (string, error) {
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid pattern in %s: %w", name, err)
+	}
+
+	type candidate struct {
+		Sha    string
+		Date   int64
+		Commit *Commit
+	}
+	seen := make(map[string]bool)
+	queue := []candidate{}
+	push := func(sha string) error {
+		if seen[sha] {
+			return nil
+		}
+		seen[sha] = true
+		object, err := ObjectRead(repository, sha)
+		if err != nil {
+			return err
+		}
+		commit, ok := object.(*Commit)
+		if !ok {
+			return fmt.Errorf("object %s is not a commit", sha)
+		}
+		date := int64(0)
+		if committer := commit.Kvlm["committer"]; len(committer) > 0 {
+			fields := strings.Fields(committer[0][strings.LastIndex(committer[0], ">")+1:])
+			if len(fields) > 0 {
+				date, _ = strconv.ParseInt(fields[0], 10, 64)
+			}
+		}
+		queue = append(queue, candidate{Sha: sha, Date: date, Commit: commit})
+		sort.SliceStable(queue, func(i, j int) bool { return queue[i].Date > queue[j].Date })
+		return nil
+	}
+
+	for _, sha := range starts {
+		err := push(sha)
+		if err != nil {
+			return "", err
+		}
+	}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		if message := next.Commit.Kvlm[""]; len(message) > 0 && compiled.MatchString(message[0]) {
+			return next.Sha, nil
+		}
+		for _, parent := range next.Commit.Kvlm["parent"] {
+			err := push(parent)
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+	return "", fmt.Errorf("unable to resolve %s as a valid reference", name)
+}
+
+// revisionRefs returns the commits HEAD and every ref point to.
+func revisionRefs(repository *repo.Repository) ([]string, error) {
+	refmap, err := ref.RefList(repository, "")
+	if err != nil {
+		return nil, err
+	}
+	names := []string{"HEAD"}
+	refs := ref.RefFlatten(refmap, "refs")
+	for name := range refs {
+		names = append(names, name)
+	}
+	sort.Strings(names[1:])
+
+	res := []string{}
+	for _, name := range names {
+		sha := refs[name]
+		if name == "HEAD" {
+			sha, err = objectRef(repository, "HEAD")
+			if err != nil || sha == "" {
+				continue
+			}
+		}
+		commit, err := objectPeel(repository, name, sha, "commit")
+		if err == nil {
+			res = append(res, commit)
+		}
+	}
+	return res, nil
+}
+
+// revisionIndex looks a path up in the index, at stage 0 unless the path is
+// prefixed with another stage as in :2:path.
+func revisionIndex(repository *repo.Repository, name string, path string) (string, error) {
+	stage := 0
+	if match := revisionStage.FindStringSubmatch(path); match != nil {
+		stage, _ = strconv.Atoi(match[1])
+		path = path[len(match[0]):]
+	}
+
+	ind, err := index.IndexRead(repository)
+	if err != nil {
+		return "", err
+	}
+	for _, entry := range ind.Entries {
+		if entry.Name == path && entry.Flagstage == stage {
+			return entry.Sha, nil
+		}
+	}
+	if stage == 0 {
+		return "", fmt.Errorf("path '%s' does not exist in the index", path)
+	}
+	return "", fmt.Errorf("path '%s' is in the index, but not at stage %d", path, stage)
+}
+
+// revisionTreePath looks a path up in a tree. An empty path names the tree
+// itself.
+func revisionTreePath(repository *repo.Repository, name string, tree string, path string) (string, error) {
+	sha := tree
+	for _, component := range strings.Split(strings.Trim(path, "/"), "/") {
+		if component == "" || component == "." {
+			continue
+		}
+		object, err := ObjectRead(repository, sha)
+		if err != nil {
+			return "", err
+		}
+		current, ok := object.(*Tree)
+		if !ok {
+			return "", fmt.Errorf("path '%s' does not exist in '%s'", path, name[:len(name)-len(path)-1])
+		}
+		found := ""
+		for _, item := range current.Items {
+			if item.Path == component {
+				found = item.Sha
+			}
+		}
+		if found == "" {
+			return "", fmt.Errorf("path '%s' does not exist in '%s'", path, name[:len(name)-len(path)-1])
+		}
+		sha = found
+	}
+	return sha, nil
+}
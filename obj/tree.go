@@ -2,31 +2,44 @@ package obj
 
 import (
 	"encoding/hex"
+	"fmt"
 	"sort"
 	"strings"
 
 	"github.com/Jcho114/go-git/repo"
 )
 
-type treeLeaf struct {
+type TreeLeaf struct {
 	Mode string
 	Path string
 	Sha  string
 }
 
-func (l *treeLeaf) Key() string {
-	if strings.HasPrefix(l.Mode, "10") {
+func NewTreeLeaf(mode string, path string, sha string) *TreeLeaf {
+	if len(mode) == 5 {
+		mode = "0" + mode
+	}
+	return &TreeLeaf{
+		Mode: mode,
+		Path: path,
+		Sha:  sha,
+	}
+}
+
+func (l *TreeLeaf) IsTree() bool {
+	return strings.TrimLeft(l.Mode, "0") == "40000"
+}
+
+func (l *TreeLeaf) Key() string {
+	if !l.IsTree() {
 		return l.Path
 	}
 	return l.Path + "/"
 }
 
-func parseTreeOne(content string, start int) (int, *treeLeaf) {
+func parseTreeOne(content string, start int) (int, *TreeLeaf) {
 	spaceindex := strings.Index(content[start:], " ") + start
 	mode := content[start:spaceindex]
-	if len(mode) == 5 {
-		mode = "0" + mode
-	}
 
 	nullindex := strings.Index(content[spaceindex:], "\x00") + spaceindex
 	path := content[spaceindex+1 : nullindex]
@@ -34,20 +47,16 @@ func parseTreeOne(content string, start int) (int, *treeLeaf) {
 	binarysha := content[nullindex+1 : nullindex+21]
 	sha := hex.EncodeToString([]byte(binarysha))
 
-	leaf := &treeLeaf{
-		Mode: mode,
-		Path: path,
-		Sha:  sha,
-	}
+	leaf := NewTreeLeaf(mode, path, sha)
 	return nullindex + 21, leaf
 }
 
-func parseTree(content string) []*treeLeaf {
+func parseTree(content string) []*TreeLeaf {
 	curr := 0
-	res := []*treeLeaf{}
+	res := []*TreeLeaf{}
 
 	for curr < len(content) {
-		var leaf *treeLeaf
+		var leaf *TreeLeaf
 		curr, leaf = parseTreeOne(content, curr)
 		res = append(res, leaf)
 	}
@@ -56,7 +65,7 @@ func parseTree(content string) []*treeLeaf {
 }
 
 type Tree struct {
-	Items []*treeLeaf
+	Items []*TreeLeaf
 }
 
 func NewTree(buffer []byte) *Tree {
@@ -74,7 +83,8 @@ func (t *Tree) Serialize(repository *repo.Repository) string {
 
 	res := ""
 	for _, item := range t.Items {
-		res += item.Mode + " " + item.Path + "\x00" + item.Sha
+		binarysha, _ := hex.DecodeString(item.Sha)
+		res += strings.TrimPrefix(item.Mode, "0") + " " + item.Path + "\x00" + string(binarysha)
 	}
 	return res
 }
@@ -86,3 +96,37 @@ func (t *Tree) Deserialize(content string) {
 func (t *Tree) Type() string {
 	return "tree"
 }
+
+func TreeFlatten(repository *repo.Repository, sha string, prefix string) (map[string]*TreeLeaf, error) {
+	object, err := ObjectRead(repository, sha)
+	if err != nil {
+		return nil, err
+	}
+	tree, ok := object.(*Tree)
+	if !ok {
+		return nil, fmt.Errorf("object %s is not a tree", sha)
+	}
+
+	res := make(map[string]*TreeLeaf)
+	for _, item := range tree.Items {
+		fullpath := item.Path
+		if prefix != "" {
+			fullpath = prefix + "/" + item.Path
+		}
+
+		if item.IsTree() {
+			subtree, err := TreeFlatten(repository, item.Sha, fullpath)
+			if err != nil {
+				return nil, err
+			}
+			for key, value := range subtree {
+				res[key] = value
+			}
+			continue
+		}
+
+		res[fullpath] = NewTreeLeaf(item.Mode, fullpath, item.Sha)
+	}
+
+	return res, nil
+}
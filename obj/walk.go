@@ -0,0 +1,90 @@
+package obj
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/Jcho114/go-git/repo"
+)
+
+type WalkEntry struct {
+	Sha    string
+	Format string
+	Path   string
+}
+
+// ObjectWalk lists every object reachable from roots, skipping anything in
+// exclude. Gitlinks point into other repositories and are never followed.
+func ObjectWalk(repository *repo.Repository, roots []string, exclude map[string]bool) ([]WalkEntry, error) {
+	res := []WalkEntry{}
+	seen := make(map[string]bool)
+	queue := []WalkEntry{}
+	for _, root := range roots {
+		queue = append(queue, WalkEntry{Sha: root})
+	}
+
+	for len(queue) > 0 {
+		entry := queue[0]
+		queue = queue[1:]
+		if seen[entry.Sha] || exclude[entry.Sha] {
+			continue
+		}
+		seen[entry.Sha] = true
+
+		if entry.Format == "blob" {
+			res = append(res, entry)
+			continue
+		}
+
+		format, data, err := ObjectReadRaw(repository, entry.Sha)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read object %s: %w", entry.Sha, err)
+		}
+		if entry.Format != "" && entry.Format != format {
+			return nil, fmt.Errorf("object %s is a %s, not a %s", entry.Sha, format, entry.Format)
+		}
+		entry.Format = format
+		res = append(res, entry)
+
+		links, err := ObjectLinks(format, data)
+		if err != nil {
+			return nil, fmt.Errorf("malformed %s %s: %w", format, entry.Sha, err)
+		}
+		for _, link := range links {
+			if format == "tree" {
+				link.Path = path.Join(entry.Path, link.Path)
+			}
+			queue = append(queue, link)
+		}
+	}
+
+	return res, nil
+}
+
+func ObjectLooseList(repository *repo.Repository) ([]string, error) {
+	objectsdir := filepath.Join(repository.Gitdir, "objects")
+	dirs, err := os.ReadDir(objectsdir)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []string{}
+	for _, dir := range dirs {
+		if !dir.IsDir() || len(dir.Name()) != 2 || !hashRegex.MatchString(dir.Name()+"00") {
+			continue
+		}
+		files, err := os.ReadDir(filepath.Join(objectsdir, dir.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			sha := dir.Name() + file.Name()
+			if len(sha) == 40 && hashRegex.MatchString(sha) {
+				res = append(res, sha)
+			}
+		}
+	}
+	return res, nil
+}
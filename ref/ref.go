@@ -1,6 +1,7 @@
 package ref
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -12,25 +13,37 @@ import (
 
 func RefResolve(repository *repo.Repository, ref string) (string, error) {
 	path := ref
-	if !strings.HasPrefix(ref, ".git") {
+	if !filepath.IsAbs(ref) && !strings.HasPrefix(ref, ".git") {
 		path = filepath.Join(repository.Gitdir, ref)
 	}
 
 	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		name, relerr := refName(repository, path)
+		if relerr != nil {
+			return "", err
+		}
+		packed, packederr := refPackedRead(repository)
+		if packederr != nil {
+			return "", packederr
+		}
+		if sha, ok := packed[name]; ok {
+			return sha, nil
+		}
+	}
 	if err != nil {
 		return "", err
 	}
 
 	if !info.Mode().IsRegular() {
-		return "", err
+		return "", fmt.Errorf("ref %s is not a file", ref)
 	}
 
 	bytescontent, err := os.ReadFile(path)
 	if err != nil {
-		return "", nil
+		return "", err
 	}
-	content := string(bytescontent)
-	content = content[:len(content)-1]
+	content := strings.TrimSpace(string(bytescontent))
 
 	if strings.HasPrefix(content, "ref: ") {
 		content, err := RefResolve(repository, content[5:])
@@ -43,6 +56,222 @@ func RefResolve(repository *repo.Repository, ref string) (string, error) {
 	return content, nil
 }
 
+func refName(repository *repo.Repository, path string) (string, error) {
+	gitdir, err := filepath.Abs(repository.Gitdir)
+	if err != nil {
+		return "", err
+	}
+	abspath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	name, err := filepath.Rel(gitdir, abspath)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(name), nil
+}
+
+// Repositories that have been through gc keep most of their refs in a single
+// packed-refs file, with loose files only for refs updated since.
+func refPackedRead(repository *repo.Repository) (map[string]string, error) {
+	res := make(map[string]string)
+	content, err := os.ReadFile(filepath.Join(repository.Gitdir, "packed-refs"))
+	if errors.Is(err, os.ErrNotExist) {
+		return res, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' || line[0] == '^' {
+			continue
+		}
+		fields := strings.SplitN(line, " ", 2)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("malformed packed-refs line: %s", line)
+		}
+		res[fields[1]] = fields[0]
+	}
+	return res, nil
+}
+
+func refPackedDelete(repository *repo.Repository, name string) (bool, error) {
+	path := filepath.Join(repository.Gitdir, "packed-refs")
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	lines := strings.Split(string(content), "\n")
+	res := []string{}
+	found := false
+	skippeeled := false
+	for _, line := range lines {
+		if skippeeled && strings.HasPrefix(line, "^") {
+			continue
+		}
+		skippeeled = false
+		if strings.HasSuffix(line, " "+name) && !strings.HasPrefix(line, "#") {
+			found = true
+			skippeeled = true
+			continue
+		}
+		res = append(res, line)
+	}
+	if !found {
+		return false, nil
+	}
+	return true, os.WriteFile(path, []byte(strings.Join(res, "\n")), 0644)
+}
+
+func RefSymbolicRead(repository *repo.Repository, ref string) (string, error) {
+	content, err := os.ReadFile(filepath.Join(repository.Gitdir, ref))
+	if err != nil {
+		return "", err
+	}
+
+	value := strings.TrimSpace(string(content))
+	if !strings.HasPrefix(value, "ref: ") {
+		return "", nil
+	}
+	return value[5:], nil
+}
+
+func RefSymbolicWrite(repository *repo.Repository, ref string, target string) error {
+	path := filepath.Join(repository.Gitdir, ref)
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte("ref: "+target+"\n"), 0644)
+}
+
+func RefWrite(repository *repo.Repository, ref string, sha string) error {
+	path := filepath.Join(repository.Gitdir, ref)
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(sha+"\n"), 0644)
+}
+
+func RefUpdate(repository *repo.Repository, ref string, sha string) error {
+	target, err := RefSymbolicRead(repository, ref)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if target != "" {
+		return RefUpdate(repository, target, sha)
+	}
+	return RefWrite(repository, ref, sha)
+}
+
+func RefDelete(repository *repo.Repository, ref string) error {
+	packed, err := refPackedDelete(repository, ref)
+	if err != nil {
+		return err
+	}
+
+	path := filepath.Join(repository.Gitdir, ref)
+	err = os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) && packed {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	refsdir := filepath.Join(repository.Gitdir, "refs")
+	for dirname := filepath.Dir(path); strings.HasPrefix(dirname, refsdir+string(filepath.Separator)); dirname = filepath.Dir(dirname) {
+		entries, err := os.ReadDir(dirname)
+		if err != nil || len(entries) > 0 {
+			break
+		}
+		if err := os.Remove(dirname); err != nil {
+			break
+		}
+	}
+	return nil
+}
+
+// RefLogAppend records an update of a ref in its reflog, in git's format so
+// that either tool can read the other's logs.
+func RefLogAppend(repository *repo.Repository, ref string, old string, new string, ident string, message string) error {
+	zero := strings.Repeat("0", 40)
+	if old == "" {
+		old = zero
+	}
+	if new == "" {
+		new = zero
+	}
+
+	path := filepath.Join(repository.Gitdir, "logs", ref)
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = fmt.Fprintf(file, "%s %s %s\t%s\n", old, new, ident, message)
+	return err
+}
+
+// RefPrevious returns the branch or commit that was checked out before the
+// nth most recent checkout, as recorded in the reflog of HEAD.
+func RefPrevious(repository *repo.Repository, n int) (string, error) {
+	content, err := os.ReadFile(filepath.Join(repository.Gitdir, "logs", "HEAD"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		_, message, _ := strings.Cut(lines[i], "\t")
+		moves, ok := strings.CutPrefix(message, "checkout: moving from ")
+		if !ok {
+			continue
+		}
+		n--
+		if n == 0 {
+			from, _, _ := strings.Cut(moves, " to ")
+			return from, nil
+		}
+	}
+	return "", fmt.Errorf("no previous checkout found")
+}
+
+func RefCheckFormat(name string) bool {
+	if name == "" || name == "@" {
+		return false
+	}
+	if strings.HasPrefix(name, "-") || strings.HasPrefix(name, "/") || strings.HasSuffix(name, "/") {
+		return false
+	}
+	if strings.HasSuffix(name, ".") || strings.Contains(name, "..") || strings.Contains(name, "@{") || strings.Contains(name, "//") {
+		return false
+	}
+	for _, component := range strings.Split(name, "/") {
+		if strings.HasPrefix(component, ".") || strings.HasSuffix(component, ".lock") {
+			return false
+		}
+	}
+	for _, char := range name {
+		if char < 0x20 || char == 0x7f || strings.ContainsRune(" ~^:?*[\\", char) {
+			return false
+		}
+	}
+	return true
+}
+
 type RefMap = map[string]interface{}
 
 func RefList(repository *repo.Repository, path string) (RefMap, error) {
@@ -50,6 +279,53 @@ func RefList(repository *repo.Repository, path string) (RefMap, error) {
 		path = filepath.Join(repository.Gitdir, "refs")
 	}
 
+	res, err := refListLoose(repository, path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	looseerr := err
+	if res == nil {
+		res = make(RefMap)
+	}
+
+	prefix, err := refName(repository, path)
+	if err != nil {
+		return nil, err
+	}
+	packed, err := refPackedRead(repository)
+	if err != nil {
+		return nil, err
+	}
+
+	found := false
+	for name, sha := range packed {
+		if !strings.HasPrefix(name, prefix+"/") {
+			continue
+		}
+		found = true
+
+		parts := strings.Split(strings.TrimPrefix(name, prefix+"/"), "/")
+		current := res
+		for _, part := range parts[:len(parts)-1] {
+			next, ok := current[part].(RefMap)
+			if !ok {
+				next = make(RefMap)
+				current[part] = next
+			}
+			current = next
+		}
+		if _, ok := current[parts[len(parts)-1]]; !ok {
+			current[parts[len(parts)-1]] = sha
+		}
+	}
+
+	if looseerr != nil && !found {
+		return nil, looseerr
+	}
+	return res, nil
+}
+
+func refListLoose(repository *repo.Repository, path string) (RefMap, error) {
 	res := make(RefMap)
 
 	entries, err := os.ReadDir(path)
@@ -71,7 +347,7 @@ func RefList(repository *repo.Repository, path string) (RefMap, error) {
 		}
 
 		if info.Mode().IsDir() {
-			refmap, err := RefList(repository, nextpath)
+			refmap, err := refListLoose(repository, nextpath)
 			if err != nil {
 				return nil, err
 			}
@@ -92,8 +368,15 @@ func RefShow(refmap RefMap, prefix string, showhash bool) error {
 	if prefix != "" {
 		prefix += "/"
 	}
-	for key, value := range refmap {
-		switch value := value.(type) {
+
+	keys := []string{}
+	for key := range refmap {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		switch value := refmap[key].(type) {
 		case RefMap:
 			err := RefShow(value, prefix+key, showhash)
 			if err != nil {
@@ -111,3 +394,22 @@ func RefShow(refmap RefMap, prefix string, showhash bool) error {
 	}
 	return nil
 }
+
+func RefFlatten(refmap RefMap, prefix string) map[string]string {
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	res := make(map[string]string)
+	for key, value := range refmap {
+		switch value := value.(type) {
+		case RefMap:
+			for name, sha := range RefFlatten(value, prefix+key) {
+				res[name] = sha
+			}
+		case string:
+			res[prefix+key] = value
+		}
+	}
+	return res
+}
@@ -0,0 +1,47 @@
+package ref
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Refspec maps refs on one side of a fetch or push to refs on the other, as
+// in "+refs/heads/*:refs/remotes/origin/*". Either side may hold a single "*"
+// which matches the same text on both sides.
+type Refspec struct {
+	Force bool
+	Src   string
+	Dst   string
+}
+
+func RefspecParse(spec string) (*Refspec, error) {
+	res := &Refspec{}
+	spec, res.Force = strings.CutPrefix(spec, "+")
+	res.Src, res.Dst, _ = strings.Cut(spec, ":")
+	if strings.Count(res.Src, "*") > 1 || strings.Count(res.Dst, "*") > 1 {
+		return nil, fmt.Errorf("invalid refspec '%s'", spec)
+	}
+	if res.Dst != "" && strings.Contains(res.Src, "*") != strings.Contains(res.Dst, "*") {
+		return nil, fmt.Errorf("invalid refspec '%s'", spec)
+	}
+	return res, nil
+}
+
+// Match returns the destination a ref on the source side maps to.
+func (r *Refspec) Match(name string) (string, bool) {
+	before, after, glob := strings.Cut(r.Src, "*")
+	if !glob {
+		return r.Dst, name == r.Src
+	}
+	if len(name) < len(before)+len(after) || !strings.HasPrefix(name, before) || !strings.HasSuffix(name, after) {
+		return "", false
+	}
+	return strings.Replace(r.Dst, "*", name[len(before):len(name)-len(after)], 1), true
+}
+
+// Prefix returns the part of the source before any "*", which is what a
+// remote is asked to list refs under.
+func (r *Refspec) Prefix() string {
+	before, _, _ := strings.Cut(r.Src, "*")
+	return before
+}
@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"gopkg.in/ini.v1"
 )
@@ -15,6 +17,26 @@ type Config struct {
 		FileMode      bool `ini:"filemode"`
 		Bare          bool `ini:"bare"`
 	} `ini:"core"`
+	User struct {
+		Name  string `ini:"name,omitempty"`
+		Email string `ini:"email,omitempty"`
+	} `ini:"user,omitempty"`
+	// Branches holds the [branch "name"] sections, which name the upstream
+	// each branch tracks.
+	Branches map[string]*BranchConfig `ini:"-"`
+	// Remotes holds the [remote "name"] sections, which name where each
+	// remote lives and which of its refs are fetched into which local ones.
+	Remotes map[string]*RemoteConfig `ini:"-"`
+}
+
+type BranchConfig struct {
+	Remote string
+	Merge  string
+}
+
+type RemoteConfig struct {
+	URL   string
+	Fetch []string
 }
 
 func defaultConfig() *Config {
@@ -22,26 +44,128 @@ func defaultConfig() *Config {
 	config.Core.FormatVersion = 0
 	config.Core.FileMode = false
 	config.Core.Bare = false
+	config.Branches = make(map[string]*BranchConfig)
+	config.Remotes = make(map[string]*RemoteConfig)
 
 	return config
 }
 
 func parseConfig(filepath string) (*Config, error) {
-	var cfg Config
-	err := ini.MapTo(&cfg, filepath)
+	file, err := ini.LoadSources(ini.LoadOptions{AllowShadows: true}, filepath)
 	if err != nil {
 		return nil, err
 	}
-	return &cfg, nil
+	cfg := defaultConfig()
+	err = file.MapTo(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, section := range file.Sections() {
+		if name, ok := configSubsection(section.Name(), "branch"); ok {
+			cfg.Branches[name] = &BranchConfig{
+				Remote: section.Key("remote").String(),
+				Merge:  section.Key("merge").String(),
+			}
+		}
+		if name, ok := configSubsection(section.Name(), "remote"); ok {
+			remote := &RemoteConfig{URL: section.Key("url").String(), Fetch: []string{}}
+			if section.HasKey("fetch") {
+				remote.Fetch = section.Key("fetch").ValueWithShadows()
+			}
+			cfg.Remotes[name] = remote
+		}
+	}
+	return cfg, nil
+}
+
+// configSubsection returns the name of a subsection such as [branch "main"]
+// when the section belongs to the given kind.
+func configSubsection(section string, kind string) (string, bool) {
+	name, ok := strings.CutPrefix(section, kind+" ")
+	if !ok || len(name) < 2 || name[0] != '"' || name[len(name)-1] != '"' {
+		return "", false
+	}
+	return name[1 : len(name)-1], true
+}
+
+func GlobalConfig() (*Config, error) {
+	paths := []string{}
+	if val := os.Getenv("XDG_CONFIG_HOME"); val != "" {
+		paths = append(paths, filepath.Join(val, "git", "config"))
+	} else if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, ".config", "git", "config"))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, ".gitconfig"))
+	}
+
+	cfg := defaultConfig()
+	for _, path := range paths {
+		_, err := os.Stat(path)
+		pathexists := !errors.Is(err, os.ErrNotExist)
+		if !pathexists {
+			continue
+		}
+		err = ini.MapTo(cfg, path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
 }
 
 func (c *Config) Write(filepath string) error {
-	inicfg := ini.Empty()
+	inicfg := ini.Empty(ini.LoadOptions{AllowShadows: true})
 
 	err := ini.ReflectFrom(inicfg, c)
 	if err != nil {
 		return err
 	}
+	for _, section := range inicfg.Sections() {
+		if section.Name() != ini.DefaultSection && len(section.Keys()) == 0 {
+			inicfg.DeleteSection(section.Name())
+		}
+	}
+
+	names := []string{}
+	for name := range c.Branches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		section, err := inicfg.NewSection(fmt.Sprintf("branch \"%s\"", name))
+		if err != nil {
+			return err
+		}
+		branch := c.Branches[name]
+		if branch.Remote != "" {
+			section.Key("remote").SetValue(branch.Remote)
+		}
+		if branch.Merge != "" {
+			section.Key("merge").SetValue(branch.Merge)
+		}
+	}
+
+	names = []string{}
+	for name := range c.Remotes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		section, err := inicfg.NewSection(fmt.Sprintf("remote \"%s\"", name))
+		if err != nil {
+			return err
+		}
+		remote := c.Remotes[name]
+		section.Key("url").SetValue(remote.URL)
+		for _, fetch := range remote.Fetch {
+			_, err := section.NewKey("fetch", fetch)
+			if err != nil {
+				return err
+			}
+		}
+	}
 
 	err = inicfg.SaveTo(filepath)
 	if err != nil {
@@ -104,20 +228,16 @@ func FindRepository(path string, required bool) (*Repository, error) {
 
 	gitpath := filepath.Join(abspath, ".git")
 	info, err := os.Stat(gitpath)
-	if err != nil {
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
 		return nil, err
 	}
 
-	if info.Mode().IsDir() {
+	if err == nil && info.Mode().IsDir() {
 		return NewRepository(path, false)
 	}
 
-	parent, err := filepath.Abs(filepath.Join(path, ".."))
-	if err != nil {
-		return nil, err
-	}
-
-	if parent == path {
+	parent := filepath.Dir(abspath)
+	if parent == abspath {
 		var err error
 		if required {
 			err = fmt.Errorf("no git directory")
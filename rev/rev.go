@@ -0,0 +1,172 @@
+package rev
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Jcho114/go-git/graph"
+	"github.com/Jcho114/go-git/obj"
+	"github.com/Jcho114/go-git/repo"
+)
+
+// Revisions is the set of commits a history walk starts from and the commits
+// whose history it leaves out, as named on a command line.
+type Revisions struct {
+	Include []string
+	Exclude []string
+	// Left holds the commits named on the left of a symmetric difference,
+	// so the walked commits can be told apart by the side they came from.
+	Left []string
+}
+
+func NewRevisions() *Revisions {
+	return &Revisions{
+		Include: []string{},
+		Exclude: []string{},
+		Left:    []string{},
+	}
+}
+
+// RevisionParse resolves revisions in git's notation: plain names, "^A" for
+// the history to leave out, "A..B" and "A...B" ranges, where a missing side
+// means HEAD, and "--not", which flips the meaning of every revision after it.
+func RevisionParse(repository *repo.Repository, args []string) (*Revisions, error) {
+	revisions := NewRevisions()
+	not := false
+	for _, arg := range args {
+		if arg == "--not" {
+			not = !not
+			continue
+		}
+		err := revisions.Add(repository, arg, not)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return revisions, nil
+}
+
+// Add resolves a single revision into the set, negated when not is set.
+func (r *Revisions) Add(repository *repo.Repository, arg string, not bool) error {
+	left, operator, right := revisionRange(arg)
+	if operator == "..." {
+		return r.addSymmetric(repository, arg, left, right, not)
+	}
+	if operator == ".." {
+		leftsha, err := revisionResolve(repository, arg, left)
+		if err != nil {
+			return err
+		}
+		rightsha, err := revisionResolve(repository, arg, right)
+		if err != nil {
+			return err
+		}
+		r.add(leftsha, !not)
+		r.add(rightsha, not)
+		return nil
+	}
+
+	name, negated := strings.CutPrefix(arg, "^")
+	sha, err := revisionResolve(repository, arg, name)
+	if err != nil {
+		return err
+	}
+	r.add(sha, negated != not)
+	return nil
+}
+
+// addSymmetric adds the commits reachable from either side of "A...B" but
+// not from both, by leaving out the history of their merge bases.
+func (r *Revisions) addSymmetric(repository *repo.Repository, arg string, left string, right string, not bool) error {
+	leftsha, err := revisionResolve(repository, arg, left)
+	if err != nil {
+		return err
+	}
+	rightsha, err := revisionResolve(repository, arg, right)
+	if err != nil {
+		return err
+	}
+	r.add(leftsha, not)
+	r.add(rightsha, not)
+	if not {
+		return nil
+	}
+
+	bases, err := graph.NewGraph(repository).MergeBaseAll(leftsha, rightsha)
+	if err != nil {
+		return err
+	}
+	for _, base := range bases {
+		r.add(base, true)
+	}
+	r.Left = append(r.Left, leftsha)
+	return nil
+}
+
+func (r *Revisions) add(sha string, exclude bool) {
+	if exclude {
+		r.Exclude = append(r.Exclude, sha)
+	} else {
+		r.Include = append(r.Include, sha)
+	}
+}
+
+// revisionRange splits arg at its first ".." or "...", skipping any inside
+// braces such as those of ^{/message} and any in a :/message search.
+func revisionRange(arg string) (string, string, string) {
+	if strings.HasPrefix(arg, ":/") {
+		return arg, "", ""
+	}
+	depth := 0
+	for i := 0; i < len(arg); i++ {
+		switch {
+		case arg[i] == '{':
+			depth++
+		case arg[i] == '}':
+			depth--
+		case depth == 0 && strings.HasPrefix(arg[i:], "..."):
+			return arg[:i], "...", arg[i+3:]
+		case depth == 0 && strings.HasPrefix(arg[i:], ".."):
+			return arg[:i], "..", arg[i+2:]
+		}
+	}
+	return arg, "", ""
+}
+
+func revisionResolve(repository *repo.Repository, arg string, name string) (string, error) {
+	if name == "" {
+		name = "HEAD"
+	}
+	sha, err := obj.ObjectFind(repository, name, "commit", true)
+	if err != nil {
+		return "", fmt.Errorf("bad revision '%s'", arg)
+	}
+	return sha, nil
+}
+
+// Walk lists the selected commits with their parents, see graph.Walk.
+func (r *Revisions) Walk(g *graph.Graph, simplify graph.Simplify, topo bool, limit int) ([]string, map[string][]string, error) {
+	return g.Walk(r.Include, r.Exclude, simplify, topo, limit)
+}
+
+// Sides marks each of the given commits with "<" when it is reachable from
+// the left side of a symmetric difference and with ">" otherwise.
+func (r *Revisions) Sides(g *graph.Graph, order []string) (map[string]string, error) {
+	left, err := g.Range(r.Left, r.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	leftside := make(map[string]bool)
+	for _, sha := range left {
+		leftside[sha] = true
+	}
+
+	res := make(map[string]string)
+	for _, sha := range order {
+		res[sha] = ">"
+		if leftside[sha] {
+			res[sha] = "<"
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,152 @@
+package rev
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Jcho114/go-git/graph"
+	"github.com/Jcho114/go-git/obj"
+	"github.com/Jcho114/go-git/ref"
+	"github.com/Jcho114/go-git/repo"
+)
+
+func testRepository(t *testing.T) *repo.Repository {
+	t.Helper()
+	repository, err := repo.NewRepository(t.TempDir(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{"objects", "refs/heads", "refs/tags"} {
+		err := os.MkdirAll(filepath.Join(repository.Gitdir, dir), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = ref.RefSymbolicWrite(repository, "HEAD", "refs/heads/main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repository
+}
+
+// testCommit writes a commit with an empty tree, dated by seconds, and
+// points branch at it.
+func testCommit(t *testing.T, repository *repo.Repository, branch string, message string, seconds int, parents ...string) string {
+	t.Helper()
+	tree, err := obj.ObjectWrite(repository, &obj.Tree{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var content strings.Builder
+	fmt.Fprintf(&content, "tree %s\n", tree)
+	for _, parent := range parents {
+		fmt.Fprintf(&content, "parent %s\n", parent)
+	}
+	fmt.Fprintf(&content, "author Tester <t@example.com> %d +0000\n", seconds)
+	fmt.Fprintf(&content, "committer Tester <t@example.com> %d +0000\n", seconds)
+	fmt.Fprintf(&content, "\n%s\n", message)
+	sha, err := obj.ObjectWriteRaw(repository, "commit", []byte(content.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ref.RefWrite(repository, "refs/heads/"+branch, sha)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sha
+}
+
+func TestRevisionRange(t *testing.T) {
+	tests := []struct {
+		arg, left, operator, right string
+	}{
+		{"main", "main", "", ""},
+		{"a..b", "a", "..", "b"},
+		{"a...b", "a", "...", "b"},
+		{"..b", "", "..", "b"},
+		{"a...", "a", "...", ""},
+		{":/fix a..b", ":/fix a..b", "", ""},
+		{":/more...", ":/more...", "", ""},
+		{"HEAD^{/a..b}", "HEAD^{/a..b}", "", ""},
+		{"HEAD^{/a...b}..main", "HEAD^{/a...b}", "..", "main"},
+		{"main@{1}..main@{0}", "main@{1}", "..", "main@{0}"},
+	}
+	for _, test := range tests {
+		left, operator, right := revisionRange(test.arg)
+		if left != test.left || operator != test.operator || right != test.right {
+			t.Errorf("revisionRange(%q) = %q %q %q, want %q %q %q", test.arg, left, operator, right, test.left, test.operator, test.right)
+		}
+	}
+}
+
+func TestRevisionParse(t *testing.T) {
+	repository := testRepository(t)
+	base := testCommit(t, repository, "main", "base", 1)
+	one := testCommit(t, repository, "main", "one a..b", 2, base)
+	two := testCommit(t, repository, "topic", "two", 3, base)
+
+	tests := []struct {
+		args             []string
+		include, exclude []string
+	}{
+		{[]string{"main"}, []string{one}, []string{}},
+		{[]string{"^main", "topic"}, []string{two}, []string{one}},
+		{[]string{"main..topic"}, []string{two}, []string{one}},
+		{[]string{"..topic"}, []string{two}, []string{one}},
+		{[]string{"main...topic"}, []string{one, two}, []string{base}},
+		{[]string{"topic", "--not", "main"}, []string{two}, []string{one}},
+		{[]string{"--not", "main..topic"}, []string{one}, []string{two}},
+		{[]string{":/one a..b"}, []string{one}, []string{}},
+		{[]string{"main^{/a..b}"}, []string{one}, []string{}},
+	}
+	for _, test := range tests {
+		revisions, err := RevisionParse(repository, test.args)
+		if err != nil {
+			t.Errorf("RevisionParse(%q): %v", test.args, err)
+			continue
+		}
+		if !slices.Equal(revisions.Include, test.include) || !slices.Equal(revisions.Exclude, test.exclude) {
+			t.Errorf("RevisionParse(%q) = %v ^%v, want %v ^%v", test.args, revisions.Include, revisions.Exclude, test.include, test.exclude)
+		}
+	}
+
+	_, err := RevisionParse(repository, []string{"main..missing"})
+	if err == nil || err.Error() != "bad revision 'main..missing'" {
+		t.Errorf("a range with a bad side returned %v", err)
+	}
+}
+
+func TestRevisionWalkLimit(t *testing.T) {
+	repository := testRepository(t)
+	shas := []string{testCommit(t, repository, "main", "c0", 1)}
+	for i := 1; i < 5; i++ {
+		shas = append(shas, testCommit(t, repository, "main", fmt.Sprintf("c%d", i), i+1, shas[i-1]))
+	}
+	revisions, err := RevisionParse(repository, []string{"main"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	visited := 0
+	simplify := func(sha string, parents []string) ([]string, bool, error) {
+		visited++
+		return parents, true, nil
+	}
+	order, parents, err := revisions.Walk(graph.NewGraph(repository), simplify, false, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if visited != 2 {
+		t.Errorf("a walk limited to 2 visited %d commits", visited)
+	}
+	if !slices.Equal(order, []string{shas[4], shas[3]}) {
+		t.Errorf("a walk limited to 2 listed %v", order)
+	}
+	if !slices.Equal(parents[shas[3]], []string{shas[2]}) {
+		t.Errorf("the last listed commit has parents %v, want %s", parents[shas[3]], shas[2])
+	}
+}
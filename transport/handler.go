@@ -0,0 +1,89 @@
+package transport
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/Jcho114/go-git/repo"
+)
+
+// Handler serves a repository over smart HTTP the way git http-backend does,
+// so stock git clients can clone, fetch and push. Fetches only speak
+// protocol version 2, and clients asking for an older one are turned away,
+// while pushes use the original protocol, which is all receive-pack has.
+type Handler struct {
+	Repository *repo.Repository
+	Hooks      *ReceiveHooks
+	// Mutex keeps pushes from updating refs at the same time.
+	Mutex sync.Mutex
+}
+
+func NewHandler(repository *repo.Repository, hooks *ReceiveHooks) *Handler {
+	return &Handler{Repository: repository, Hooks: hooks}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/info/refs"):
+		h.serveAdvertisement(w, r)
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/git-upload-pack"):
+		h.serveService(w, r, "git-upload-pack")
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/git-receive-pack"):
+		h.serveService(w, r, "git-receive-pack")
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *Handler) serveAdvertisement(w http.ResponseWriter, r *http.Request) {
+	service := r.URL.Query().Get("service")
+	if service != "git-upload-pack" && service != "git-receive-pack" {
+		http.Error(w, "only smart HTTP is supported", http.StatusForbidden)
+		return
+	}
+	w.Header().Set("Content-Type", fmt.Sprintf("application/x-%s-advertisement", service))
+	w.Header().Set("Cache-Control", "no-cache")
+
+	if service == "git-upload-pack" && UploadPackVersion(r.Header.Get("Git-Protocol")) {
+		UploadPackAdvertise(w)
+		return
+	}
+	PktLineWritef(w, "# service=%s\n", service)
+	PktFlushWrite(w)
+	if service == "git-upload-pack" {
+		UploadPackRefuse(w)
+		return
+	}
+	ReceivePackAdvertise(h.Repository, w)
+}
+
+func (h *Handler) serveService(w http.ResponseWriter, r *http.Request, service string) {
+	if r.Header.Get("Content-Type") != fmt.Sprintf("application/x-%s-request", service) {
+		http.Error(w, "unexpected content type", http.StatusUnsupportedMediaType)
+		return
+	}
+	var body io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		reader, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		defer reader.Close()
+		body = reader
+	}
+	w.Header().Set("Content-Type", fmt.Sprintf("application/x-%s-result", service))
+	w.Header().Set("Cache-Control", "no-cache")
+
+	if service == "git-upload-pack" {
+		UploadPack(h.Repository, body, w)
+		return
+	}
+	h.Mutex.Lock()
+	defer h.Mutex.Unlock()
+	ReceivePack(h.Repository, body, w, h.Hooks)
+}
@@ -0,0 +1,144 @@
+package transport
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/Jcho114/go-git/repo"
+)
+
+// HTTPTransport speaks git's smart HTTP protocol, where every request to
+// upload-pack or receive-pack is a separate POST and the remote keeps no
+// state in between.
+type HTTPTransport struct {
+	URL          string
+	Client       *http.Client
+	Capabilities map[string]string
+	// Receive holds what receive-pack advertised, which it only does in
+	// the original protocol.
+	Receive             []Ref
+	ReceiveCapabilities map[string]string
+}
+
+func NewHTTPTransport(url string) (*HTTPTransport, error) {
+	return &HTTPTransport{
+		URL:    strings.TrimSuffix(url, "/"),
+		Client: http.DefaultClient,
+	}, nil
+}
+
+func (t *HTTPTransport) do(method string, url string, contenttype string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if strings.Contains(url, "git-upload-pack") {
+		req.Header.Set("Git-Protocol", "version=2")
+	}
+	if contenttype != "" {
+		req.Header.Set("Content-Type", contenttype)
+	}
+
+	res, err := t.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, fmt.Errorf("unable to access '%s': the server returned %s", t.URL, res.Status)
+	}
+	return res, nil
+}
+
+// discover asks the remote for the capabilities of its upload-pack, which
+// also checks that it speaks protocol version 2 at all.
+func (t *HTTPTransport) discover() error {
+	if t.Capabilities != nil {
+		return nil
+	}
+	res, err := t.do(http.MethodGet, t.URL+"/info/refs?service=git-upload-pack", "", nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.Header.Get("Content-Type") != "application/x-git-upload-pack-advertisement" {
+		return fmt.Errorf("'%s' is not a smart HTTP git repository", t.URL)
+	}
+
+	capabilities, err := capabilitiesRead(NewPktReader(res.Body))
+	if err != nil {
+		return err
+	}
+	t.Capabilities = capabilities
+	return nil
+}
+
+func (t *HTTPTransport) request(body []byte) (io.ReadCloser, error) {
+	res, err := t.do(http.MethodPost, t.URL+"/git-upload-pack", "application/x-git-upload-pack-request", body)
+	if err != nil {
+		return nil, err
+	}
+	return res.Body, nil
+}
+
+func (t *HTTPTransport) LsRefs(prefixes []string) ([]Ref, error) {
+	err := t.discover()
+	if err != nil {
+		return nil, err
+	}
+	return v2LsRefs(t, prefixes)
+}
+
+func (t *HTTPTransport) Fetch(repository *repo.Repository, wants []string, progress io.Writer) error {
+	err := t.discover()
+	if err != nil {
+		return err
+	}
+	return v2Fetch(t, repository, wants, progress)
+}
+
+func (t *HTTPTransport) PushRefs() ([]Ref, error) {
+	if t.ReceiveCapabilities != nil {
+		return t.Receive, nil
+	}
+	res, err := t.do(http.MethodGet, t.URL+"/info/refs?service=git-receive-pack", "", nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.Header.Get("Content-Type") != "application/x-git-receive-pack-advertisement" {
+		return nil, fmt.Errorf("'%s' is not a smart HTTP git repository", t.URL)
+	}
+
+	t.Receive, t.ReceiveCapabilities, err = advertisementRead(NewPktReader(res.Body))
+	if err != nil {
+		return nil, err
+	}
+	return t.Receive, nil
+}
+
+func (t *HTTPTransport) Push(repository *repo.Repository, commands []Command, haves []string, progress io.Writer) (map[string]string, error) {
+	_, err := t.PushRefs()
+	if err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	err = receivePackWrite(&body, repository, commands, haves, t.ReceiveCapabilities, progress)
+	if err != nil {
+		return nil, err
+	}
+	res, err := t.do(http.MethodPost, t.URL+"/git-receive-pack", "application/x-git-receive-pack-request", body.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	return receivePackReport(res.Body, t.ReceiveCapabilities, progress)
+}
+
+func (t *HTTPTransport) Close() error {
+	return nil
+}
@@ -0,0 +1,328 @@
+package transport
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Jcho114/go-git/obj"
+	"github.com/Jcho114/go-git/ref"
+	"github.com/Jcho114/go-git/repo"
+)
+
+// testRepository creates an empty repository whose HEAD points at main.
+func testRepository(t *testing.T) *repo.Repository {
+	t.Helper()
+	repository, err := repo.NewRepository(t.TempDir(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{"objects", "refs/heads", "refs/tags"} {
+		err := os.MkdirAll(filepath.Join(repository.Gitdir, dir), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = ref.RefSymbolicWrite(repository, "HEAD", "refs/heads/main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = repository.Config.Write(filepath.Join(repository.Gitdir, "config"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return repository
+}
+
+// testCommit commits the given files on top of a branch and moves the
+// branch to the new commit.
+func testCommit(t *testing.T, repository *repo.Repository, branch string, files map[string]string, message string) string {
+	t.Helper()
+	tree := &obj.Tree{}
+	for name, content := range files {
+		sha, err := obj.ObjectWriteRaw(repository, "blob", []byte(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tree.Items = append(tree.Items, obj.NewTreeLeaf("100644", name, sha))
+	}
+	treesha, err := obj.ObjectWrite(repository, tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var content strings.Builder
+	fmt.Fprintf(&content, "tree %s\n", treesha)
+	if parent, err := ref.RefResolve(repository, "refs/heads/"+branch); err == nil {
+		fmt.Fprintf(&content, "parent %s\n", parent)
+	}
+	content.WriteString("author Tester <t@example.com> 1700000000 +0000\n")
+	content.WriteString("committer Tester <t@example.com> 1700000000 +0000\n")
+	fmt.Fprintf(&content, "\n%s\n", message)
+	sha, err := obj.ObjectWriteRaw(repository, "commit", []byte(content.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ref.RefWrite(repository, "refs/heads/"+branch, sha)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sha
+}
+
+// testTag writes an annotated tag pointing at a commit.
+func testTag(t *testing.T, repository *repo.Repository, name string, commit string) string {
+	t.Helper()
+	content := fmt.Sprintf("object %s\ntype commit\ntag %s\ntagger Tester <t@example.com> 1700000000 +0000\n\n%s\n", commit, name, name)
+	sha, err := obj.ObjectWriteRaw(repository, "tag", []byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ref.RefWrite(repository, "refs/tags/"+name, sha)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sha
+}
+
+// testRecorder keeps the bodies of the requests a test server receives.
+type testRecorder struct {
+	Mutex  sync.Mutex
+	Bodies []string
+}
+
+func testServer(t *testing.T, repository *repo.Repository, hooks *ReceiveHooks) (*httptest.Server, *testRecorder) {
+	t.Helper()
+	recorder := &testRecorder{}
+	handler := NewHandler(repository, hooks)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				t.Error(err)
+			}
+			recorder.Mutex.Lock()
+			recorder.Bodies = append(recorder.Bodies, string(body))
+			recorder.Mutex.Unlock()
+			r.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server, recorder
+}
+
+func testPackObjects(t *testing.T, repository *repo.Repository) map[string]bool {
+	t.Helper()
+	packs, err := obj.PackList(repository)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := make(map[string]bool)
+	for _, pack := range packs {
+		shas, err := obj.PackObjects(pack)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, sha := range shas {
+			res[sha] = true
+		}
+	}
+	return res
+}
+
+func TestHTTPLsRefs(t *testing.T) {
+	server := testRepository(t)
+	main := testCommit(t, server, "main", map[string]string{"a.txt": "a\n"}, "first")
+	topic := testCommit(t, server, "topic", map[string]string{"b.txt": "b\n"}, "topic")
+	tag := testTag(t, server, "v1", main)
+	httpserver, _ := testServer(t, server, nil)
+
+	conn, err := NewHTTPTransport(httpserver.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	refs, err := conn.LsRefs([]string{"HEAD", "refs/heads/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := []string{}
+	for _, remoteref := range refs {
+		got = append(got, remoteref.Name+" "+remoteref.Sha+" "+remoteref.Target)
+	}
+	want := []string{
+		"HEAD " + main + " refs/heads/main",
+		"refs/heads/main " + main + " ",
+		"refs/heads/topic " + topic + " ",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("ls-refs returned\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	refs, err = conn.LsRefs([]string{"refs/tags/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(refs) != 1 || refs[0].Name != "refs/tags/v1" || refs[0].Sha != tag || refs[0].Peeled != main {
+		t.Errorf("ls-refs for tags returned %+v, want v1 at %s peeled to %s", refs, tag, main)
+	}
+}
+
+func TestHTTPFetchNegotiation(t *testing.T) {
+	server := testRepository(t)
+	client := testRepository(t)
+	first := testCommit(t, server, "main", map[string]string{"a.txt": "a\n"}, "first")
+	httpserver, recorder := testServer(t, server, nil)
+
+	conn, err := NewHTTPTransport(httpserver.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = conn.Fetch(client, []string{first}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !obj.ObjectExists(client, first) {
+		t.Fatalf("fetch did not bring %s", first)
+	}
+	err = ref.RefWrite(client, "refs/remotes/origin/main", first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := testPackObjects(t, client)
+
+	second := testCommit(t, server, "main", map[string]string{"a.txt": "a\n", "b.txt": "b\n"}, "second")
+	recorder.Bodies = nil
+	err = conn.Fetch(client, []string{second}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !obj.ObjectExists(client, second) {
+		t.Fatalf("fetch did not bring %s", second)
+	}
+
+	if len(recorder.Bodies) == 0 || !strings.Contains(recorder.Bodies[0], "have "+first) {
+		t.Errorf("fetch did not offer %s as a have: %q", first, recorder.Bodies)
+	}
+	sent := make(map[string]bool)
+	for sha := range testPackObjects(t, client) {
+		if !before[sha] {
+			sent[sha] = true
+		}
+	}
+	if sent[first] {
+		t.Errorf("the second pack resent the common commit %s", first)
+	}
+	// The new commit, its tree and the new blob.
+	if len(sent) != 3 {
+		t.Errorf("the second pack held %d objects, want 3", len(sent))
+	}
+}
+
+func TestHTTPFetchProgress(t *testing.T) {
+	server := testRepository(t)
+	client := testRepository(t)
+	main := testCommit(t, server, "main", map[string]string{"a.txt": "a\n"}, "first")
+	httpserver, recorder := testServer(t, server, nil)
+
+	conn, err := NewHTTPTransport(httpserver.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var progress bytes.Buffer
+	err = conn.Fetch(client, []string{main}, NewProgressWriter(&progress))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if progress.String() != "remote: Enumerating objects: 3, done.\n" {
+		t.Errorf("progress was %q", progress.String())
+	}
+
+	quiet := testRepository(t)
+	recorder.Bodies = nil
+	err = conn.Fetch(quiet, []string{main}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(recorder.Bodies[0], "no-progress") {
+		t.Errorf("a fetch without progress did not ask for no-progress: %q", recorder.Bodies[0])
+	}
+}
+
+func TestHTTPFetchIncludeTag(t *testing.T) {
+	server := testRepository(t)
+	client := testRepository(t)
+	main := testCommit(t, server, "main", map[string]string{"a.txt": "a\n"}, "first")
+	tag := testTag(t, server, "v1", main)
+	httpserver, _ := testServer(t, server, nil)
+
+	conn, err := NewHTTPTransport(httpserver.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = conn.Fetch(client, []string{main}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !obj.ObjectExists(client, tag) {
+		t.Errorf("the tag %s pointing at the fetched commit was not included", tag)
+	}
+}
+
+func TestHTTPFetchNotOurRef(t *testing.T) {
+	server := testRepository(t)
+	client := testRepository(t)
+	testCommit(t, server, "main", map[string]string{"a.txt": "a\n"}, "first")
+	missing := testCommit(t, client, "main", map[string]string{"b.txt": "b\n"}, "elsewhere")
+	httpserver, _ := testServer(t, server, nil)
+
+	conn, err := NewHTTPTransport(httpserver.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = conn.Fetch(testRepository(t), []string{missing}, nil)
+	if err == nil || !strings.Contains(err.Error(), "not our ref") {
+		t.Errorf("fetching an unknown object returned %v", err)
+	}
+}
+
+func TestHTTPOlderProtocolRefused(t *testing.T) {
+	server := testRepository(t)
+	testCommit(t, server, "main", map[string]string{"a.txt": "a\n"}, "first")
+	httpserver, _ := testServer(t, server, nil)
+
+	for _, protocol := range []string{"", "version=1", "version=2:object-format=sha1"} {
+		req, err := http.NewRequest(http.MethodGet, httpserver.URL+"/info/refs?service=git-upload-pack", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if protocol != "" {
+			req.Header.Set("Git-Protocol", protocol)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		refused := strings.Contains(string(body), "ERR only protocol version 2 is supported")
+		if UploadPackVersion(protocol) {
+			if refused || !strings.HasPrefix(string(body), "000eversion 2\n") {
+				t.Errorf("Git-Protocol %q was not answered with version 2: %q", protocol, body)
+			}
+		} else if !refused || !strings.HasPrefix(string(body), "001e# service=git-upload-pack\n0000") {
+			t.Errorf("Git-Protocol %q was not refused: %q", protocol, body)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package transport
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Jcho114/go-git/obj"
+	"github.com/Jcho114/go-git/ref"
+	"github.com/Jcho114/go-git/repo"
+)
+
+// LocalTransport reaches a repository on the same filesystem, given as a
+// path or a file:// url, without any server in between. A clone hardlinks
+// the objects of the remote, while later fetches and pushes copy only the
+// objects that are missing, as a pack streamed from one store to the other.
+type LocalTransport struct {
+	Remote *repo.Repository
+}
+
+func NewLocalTransport(path string) (*LocalTransport, error) {
+	if filepath.Base(path) == ".git" {
+		path = filepath.Dir(path)
+	}
+	remote, err := repo.NewRepository(path, false)
+	if err != nil {
+		return nil, fmt.Errorf("'%s' does not appear to be a git repository", path)
+	}
+	return &LocalTransport{Remote: remote}, nil
+}
+
+// LocalPath returns the path of the repository a url names when it is on the
+// local filesystem.
+func LocalPath(url string) (string, bool) {
+	if path, ok := strings.CutPrefix(url, "file://"); ok {
+		return path, true
+	}
+	if _, _, _, ok := sshParse(url); ok || strings.Contains(url, "://") {
+		return "", false
+	}
+	return url, true
+}
+
+func (t *LocalTransport) LsRefs(prefixes []string) ([]Ref, error) {
+	refs, err := serverRefs(t.Remote)
+	if err != nil {
+		return nil, err
+	}
+	return refsFilter(refs, prefixes), nil
+}
+
+// Fetch hardlinks every object of the remote when the repository has none
+// yet, as a clone does, and otherwise copies what the wants need beyond
+// the refs the repository already has.
+func (t *LocalTransport) Fetch(repository *repo.Repository, wants []string, progress io.Writer) error {
+	empty, err := localEmpty(repository)
+	if err != nil {
+		return err
+	}
+	if empty {
+		return localLink(t.Remote, repository)
+	}
+
+	haves := []string{}
+	refmap, err := ref.RefList(repository, "")
+	if err != nil {
+		return err
+	}
+	for _, sha := range ref.RefFlatten(refmap, "refs") {
+		if obj.ObjectExists(t.Remote, sha) {
+			haves = append(haves, sha)
+		}
+	}
+	return localCopy(t.Remote, repository, wants, haves)
+}
+
+func (t *LocalTransport) PushRefs() ([]Ref, error) {
+	refs, err := serverRefs(t.Remote)
+	if err != nil {
+		return nil, err
+	}
+	return refsFilter(refs, []string{"refs/"}), nil
+}
+
+// Push copies the objects the commands need into the remote and updates its
+// refs with the same checks and hooks as receive-pack.
+func (t *LocalTransport) Push(repository *repo.Repository, commands []Command, haves []string, progress io.Writer) (map[string]string, error) {
+	news := []string{}
+	for _, command := range commands {
+		if command.New != "" {
+			news = append(news, command.New)
+		}
+	}
+	if len(news) > 0 {
+		err := localCopy(repository, t.Remote, news, haves)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if progress == nil {
+		progress = io.Discard
+	}
+	return receivePackUpdate(t.Remote, commands, NewHookScripts(), progress), nil
+}
+
+func (t *LocalTransport) Close() error {
+	return nil
+}
+
+// localCopy sends the objects reachable from wants but not from haves, and
+// the annotated tags pointing at them, as a thin pack from one repository
+// to the other, which completes and stores it.
+func localCopy(from *repo.Repository, to *repo.Repository, wants []string, haves []string) error {
+	entries, bases, err := packEntries(from, wants, haves)
+	if err != nil {
+		return err
+	}
+	entries, err = fetchServeTags(from, entries, haves)
+	if err != nil {
+		return err
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(obj.PackSend(from, writer, entries, bases))
+	}()
+	_, err = obj.PackReceive(to, reader)
+	reader.CloseWithError(err)
+	return err
+}
+
+// localEmpty tells whether a repository has no objects at all.
+func localEmpty(repository *repo.Repository) (bool, error) {
+	objectdir := filepath.Join(repository.Gitdir, "objects")
+	entries, err := os.ReadDir(objectdir)
+	if errors.Is(err, os.ErrNotExist) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, entry := range entries {
+		if entry.Name() == "info" {
+			continue
+		}
+		children, err := os.ReadDir(filepath.Join(objectdir, entry.Name()))
+		if err != nil || len(children) > 0 {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// localLink hardlinks the loose objects and packs of one repository into
+// another, copying them instead when they are on different filesystems.
+// Objects are never modified once written, so both can share the files.
+func localLink(from *repo.Repository, to *repo.Repository) error {
+	fromdir := filepath.Join(from.Gitdir, "objects")
+	todir := filepath.Join(to.Gitdir, "objects")
+	return filepath.WalkDir(fromdir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(fromdir, path)
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if rel == "info" {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(filepath.Join(todir, rel), 0755)
+		}
+		if strings.HasPrefix(entry.Name(), "tmp_") {
+			return nil
+		}
+
+		dst := filepath.Join(todir, rel)
+		err = os.Link(path, dst)
+		if err == nil || errors.Is(err, os.ErrExist) {
+			return nil
+		}
+		return localCopyFile(path, dst)
+	})
+}
+
+func localCopyFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0444)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,113 @@
+package transport
+
+import (
+	"sort"
+
+	"github.com/Jcho114/go-git/graph"
+	"github.com/Jcho114/go-git/obj"
+	"github.com/Jcho114/go-git/ref"
+	"github.com/Jcho114/go-git/repo"
+)
+
+// negotiator hands out the commits of the local history newest first, so
+// the remote can say which of them it already has and leave their history
+// out of the pack. Once the remote has a commit, none of its ancestors are
+// offered again.
+type negotiator struct {
+	Graph  *graph.Graph
+	Queue  []string
+	Dates  map[string]int64
+	Seen   map[string]bool
+	Common map[string]bool
+}
+
+func newNegotiator(repository *repo.Repository) (*negotiator, error) {
+	n := &negotiator{
+		Graph:  graph.NewGraph(repository),
+		Queue:  []string{},
+		Dates:  make(map[string]int64),
+		Seen:   make(map[string]bool),
+		Common: make(map[string]bool),
+	}
+
+	refmap, err := ref.RefList(repository, "")
+	if err != nil {
+		return nil, err
+	}
+	names := []string{"HEAD"}
+	for _, sha := range ref.RefFlatten(refmap, "refs") {
+		names = append(names, sha)
+	}
+	for _, name := range names {
+		sha, err := obj.ObjectFind(repository, name, "commit", true)
+		if err != nil {
+			continue
+		}
+		err = n.push(sha)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return n, nil
+}
+
+func (n *negotiator) push(sha string) error {
+	if n.Seen[sha] {
+		return nil
+	}
+	n.Seen[sha] = true
+	date, err := n.Graph.Date(sha)
+	if err != nil {
+		return err
+	}
+	n.Dates[sha] = date
+	n.Queue = append(n.Queue, sha)
+	sort.SliceStable(n.Queue, func(i, j int) bool {
+		return n.Dates[n.Queue[i]] > n.Dates[n.Queue[j]]
+	})
+	return nil
+}
+
+// next returns up to count more commits to offer.
+func (n *negotiator) next(count int) ([]string, error) {
+	res := []string{}
+	for len(n.Queue) > 0 && len(res) < count {
+		sha := n.Queue[0]
+		n.Queue = n.Queue[1:]
+		if n.Common[sha] {
+			continue
+		}
+		res = append(res, sha)
+
+		parents, err := n.Graph.Parents(sha)
+		if err != nil {
+			return nil, err
+		}
+		for _, parent := range parents {
+			err := n.push(parent)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return res, nil
+}
+
+// ack records that the remote has a commit, and with it all its ancestors.
+func (n *negotiator) ack(sha string) error {
+	stack := []string{sha}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if n.Common[current] {
+			continue
+		}
+		n.Common[current] = true
+		parents, err := n.Graph.Parents(current)
+		if err != nil {
+			return err
+		}
+		stack = append(stack, parents...)
+	}
+	return nil
+}
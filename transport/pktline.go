@@ -0,0 +1,161 @@
+package transport
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Packets of the pkt-line format carry their own length as four hex digits.
+// The lengths below four are special packets without any data.
+const (
+	PktData = iota
+	PktFlush
+	PktDelim
+	PktResponseEnd
+)
+
+const pktMaxData = 65516
+
+func PktLineWrite(writer io.Writer, data []byte) error {
+	if len(data) > pktMaxData {
+		return fmt.Errorf("pkt-line of %d bytes is too long", len(data))
+	}
+	_, err := fmt.Fprintf(writer, "%04x", len(data)+4)
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(data)
+	return err
+}
+
+// PktLineWritef writes a formatted line as a single packet.
+func PktLineWritef(writer io.Writer, format string, args ...any) error {
+	return PktLineWrite(writer, []byte(fmt.Sprintf(format, args...)))
+}
+
+func PktFlushWrite(writer io.Writer) error {
+	_, err := io.WriteString(writer, "0000")
+	return err
+}
+
+func PktDelimWrite(writer io.Writer) error {
+	_, err := io.WriteString(writer, "0001")
+	return err
+}
+
+type PktReader struct {
+	Reader io.Reader
+}
+
+func NewPktReader(reader io.Reader) *PktReader {
+	return &PktReader{Reader: reader}
+}
+
+// Next reads a single packet and returns its data along with which kind of
+// packet it was.
+func (p *PktReader) Next() ([]byte, int, error) {
+	header := make([]byte, 4)
+	_, err := io.ReadFull(p.Reader, header)
+	if err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, 0, fmt.Errorf("truncated pkt-line")
+		}
+		return nil, 0, err
+	}
+	length, err := strconv.ParseUint(string(header), 16, 16)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid pkt-line length %q", header)
+	}
+	switch length {
+	case 0:
+		return nil, PktFlush, nil
+	case 1:
+		return nil, PktDelim, nil
+	case 2:
+		return nil, PktResponseEnd, nil
+	case 3:
+		return nil, 0, fmt.Errorf("invalid pkt-line length %q", header)
+	}
+
+	data := make([]byte, length-4)
+	_, err = io.ReadFull(p.Reader, data)
+	if err != nil {
+		return nil, 0, fmt.Errorf("truncated pkt-line")
+	}
+	return data, PktData, nil
+}
+
+// Lines reads text packets up to the next special packet, which it returns,
+// with their trailing newlines removed.
+func (p *PktReader) Lines() ([]string, int, error) {
+	res := []string{}
+	for {
+		data, kind, err := p.Next()
+		if err != nil {
+			return nil, 0, err
+		}
+		if kind != PktData {
+			return res, kind, nil
+		}
+		res = append(res, strings.TrimSuffix(string(data), "\n"))
+	}
+}
+
+// Sideband splits the multiplexed packets that follow into the data of band
+// 1, which goes to data, and the progress messages of band 2, which go to
+// progress. A message on band 3 is a fatal error from the remote.
+func (p *PktReader) Sideband(data io.Writer, progress io.Writer) error {
+	for {
+		packet, kind, err := p.Next()
+		if err == io.EOF || kind == PktFlush {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if kind != PktData || len(packet) == 0 {
+			return fmt.Errorf("unexpected packet in sideband stream")
+		}
+
+		switch packet[0] {
+		case 1:
+			_, err = data.Write(packet[1:])
+		case 2:
+			if progress != nil {
+				_, err = progress.Write(packet[1:])
+			}
+		case 3:
+			return fmt.Errorf("remote error: %s", bytes.TrimSpace(packet[1:]))
+		default:
+			return fmt.Errorf("unknown sideband %d", packet[0])
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// sidebandWriter sends everything written to it on one band of a
+// multiplexed stream, split into packets that fit.
+type sidebandWriter struct {
+	Writer io.Writer
+	Band   byte
+}
+
+func NewSidebandWriter(writer io.Writer, band byte) io.Writer {
+	return &sidebandWriter{Writer: writer, Band: band}
+}
+
+func (w *sidebandWriter) Write(data []byte) (int, error) {
+	for start := 0; start < len(data); start += pktMaxData - 1 {
+		end := min(start+pktMaxData-1, len(data))
+		err := PktLineWrite(w.Writer, append([]byte{w.Band}, data[start:end]...))
+		if err != nil {
+			return start, err
+		}
+	}
+	return len(data), nil
+}
@@ -0,0 +1,219 @@
+package transport
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Jcho114/go-git/graph"
+	"github.com/Jcho114/go-git/obj"
+	"github.com/Jcho114/go-git/repo"
+)
+
+var zeroSha = strings.Repeat("0", 40)
+
+// advertisementRead reads the refs receive-pack starts with, the first of
+// which carries its capabilities after a NUL. A repository without refs
+// advertises a single placeholder named "capabilities^{}" instead.
+func advertisementRead(reader *PktReader) ([]Ref, map[string]string, error) {
+	lines, _, err := reader.Lines()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(lines) > 0 && strings.HasPrefix(lines[0], "# service=") {
+		lines, _, err = reader.Lines()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	refs := []Ref{}
+	capabilities := make(map[string]string)
+	for i, line := range lines {
+		if message, ok := strings.CutPrefix(line, "ERR "); ok {
+			return nil, nil, fmt.Errorf("remote error: %s", message)
+		}
+		if i == 0 {
+			var rest string
+			line, rest, _ = strings.Cut(line, "\x00")
+			for _, capability := range strings.Fields(rest) {
+				key, value, _ := strings.Cut(capability, "=")
+				capabilities[key] = value
+			}
+		}
+		sha, name, ok := strings.Cut(line, " ")
+		if !ok {
+			return nil, nil, fmt.Errorf("invalid ref advertisement '%s'", line)
+		}
+		if name == "capabilities^{}" {
+			continue
+		}
+		refs = append(refs, Ref{Name: name, Sha: sha})
+	}
+	return refs, capabilities, nil
+}
+
+// packEntries lists the objects reachable from news but not from haves. The
+// trees and blobs of the commits at the edge of what the remote has are
+// returned as well, as bases the pack can hold deltas against.
+func packEntries(repository *repo.Repository, news []string, haves []string) ([]obj.WalkEntry, []obj.WalkEntry, error) {
+	include, exclude := []string{}, []string{}
+	roots := []string{}
+	for _, sha := range news {
+		commit, err := obj.ObjectFind(repository, sha, "commit", true)
+		if err == nil {
+			include = append(include, commit)
+		}
+		roots = append(roots, sha)
+	}
+	for _, sha := range haves {
+		commit, err := obj.ObjectFind(repository, sha, "commit", true)
+		if err == nil {
+			exclude = append(exclude, commit)
+		}
+	}
+
+	g := graph.NewGraph(repository)
+	commits, err := g.Range(include, exclude)
+	if err != nil {
+		return nil, nil, err
+	}
+	sending := make(map[string]bool)
+	for _, commit := range commits {
+		sending[commit] = true
+	}
+	had, err := g.Range(exclude, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	skip := make(map[string]bool)
+	for _, sha := range append(had, haves...) {
+		skip[sha] = true
+	}
+
+	edges := []string{}
+	edged := make(map[string]bool)
+	for _, commit := range append(commits, exclude...) {
+		parents, err := g.Parents(commit)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !sending[commit] {
+			parents = []string{commit}
+		}
+		for _, parent := range parents {
+			if sending[parent] || edged[parent] {
+				continue
+			}
+			edged[parent] = true
+			tree, err := obj.ObjectFind(repository, parent, "tree", true)
+			if err != nil {
+				return nil, nil, err
+			}
+			edges = append(edges, tree)
+		}
+	}
+
+	bases, err := obj.ObjectWalk(repository, edges, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, base := range bases {
+		skip[base.Sha] = true
+	}
+	entries, err := obj.ObjectWalk(repository, roots, skip)
+	if err != nil {
+		return nil, nil, err
+	}
+	return entries, bases, nil
+}
+
+// receivePackWrite writes the request receive-pack expects: the commands,
+// the first one followed by the capabilities asked for, and then the pack
+// of objects they need, which is left out when every command is a delete.
+func receivePackWrite(writer io.Writer, repository *repo.Repository, commands []Command, haves []string, capabilities map[string]string, progress io.Writer) error {
+	requested := []string{"report-status"}
+	if _, ok := capabilities["side-band-64k"]; ok {
+		requested = append(requested, "side-band-64k")
+	}
+	if _, ok := capabilities["ofs-delta"]; ok {
+		requested = append(requested, "ofs-delta")
+	}
+	if _, ok := capabilities["quiet"]; ok && progress == nil {
+		requested = append(requested, "quiet")
+	}
+	requested = append(requested, "agent=go-git")
+
+	news := []string{}
+	for i, command := range commands {
+		old, new := command.Old, command.New
+		if old == "" {
+			old = zeroSha
+		}
+		if new == "" {
+			if _, ok := capabilities["delete-refs"]; !ok {
+				return fmt.Errorf("the remote does not support deleting refs")
+			}
+			new = zeroSha
+		} else {
+			news = append(news, new)
+		}
+
+		line := fmt.Sprintf("%s %s %s", old, new, command.Name)
+		if i == 0 {
+			line += "\x00" + strings.Join(requested, " ")
+		}
+		err := PktLineWritef(writer, "%s\n", line)
+		if err != nil {
+			return err
+		}
+	}
+	err := PktFlushWrite(writer)
+	if err != nil {
+		return err
+	}
+	if len(news) == 0 {
+		return nil
+	}
+
+	entries, bases, err := packEntries(repository, news, haves)
+	if err != nil {
+		return err
+	}
+	return obj.PackSend(repository, writer, entries, bases)
+}
+
+// receivePackReport reads the report-status receive-pack answers with,
+// which comes on band 1 when side-band-64k was asked for.
+func receivePackReport(reader io.Reader, capabilities map[string]string, progress io.Writer) (map[string]string, error) {
+	report := NewPktReader(reader)
+	if _, ok := capabilities["side-band-64k"]; ok {
+		var buffer bytes.Buffer
+		err := report.Sideband(&buffer, progress)
+		if err != nil {
+			return nil, err
+		}
+		report = NewPktReader(&buffer)
+	}
+
+	lines, _, err := report.Lines()
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("the remote did not report a status")
+	}
+	if status, _ := strings.CutPrefix(lines[0], "unpack "); status != "ok" {
+		return nil, fmt.Errorf("the remote failed to unpack objects: %s", status)
+	}
+
+	res := make(map[string]string)
+	for _, line := range lines[1:] {
+		if rest, ok := strings.CutPrefix(line, "ng "); ok {
+			name, reason, _ := strings.Cut(rest, " ")
+			res[name] = reason
+		}
+	}
+	return res, nil
+}
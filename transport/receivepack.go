@@ -0,0 +1,299 @@
+package transport
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/Jcho114/go-git/obj"
+	"github.com/Jcho114/go-git/ref"
+	"github.com/Jcho114/go-git/repo"
+)
+
+// ReceiveHooks are called around the ref updates of a push, with anything
+// they write shown to the client. PreReceive sees every update that passed
+// the usual checks and can refuse all of them by returning an error.
+// PostReceive sees the updates that were made.
+type ReceiveHooks struct {
+	PreReceive  func(repository *repo.Repository, commands []Command, output io.Writer) error
+	PostReceive func(repository *repo.Repository, commands []Command, output io.Writer)
+}
+
+var receivePackCapabilities = "report-status delete-refs side-band-64k quiet ofs-delta agent=go-git object-format=sha1"
+
+// ReceivePackAdvertise writes the refs of a repository the way receive-pack
+// starts a conversation, with its capabilities after the first one.
+func ReceivePackAdvertise(repository *repo.Repository, writer io.Writer) error {
+	refs, err := serverRefs(repository)
+	if err != nil {
+		return err
+	}
+	lines := []string{}
+	for _, serverref := range refs {
+		if serverref.Name != "HEAD" {
+			lines = append(lines, serverref.Sha+" "+serverref.Name)
+		}
+	}
+	if len(lines) == 0 {
+		lines = append(lines, zeroSha+" capabilities^{}")
+	}
+	lines[0] += "\x00" + receivePackCapabilities
+
+	for _, line := range lines {
+		err := PktLineWritef(writer, "%s\n", line)
+		if err != nil {
+			return err
+		}
+	}
+	return PktFlushWrite(writer)
+}
+
+// ReceivePack reads the ref updates and pack a client pushes, stores the
+// pack, makes every update that is still valid and reports back on each.
+func ReceivePack(repository *repo.Repository, reader io.Reader, writer io.Writer, hooks *ReceiveHooks) error {
+	lines, _, err := NewPktReader(reader).Lines()
+	if err == io.EOF || (err == nil && len(lines) == 0) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	capabilities := make(map[string]bool)
+	commands := []Command{}
+	for i, line := range lines {
+		if i == 0 {
+			var rest string
+			line, rest, _ = strings.Cut(line, "\x00")
+			for _, capability := range strings.Fields(rest) {
+				capabilities[capability] = true
+			}
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return fmt.Errorf("invalid command '%s'", line)
+		}
+		command := Command{Old: fields[0], New: fields[1], Name: fields[2]}
+		if command.Old == zeroSha {
+			command.Old = ""
+		}
+		if command.New == zeroSha {
+			command.New = ""
+		}
+		commands = append(commands, command)
+	}
+
+	output := io.Discard
+	if capabilities["side-band-64k"] {
+		output = NewSidebandWriter(writer, 2)
+	}
+
+	unpack := "ok"
+	for _, command := range commands {
+		if command.New == "" {
+			continue
+		}
+		_, err := obj.PackReceive(repository, reader)
+		if err != nil {
+			unpack = err.Error()
+		}
+		break
+	}
+
+	reasons := make(map[string]string)
+	if unpack != "ok" {
+		for _, command := range commands {
+			reasons[command.Name] = "unpacker error"
+		}
+	} else {
+		reasons = receivePackUpdate(repository, commands, hooks, output)
+	}
+
+	var report bytes.Buffer
+	PktLineWritef(&report, "unpack %s\n", unpack)
+	for _, command := range commands {
+		if reason, ok := reasons[command.Name]; ok {
+			PktLineWritef(&report, "ng %s %s\n", command.Name, reason)
+		} else {
+			PktLineWritef(&report, "ok %s\n", command.Name)
+		}
+	}
+	PktFlushWrite(&report)
+
+	if !capabilities["side-band-64k"] {
+		_, err := writer.Write(report.Bytes())
+		return err
+	}
+	_, err = NewSidebandWriter(writer, 1).Write(report.Bytes())
+	if err != nil {
+		return err
+	}
+	return PktFlushWrite(writer)
+}
+
+// receivePackUpdate checks and makes the ref updates, returning why each
+// one that was not made failed. A ref must still be where the client saw
+// it, and the branch checked out in a repository with a worktree is never
+// updated behind its back.
+func receivePackUpdate(repository *repo.Repository, commands []Command, hooks *ReceiveHooks, output io.Writer) map[string]string {
+	reasons := make(map[string]string)
+	current, _ := ref.RefSymbolicRead(repository, "HEAD")
+	known := receivePackKnown(repository)
+	accepted := []Command{}
+	for _, command := range commands {
+		old, _ := ref.RefResolve(repository, command.Name)
+		switch {
+		case !strings.HasPrefix(command.Name, "refs/") || !ref.RefCheckFormat(strings.TrimPrefix(command.Name, "refs/")):
+			reasons[command.Name] = "funny refname"
+		case old != command.Old:
+			reasons[command.Name] = "stale info"
+		case command.New != "" && !receivePackConnected(repository, command.New, known):
+			reasons[command.Name] = "missing necessary objects"
+		case command.Name == current && !repository.Config.Core.Bare:
+			reasons[command.Name] = "branch is currently checked out"
+		default:
+			accepted = append(accepted, command)
+		}
+	}
+
+	if hooks != nil && hooks.PreReceive != nil && len(accepted) > 0 {
+		err := hooks.PreReceive(repository, accepted, output)
+		if err != nil {
+			for _, command := range accepted {
+				reasons[command.Name] = "pre-receive hook declined"
+			}
+			return reasons
+		}
+	}
+
+	updated := []Command{}
+	for _, command := range accepted {
+		var err error
+		if command.New == "" {
+			err = ref.RefDelete(repository, command.Name)
+		} else {
+			err = ref.RefWrite(repository, command.Name, command.New)
+		}
+		if err != nil {
+			reasons[command.Name] = "failed to update ref"
+			continue
+		}
+		updated = append(updated, command)
+	}
+
+	if hooks != nil && hooks.PostReceive != nil && len(updated) > 0 {
+		hooks.PostReceive(repository, updated, output)
+	}
+	return reasons
+}
+
+// receivePackKnown lists the commits the refs already reach, which are taken
+// to be complete along with everything they point to.
+func receivePackKnown(repository *repo.Repository) map[string]bool {
+	known := make(map[string]bool)
+	refs, err := serverRefs(repository)
+	if err != nil {
+		return known
+	}
+	queue := []string{}
+	for _, remoteref := range refs {
+		queue = append(queue, remoteref.Sha)
+	}
+	for len(queue) > 0 {
+		sha := queue[0]
+		queue = queue[1:]
+		if known[sha] {
+			continue
+		}
+		format, data, err := obj.ObjectReadRaw(repository, sha)
+		if err != nil || (format != "commit" && format != "tag") {
+			continue
+		}
+		links, err := obj.ObjectLinks(format, data)
+		if err != nil {
+			continue
+		}
+		if format == "commit" {
+			known[sha] = true
+		}
+		for _, link := range links {
+			if link.Format == "commit" || link.Format == "tag" {
+				queue = append(queue, link.Sha)
+			}
+		}
+	}
+	return known
+}
+
+// receivePackConnected reports whether everything reachable from sha is in
+// the repository, walking until it meets commits that are already known,
+// the same check git's check_connected makes before it updates a ref.
+func receivePackConnected(repository *repo.Repository, sha string, known map[string]bool) bool {
+	entries, err := obj.ObjectWalk(repository, []string{sha}, known)
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		if entry.Format == "blob" && !obj.ObjectExists(repository, entry.Sha) {
+			return false
+		}
+	}
+	return true
+}
+
+// NewHookScripts returns hooks that run the pre-receive and post-receive
+// scripts of the repository when they exist, the same as git does, feeding
+// each the "<old> <new> <ref>" lines of the updates.
+func NewHookScripts() *ReceiveHooks {
+	return &ReceiveHooks{
+		PreReceive: func(repository *repo.Repository, commands []Command, output io.Writer) error {
+			return hookRun(repository, "pre-receive", commands, output)
+		},
+		PostReceive: func(repository *repo.Repository, commands []Command, output io.Writer) {
+			hookRun(repository, "post-receive", commands, output)
+		},
+	}
+}
+
+func hookRun(repository *repo.Repository, name string, commands []Command, output io.Writer) error {
+	path, err := filepath.Abs(filepath.Join(repository.Gitdir, "hooks", name))
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) || (err == nil && info.Mode()&0111 == 0) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var input strings.Builder
+	for _, command := range commands {
+		old, new := command.Old, command.New
+		if old == "" {
+			old = zeroSha
+		}
+		if new == "" {
+			new = zeroSha
+		}
+		fmt.Fprintf(&input, "%s %s %s\n", old, new, command.Name)
+	}
+
+	gitdir, err := filepath.Abs(repository.Gitdir)
+	if err != nil {
+		return err
+	}
+	hook := exec.Command(path)
+	hook.Dir = gitdir
+	hook.Env = append(os.Environ(), "GIT_DIR="+gitdir)
+	hook.Stdin = strings.NewReader(input.String())
+	hook.Stdout = output
+	hook.Stderr = output
+	return hook.Run()
+}
@@ -0,0 +1,61 @@
+package transport
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Jcho114/go-git/obj"
+	"github.com/Jcho114/go-git/ref"
+)
+
+func TestReceivePackMissingObjects(t *testing.T) {
+	client := testRepository(t)
+	first := testCommit(t, client, "main", map[string]string{"a.txt": "a\n"}, "first")
+	second := testCommit(t, client, "main", map[string]string{"a.txt": "a\n", "b.txt": "b\n"}, "second")
+	tree, err := obj.ObjectFind(client, second, "tree", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blob, err := obj.ObjectWrite(nil, obj.NewBlob([]byte("b\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, missing := range map[string]string{"tree": tree, "blob": blob, "parent": first, "none": ""} {
+		t.Run(name, func(t *testing.T) {
+			server := testRepository(t)
+			entries, err := obj.ObjectWalk(client, []string{second}, map[string]bool{missing: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var request bytes.Buffer
+			PktLineWritef(&request, "%s %s refs/heads/topic\x00report-status\n", zeroSha, second)
+			PktFlushWrite(&request)
+			err = obj.PackSend(client, &request, entries, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var response bytes.Buffer
+			err = ReceivePack(server, &request, &response, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			_, err = ref.RefResolve(server, "refs/heads/topic")
+			if missing == "" {
+				if !strings.Contains(response.String(), "ok refs/heads/topic") || err != nil {
+					t.Errorf("a complete push was refused: %q", response.String())
+				}
+				return
+			}
+			if !strings.Contains(response.String(), "ng refs/heads/topic missing necessary objects") {
+				t.Errorf("a push without its %s got %q", name, response.String())
+			}
+			if err == nil {
+				t.Errorf("a push without its %s created the ref", name)
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package transport
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/Jcho114/go-git/repo"
+)
+
+// SSHTransport runs upload-pack and receive-pack on the remote host through
+// the ssh command, the same way git does, and talks to them over its
+// standard input and output. GIT_SSH_COMMAND or GIT_SSH replace the ssh
+// command when set.
+type SSHTransport struct {
+	Host         string
+	Port         string
+	Path         string
+	Capabilities map[string]string
+	Upload       *sshSession
+	// Receive holds what receive-pack advertised, since it only speaks the
+	// original protocol.
+	Receive             []Ref
+	ReceiveCapabilities map[string]string
+	ReceiveSession      *sshSession
+	Pushed              bool
+}
+
+type sshSession struct {
+	Command *exec.Cmd
+	Stdin   io.WriteCloser
+	Stdout  *bufio.Reader
+}
+
+// NewSSHTransport blocks a host, port or path starting with a dash, which
+// ssh or the remote shell would take for an option such as -oProxyCommand.
+func NewSSHTransport(host string, port string, path string) (*SSHTransport, error) {
+	if host == "" || path == "" {
+		return nil, fmt.Errorf("invalid ssh url for host '%s' and path '%s'", host, path)
+	}
+	if strings.HasPrefix(host, "-") {
+		return nil, fmt.Errorf("strange hostname '%s' blocked", host)
+	}
+	if strings.HasPrefix(port, "-") {
+		return nil, fmt.Errorf("strange port '%s' blocked", port)
+	}
+	if strings.HasPrefix(path, "-") {
+		return nil, fmt.Errorf("strange pathname '%s' blocked", path)
+	}
+	return &SSHTransport{Host: host, Port: port, Path: path}, nil
+}
+
+// sshParse splits an ssh url into its host, port and path. Besides
+// ssh://[user@]host[:port]/path, it takes the scp-style [user@]host:path,
+// which is told apart from a local path by its colon coming before any
+// slash. A path starting with ~ is relative to a home directory.
+func sshParse(url string) (string, string, string, bool) {
+	for _, scheme := range []string{"ssh://", "git+ssh://", "ssh+git://"} {
+		rest, ok := strings.CutPrefix(url, scheme)
+		if !ok {
+			continue
+		}
+		hostport, path, _ := strings.Cut(rest, "/")
+		path = "/" + path
+		if strings.HasPrefix(path, "/~") {
+			path = path[1:]
+		}
+		host, port := hostport, ""
+		if i := strings.LastIndex(hostport, ":"); i > strings.LastIndex(hostport, "]") {
+			host, port = hostport[:i], hostport[i+1:]
+		}
+		host = strings.NewReplacer("[", "", "]", "").Replace(host)
+		return host, port, path, true
+	}
+	if strings.Contains(url, "://") {
+		return "", "", "", false
+	}
+
+	colon := strings.Index(url, ":")
+	slash := strings.Index(url, "/")
+	if colon <= 0 || (slash >= 0 && slash < colon) {
+		return "", "", "", false
+	}
+	return url[:colon], "", url[colon+1:], true
+}
+
+// sshQuote quotes an argument for the shell the remote runs commands with.
+func sshQuote(arg string) string {
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
+
+// start runs a service on the remote host. The protocol version is passed
+// in GIT_PROTOCOL, which ssh forwards when the server accepts it.
+func (t *SSHTransport) start(service string, protocol string) (*sshSession, error) {
+	args := []string{}
+	if t.Port != "" {
+		args = append(args, "-p", t.Port)
+	}
+	if protocol != "" {
+		args = append(args, "-o", "SendEnv=GIT_PROTOCOL")
+	}
+	args = append(args, t.Host, service+" "+sshQuote(t.Path))
+
+	var command *exec.Cmd
+	if custom := os.Getenv("GIT_SSH_COMMAND"); custom != "" {
+		command = exec.Command("sh", append([]string{"-c", custom + ` "$@"`, custom}, args...)...)
+	} else if program := os.Getenv("GIT_SSH"); program != "" {
+		command = exec.Command(program, args...)
+	} else {
+		command = exec.Command("ssh", args...)
+	}
+	command.Env = os.Environ()
+	if protocol != "" {
+		command.Env = append(command.Env, "GIT_PROTOCOL="+protocol)
+	}
+	command.Stderr = os.Stderr
+
+	stdin, err := command.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := command.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	err = command.Start()
+	if err != nil {
+		return nil, err
+	}
+	return &sshSession{Command: command, Stdin: stdin, Stdout: bufio.NewReader(stdout)}, nil
+}
+
+// close hangs up on a service, first telling it there is nothing more to
+// ask when it is still waiting for requests.
+func (s *sshSession) close(flush bool) error {
+	if flush {
+		PktFlushWrite(s.Stdin)
+	}
+	s.Stdin.Close()
+	return s.Command.Wait()
+}
+
+func (t *SSHTransport) hungUp() error {
+	return fmt.Errorf("could not read from remote repository '%s:%s'", t.Host, t.Path)
+}
+
+// discover starts upload-pack and reads its capabilities, which also checks
+// that it speaks protocol version 2 at all.
+func (t *SSHTransport) discover() error {
+	if t.Upload != nil {
+		return nil
+	}
+	session, err := t.start("git-upload-pack", "version=2")
+	if err != nil {
+		return err
+	}
+	capabilities, err := capabilitiesRead(NewPktReader(session.Stdout))
+	if err == io.EOF {
+		session.close(false)
+		return t.hungUp()
+	}
+	if err != nil {
+		session.close(false)
+		return err
+	}
+	t.Upload, t.Capabilities = session, capabilities
+	return nil
+}
+
+// request sends a request to the upload-pack that is already running. Its
+// response is read straight off the same stream, which stays open.
+func (t *SSHTransport) request(body []byte) (io.ReadCloser, error) {
+	_, err := t.Upload.Stdin.Write(body)
+	if err != nil {
+		return nil, t.hungUp()
+	}
+	return io.NopCloser(t.Upload.Stdout), nil
+}
+
+func (t *SSHTransport) LsRefs(prefixes []string) ([]Ref, error) {
+	err := t.discover()
+	if err != nil {
+		return nil, err
+	}
+	return v2LsRefs(t, prefixes)
+}
+
+func (t *SSHTransport) Fetch(repository *repo.Repository, wants []string, progress io.Writer) error {
+	err := t.discover()
+	if err != nil {
+		return err
+	}
+	return v2Fetch(t, repository, wants, progress)
+}
+
+func (t *SSHTransport) PushRefs() ([]Ref, error) {
+	if t.ReceiveSession != nil {
+		return t.Receive, nil
+	}
+	session, err := t.start("git-receive-pack", "")
+	if err != nil {
+		return nil, err
+	}
+	t.Receive, t.ReceiveCapabilities, err = advertisementRead(NewPktReader(session.Stdout))
+	if err == io.EOF {
+		session.close(false)
+		return nil, t.hungUp()
+	}
+	if err != nil {
+		session.close(false)
+		return nil, err
+	}
+	t.ReceiveSession = session
+	return t.Receive, nil
+}
+
+func (t *SSHTransport) Push(repository *repo.Repository, commands []Command, haves []string, progress io.Writer) (map[string]string, error) {
+	_, err := t.PushRefs()
+	if err != nil {
+		return nil, err
+	}
+
+	t.Pushed = true
+	writer := bufio.NewWriter(t.ReceiveSession.Stdin)
+	err = receivePackWrite(writer, repository, commands, haves, t.ReceiveCapabilities, progress)
+	if err != nil {
+		return nil, err
+	}
+	err = writer.Flush()
+	if err != nil {
+		return nil, t.hungUp()
+	}
+	t.ReceiveSession.Stdin.Close()
+	return receivePackReport(t.ReceiveSession.Stdout, t.ReceiveCapabilities, progress)
+}
+
+func (t *SSHTransport) Close() error {
+	var err error
+	if t.Upload != nil {
+		err = t.Upload.close(true)
+	}
+	if t.ReceiveSession != nil {
+		receiveerr := t.ReceiveSession.close(!t.Pushed)
+		if err == nil {
+			err = receiveerr
+		}
+	}
+	return err
+}
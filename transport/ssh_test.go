@@ -0,0 +1,419 @@
+package transport
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Jcho114/go-git/obj"
+	"github.com/Jcho114/go-git/ref"
+	"github.com/Jcho114/go-git/repo"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestSSHParse(t *testing.T) {
+	tests := []struct {
+		url  string
+		host string
+		port string
+		path string
+		ok   bool
+	}{
+		{"git@example.com:user/repo.git", "git@example.com", "", "user/repo.git", true},
+		{"example.com:/srv/repo", "example.com", "", "/srv/repo", true},
+		{"ssh://example.com/srv/repo", "example.com", "", "/srv/repo", true},
+		{"ssh://git@example.com:2222/~user/repo", "git@example.com", "2222", "~user/repo", true},
+		{"git+ssh://example.com/repo", "example.com", "", "/repo", true},
+		{"ssh://[::1]:22/repo", "::1", "22", "/repo", true},
+		{"ssh://git@[fe80::1]/repo", "git@fe80::1", "", "/repo", true},
+		{"./dir:name", "", "", "", false},
+		{"dir/sub:name", "", "", "", false},
+		{"/abs/dir:name", "", "", "", false},
+		{"repo", "", "", "", false},
+		{"https://example.com/repo", "", "", "", false},
+		{"file:///srv/repo", "", "", "", false},
+	}
+	for _, test := range tests {
+		host, port, path, ok := sshParse(test.url)
+		if host != test.host || port != test.port || path != test.path || ok != test.ok {
+			t.Errorf("sshParse(%q) = %q, %q, %q, %v, want %q, %q, %q, %v", test.url, host, port, path, ok, test.host, test.port, test.path, test.ok)
+		}
+	}
+}
+
+func TestSSHOptionBlocked(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"ssh://-oProxyCommand=touch${IFS}pwned/repo", "strange hostname"},
+		{"-oProxyCommand=touch:repo", "strange hostname"},
+		{"ssh://example.com:-oProxyCommand=x/repo", "strange port"},
+		{"example.com:--upload-pack=touch", "strange pathname"},
+	}
+	for _, test := range tests {
+		_, err := Open(test.url)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("opening %q returned %v, want %s blocked", test.url, err, test.want)
+		}
+	}
+}
+
+// testSSHCommand builds gg and stands in for ssh with a GIT_SSH script
+// that runs the requested service locally, the way sshd would run it on the
+// remote host. It returns the log the stand-in keeps.
+func testSSHCommand(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no shell to run the ssh stand-in with")
+	}
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("no go command to build gg with")
+	}
+	bin := t.TempDir()
+	build := exec.Command("go", "build", "-o", filepath.Join(bin, "gg"), "github.com/Jcho114/go-git")
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("building gg: %v\n%s", err, out)
+	}
+	for _, service := range []string{"upload-pack", "receive-pack"} {
+		script := "#!/bin/sh\nexec " + sshQuote(filepath.Join(bin, "gg")) + " " + service + " \"$@\"\n"
+		err := os.WriteFile(filepath.Join(bin, "git-"+service), []byte(script), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The host is dropped along with the options, and a log records what
+	// the remote was asked to run and with which protocol.
+	stub := "#!/bin/sh\n" +
+		"while [ $# -gt 0 ]; do case \"$1\" in -p|-o) shift 2;; *) break;; esac; done\n" +
+		"shift\n" +
+		"echo \"$GIT_PROTOCOL $1\" >> " + sshQuote(filepath.Join(bin, "log")) + "\n" +
+		"PATH=" + sshQuote(bin) + ":\"$PATH\" exec sh -c \"$1\"\n"
+	err := os.WriteFile(filepath.Join(bin, "ssh"), []byte(stub), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GIT_SSH_COMMAND", "")
+	t.Setenv("GIT_SSH", filepath.Join(bin, "ssh"))
+	return filepath.Join(bin, "log")
+}
+
+func TestSSHFetch(t *testing.T) {
+	log := testSSHCommand(t)
+	server := testRepository(t)
+	client := testRepository(t)
+	main := testCommit(t, server, "main", map[string]string{"a.txt": "a\n"}, "first")
+	tag := testTag(t, server, "v1", main)
+
+	conn, err := Open("example.com:" + server.Worktree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	refs, err := conn.LsRefs([]string{"HEAD", "refs/tags/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(refs) != 2 || refs[0].Target != "refs/heads/main" || refs[0].Sha != main || refs[1].Sha != tag {
+		t.Errorf("ls-refs returned %+v, want HEAD at %s and v1 at %s", refs, main, tag)
+	}
+
+	err = conn.Fetch(client, []string{main}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sha := range []string{main, tag} {
+		if !obj.ObjectExists(client, sha) {
+			t.Errorf("fetch did not bring %s", sha)
+		}
+	}
+
+	// One upload-pack serves both requests, and knows to speak version 2.
+	calls, err := os.ReadFile(log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "version=2 git-upload-pack " + sshQuote(server.Worktree) + "\n"; string(calls) != want {
+		t.Errorf("ssh ran %q, want %q", calls, want)
+	}
+}
+
+func TestSSHPush(t *testing.T) {
+	testSSHCommand(t)
+	server := testRepository(t)
+	client := testRepository(t)
+	base := testCommit(t, server, "main", map[string]string{"a.txt": "a\n"}, "first")
+	testCommit(t, client, "main", map[string]string{"b.txt": "b\n"}, "unrelated")
+
+	conn, err := Open("ssh://example.com:2222" + server.Worktree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	err = conn.Fetch(client, []string{base}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ref.RefWrite(client, "refs/heads/topic", base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := testCommit(t, client, "topic", map[string]string{"a.txt": "a\n", "c.txt": "c\n"}, "next")
+
+	refs, err := conn.PushRefs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(refs) != 1 || refs[0].Name != "refs/heads/main" || refs[0].Sha != base {
+		t.Errorf("receive-pack advertised %+v, want main at %s", refs, base)
+	}
+	statuses, err := conn.Push(client, []Command{{Old: zeroSha, New: next, Name: "refs/heads/topic"}}, []string{base}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status := statuses["refs/heads/topic"]; status != "" {
+		t.Errorf("pushing topic was rejected: %s", status)
+	}
+	if got, _ := ref.RefResolve(server, "refs/heads/topic"); got != next {
+		t.Errorf("remote topic is at %s, want %s", got, next)
+	}
+	if !obj.ObjectExists(server, next) {
+		t.Errorf("the remote is missing %s", next)
+	}
+}
+
+// testSSHServer stands in for sshd, running upload-pack and receive-pack in
+// process for the commands clients exec, and keeps "<GIT_PROTOCOL> <command>"
+// for each of them.
+type testSSHServer struct {
+	Config   *ssh.ServerConfig
+	Mutex    sync.Mutex
+	Commands []string
+}
+
+// testSSHListen starts a server and points GIT_SSH_COMMAND at the ssh client
+// with options that let it connect without keys or known hosts. It returns
+// the address to reach the server on.
+func testSSHListen(t *testing.T) (*testSSHServer, string, string) {
+	t.Helper()
+	if _, err := exec.LookPath("ssh"); err != nil {
+		t.Skip("no ssh client to connect with")
+	}
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &testSSHServer{Config: &ssh.ServerConfig{NoClientAuth: true}}
+	server.Config.AddHostKey(signer)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serveConn(conn)
+		}
+	}()
+
+	t.Setenv("GIT_SSH", "")
+	t.Setenv("GIT_SSH_COMMAND", "ssh -F /dev/null -o BatchMode=yes -o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null -o LogLevel=ERROR")
+	host, port, err := net.SplitHostPort(listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return server, host, port
+}
+
+func (s *testSSHServer) serveConn(conn net.Conn) {
+	defer conn.Close()
+	_, channels, requests, err := ssh.NewServerConn(conn, s.Config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(requests)
+	for newchannel := range channels {
+		if newchannel.ChannelType() != "session" {
+			newchannel.Reject(ssh.UnknownChannelType, "only sessions are served")
+			continue
+		}
+		channel, requests, err := newchannel.Accept()
+		if err != nil {
+			return
+		}
+		go s.serveSession(channel, requests)
+	}
+}
+
+func (s *testSSHServer) serveSession(channel ssh.Channel, requests <-chan *ssh.Request) {
+	env := make(map[string]string)
+	for request := range requests {
+		switch request.Type {
+		case "env":
+			var payload struct{ Name, Value string }
+			if ssh.Unmarshal(request.Payload, &payload) == nil {
+				env[payload.Name] = payload.Value
+			}
+			request.Reply(true, nil)
+		case "exec":
+			var payload struct{ Command string }
+			if ssh.Unmarshal(request.Payload, &payload) != nil {
+				request.Reply(false, nil)
+				continue
+			}
+			request.Reply(true, nil)
+			s.Mutex.Lock()
+			s.Commands = append(s.Commands, env["GIT_PROTOCOL"]+" "+payload.Command)
+			s.Mutex.Unlock()
+			go func() {
+				status := uint32(0)
+				err := testSSHRun(payload.Command, env["GIT_PROTOCOL"], channel)
+				if err != nil {
+					fmt.Fprintln(channel.Stderr(), err)
+					status = 1
+				}
+				channel.CloseWrite()
+				channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{status}))
+				channel.Close()
+			}()
+		default:
+			request.Reply(false, nil)
+		}
+	}
+}
+
+// testSSHRun serves a "<service> '<path>'" command the way gg upload-pack
+// and gg receive-pack do.
+func testSSHRun(command string, protocol string, channel ssh.Channel) error {
+	service, path, _ := strings.Cut(command, " ")
+	if len(path) < 2 || path[0] != '\'' || path[len(path)-1] != '\'' {
+		return fmt.Errorf("unquoted path in '%s'", command)
+	}
+	path = strings.ReplaceAll(path[1:len(path)-1], `'\''`, "'")
+	repository, err := repo.FindRepository(path, true)
+	if err != nil {
+		return err
+	}
+
+	switch service {
+	case "git-upload-pack":
+		if !UploadPackVersion(protocol) {
+			return UploadPackRefuse(channel)
+		}
+		err := UploadPackAdvertise(channel)
+		if err != nil {
+			return err
+		}
+		return UploadPack(repository, channel, channel)
+	case "git-receive-pack":
+		err := ReceivePackAdvertise(repository, channel)
+		if err != nil {
+			return err
+		}
+		return ReceivePack(repository, channel, channel, nil)
+	}
+	return fmt.Errorf("unknown command '%s'", command)
+}
+
+func TestSSHServerFetch(t *testing.T) {
+	sshserver, host, port := testSSHListen(t)
+	server := testRepository(t)
+	client := testRepository(t)
+	main := testCommit(t, server, "main", map[string]string{"a.txt": "a\n"}, "first")
+	tag := testTag(t, server, "v1", main)
+
+	conn, err := Open(fmt.Sprintf("ssh://git@%s:%s%s", host, port, server.Worktree))
+	if err != nil {
+		t.Fatal(err)
+	}
+	refs, err := conn.LsRefs([]string{"HEAD", "refs/tags/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(refs) != 2 || refs[0].Target != "refs/heads/main" || refs[0].Sha != main || refs[1].Sha != tag {
+		t.Errorf("ls-refs returned %+v, want HEAD at %s and v1 at %s", refs, main, tag)
+	}
+	err = conn.Fetch(client, []string{main}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = conn.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sha := range []string{main, tag} {
+		if !obj.ObjectExists(client, sha) {
+			t.Errorf("fetch did not bring %s", sha)
+		}
+	}
+
+	want := []string{"version=2 git-upload-pack " + sshQuote(server.Worktree)}
+	if strings.Join(sshserver.Commands, "\n") != strings.Join(want, "\n") {
+		t.Errorf("the server ran %q, want %q", sshserver.Commands, want)
+	}
+}
+
+func TestSSHServerPush(t *testing.T) {
+	sshserver, host, port := testSSHListen(t)
+	server := testRepository(t)
+	client := testRepository(t)
+	base := testCommit(t, server, "main", map[string]string{"a.txt": "a\n"}, "first")
+
+	// The scp-style url reaches the server through the port in ssh's options.
+	t.Setenv("GIT_SSH_COMMAND", os.Getenv("GIT_SSH_COMMAND")+" -p "+port)
+	conn, err := Open(host + ":" + server.Worktree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = conn.Fetch(client, []string{base}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ref.RefWrite(client, "refs/heads/topic", base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := testCommit(t, client, "topic", map[string]string{"a.txt": "a\n", "c.txt": "c\n"}, "next")
+
+	refs, err := conn.PushRefs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(refs) != 1 || refs[0].Name != "refs/heads/main" || refs[0].Sha != base {
+		t.Errorf("receive-pack advertised %+v, want main at %s", refs, base)
+	}
+	statuses, err := conn.Push(client, []Command{{Old: zeroSha, New: next, Name: "refs/heads/topic"}}, []string{base}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = conn.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status, ok := statuses["refs/heads/topic"]; ok {
+		t.Errorf("pushing topic was rejected: %s", status)
+	}
+	if got, _ := ref.RefResolve(server, "refs/heads/topic"); got != next {
+		t.Errorf("remote topic is at %s, want %s", got, next)
+	}
+	if len(sshserver.Commands) != 2 || sshserver.Commands[1] != " git-receive-pack "+sshQuote(server.Worktree) {
+		t.Errorf("the server ran %q", sshserver.Commands)
+	}
+}
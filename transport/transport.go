@@ -0,0 +1,82 @@
+package transport
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Jcho114/go-git/repo"
+)
+
+// Ref is a ref as advertised by a remote. Target is set for symbolic refs
+// such as HEAD and Peeled for annotated tags.
+type Ref struct {
+	Name   string
+	Sha    string
+	Target string
+	Peeled string
+}
+
+// Command asks a remote to move a ref from Old to New. An empty Old creates
+// the ref and an empty New deletes it.
+type Command struct {
+	Name string
+	Old  string
+	New  string
+}
+
+// Transport is a connection to a remote repository, however it is reached.
+type Transport interface {
+	// LsRefs lists the refs of the remote whose names start with one of the
+	// given prefixes, or every ref when none are given.
+	LsRefs(prefixes []string) ([]Ref, error)
+	// Fetch copies the objects reachable from wants into the repository,
+	// writing any progress the remote reports to progress.
+	Fetch(repository *repo.Repository, wants []string, progress io.Writer) error
+	// PushRefs lists the refs of the remote as they are before a push.
+	PushRefs() ([]Ref, error)
+	// Push sends the commands along with the objects they need that are not
+	// reachable from haves, which the remote already has. It returns the
+	// reason the remote gave for each ref it refused to update.
+	Push(repository *repo.Repository, commands []Command, haves []string, progress io.Writer) (map[string]string, error)
+	Close() error
+}
+
+// Open connects to the repository at the given url, which is either an
+// http or https url, an ssh url, a file:// url, or a plain path.
+func Open(url string) (Transport, error) {
+	if strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://") {
+		return NewHTTPTransport(url)
+	}
+	if host, port, path, ok := sshParse(url); ok {
+		return NewSSHTransport(host, port, path)
+	}
+	if path, ok := LocalPath(url); ok {
+		return NewLocalTransport(path)
+	}
+	return nil, fmt.Errorf("unsupported url '%s'", url)
+}
+
+// progressWriter prefixes every line of the progress a remote reports, so it
+// can be told apart from local output.
+type progressWriter struct {
+	Writer io.Writer
+	Start  bool
+}
+
+func NewProgressWriter(writer io.Writer) io.Writer {
+	return &progressWriter{Writer: writer, Start: true}
+}
+
+func (w *progressWriter) Write(data []byte) (int, error) {
+	var out strings.Builder
+	for _, b := range data {
+		if w.Start {
+			out.WriteString("remote: ")
+		}
+		out.WriteByte(b)
+		w.Start = b == '\n' || b == '\r'
+	}
+	_, err := io.WriteString(w.Writer, out.String())
+	return len(data), err
+}
@@ -0,0 +1,276 @@
+package transport
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/Jcho114/go-git/obj"
+	"github.com/Jcho114/go-git/ref"
+	"github.com/Jcho114/go-git/repo"
+)
+
+// UploadPackAdvertise writes the protocol version 2 capabilities a client
+// needs before it sends any requests.
+func UploadPackAdvertise(writer io.Writer) error {
+	for _, line := range []string{"version 2", "agent=go-git", "ls-refs", "fetch", "object-format=sha1"} {
+		err := PktLineWritef(writer, "%s\n", line)
+		if err != nil {
+			return err
+		}
+	}
+	return PktFlushWrite(writer)
+}
+
+// UploadPackVersion reports whether a client asked for protocol version 2 in
+// its Git-Protocol header or GIT_PROTOCOL variable, a colon separated list
+// of parameters. Clients that send neither speak the original protocol.
+func UploadPackVersion(protocol string) bool {
+	for _, param := range strings.Split(protocol, ":") {
+		if param == "version=2" {
+			return true
+		}
+	}
+	return false
+}
+
+// UploadPackRefuse turns away a client speaking an older protocol. It reads
+// the reply as a ref advertisement, where an ERR line makes it stop with the
+// message rather than take the repository for an empty one.
+func UploadPackRefuse(writer io.Writer) error {
+	return uploadPackError(writer, "only protocol version 2 is supported, set protocol.version=2")
+}
+
+// UploadPack answers protocol version 2 requests for the refs and objects of
+// a repository until the client hangs up or sends a lone flush.
+func UploadPack(repository *repo.Repository, reader io.Reader, writer io.Writer) error {
+	requests := NewPktReader(reader)
+	for {
+		lines, kind, err := requests.Lines()
+		if err == io.EOF || (err == nil && len(lines) == 0 && kind == PktFlush) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		command, ok := strings.CutPrefix(lines[0], "command=")
+		if !ok {
+			return uploadPackError(writer, "expected a command, got '%s'", lines[0])
+		}
+		args := []string{}
+		if kind == PktDelim {
+			args, _, err = requests.Lines()
+			if err != nil {
+				return err
+			}
+		}
+
+		switch command {
+		case "ls-refs":
+			err = lsRefsServe(repository, args, writer)
+		case "fetch":
+			err = fetchServe(repository, args, writer)
+		default:
+			return uploadPackError(writer, "unknown command '%s'", command)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func uploadPackError(writer io.Writer, format string, args ...any) error {
+	message := fmt.Sprintf(format, args...)
+	PktLineWritef(writer, "ERR %s\n", message)
+	return fmt.Errorf("%s", message)
+}
+
+// serverRefs lists HEAD followed by every ref of the repository in name
+// order, with symbolic refs and annotated tags resolved.
+func serverRefs(repository *repo.Repository) ([]Ref, error) {
+	res := []Ref{}
+	if sha, err := ref.RefResolve(repository, "HEAD"); err == nil {
+		target, _ := ref.RefSymbolicRead(repository, "HEAD")
+		res = append(res, Ref{Name: "HEAD", Sha: sha, Target: target})
+	}
+
+	refmap, err := ref.RefList(repository, "")
+	if err != nil {
+		return nil, err
+	}
+	refs := ref.RefFlatten(refmap, "refs")
+	names := []string{}
+	for name := range refs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		serverref := Ref{Name: name, Sha: refs[name]}
+		if format, _, err := obj.ObjectReadRaw(repository, serverref.Sha); err == nil && format == "tag" {
+			serverref.Peeled, _ = obj.ObjectFind(repository, serverref.Sha+"^{}", "any", true)
+		}
+		res = append(res, serverref)
+	}
+	return res, nil
+}
+
+// refsFilter keeps the refs whose names start with one of the prefixes, or
+// every ref when there are none.
+func refsFilter(refs []Ref, prefixes []string) []Ref {
+	if len(prefixes) == 0 {
+		return refs
+	}
+	res := []Ref{}
+	for _, serverref := range refs {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(serverref.Name, prefix) {
+				res = append(res, serverref)
+				break
+			}
+		}
+	}
+	return res
+}
+
+func lsRefsServe(repository *repo.Repository, args []string, writer io.Writer) error {
+	peel, symrefs := false, false
+	prefixes := []string{}
+	for _, arg := range args {
+		switch {
+		case arg == "peel":
+			peel = true
+		case arg == "symrefs":
+			symrefs = true
+		case strings.HasPrefix(arg, "ref-prefix "):
+			prefixes = append(prefixes, strings.TrimPrefix(arg, "ref-prefix "))
+		}
+	}
+
+	refs, err := serverRefs(repository)
+	if err != nil {
+		return err
+	}
+	for _, serverref := range refsFilter(refs, prefixes) {
+		line := serverref.Sha + " " + serverref.Name
+		if symrefs && serverref.Target != "" {
+			line += " symref-target:" + serverref.Target
+		}
+		if peel && serverref.Peeled != "" {
+			line += " peeled:" + serverref.Peeled
+		}
+		err := PktLineWritef(writer, "%s\n", line)
+		if err != nil {
+			return err
+		}
+	}
+	return PktFlushWrite(writer)
+}
+
+// fetchServe answers a single round of negotiation. Until the client is
+// done, it acknowledges the commits both sides have, and declares itself
+// ready to send a pack as soon as there are any, since the pack can leave
+// out their history.
+func fetchServe(repository *repo.Repository, args []string, writer io.Writer) error {
+	wants, haves := []string{}, []string{}
+	done, thin, progress, includetag := false, false, true, false
+	for _, arg := range args {
+		switch {
+		case strings.HasPrefix(arg, "want "):
+			wants = append(wants, strings.TrimPrefix(arg, "want "))
+		case strings.HasPrefix(arg, "have "):
+			haves = append(haves, strings.TrimPrefix(arg, "have "))
+		case arg == "done":
+			done = true
+		case arg == "thin-pack":
+			thin = true
+		case arg == "no-progress":
+			progress = false
+		case arg == "include-tag":
+			includetag = true
+		}
+	}
+	for _, want := range wants {
+		if !obj.ObjectExists(repository, want) {
+			return uploadPackError(writer, "upload-pack: not our ref %s", want)
+		}
+	}
+	common := []string{}
+	for _, have := range haves {
+		if obj.ObjectExists(repository, have) {
+			common = append(common, have)
+		}
+	}
+
+	if !done {
+		PktLineWritef(writer, "acknowledgments\n")
+		if len(common) == 0 {
+			PktLineWritef(writer, "NAK\n")
+		}
+		for _, sha := range common {
+			PktLineWritef(writer, "ACK %s\n", sha)
+		}
+		if len(common) == 0 {
+			return PktFlushWrite(writer)
+		}
+		PktLineWritef(writer, "ready\n")
+		PktDelimWrite(writer)
+	}
+
+	entries, bases, err := packEntries(repository, wants, common)
+	if err != nil {
+		return err
+	}
+	if !thin {
+		bases = nil
+	}
+	if includetag {
+		entries, err = fetchServeTags(repository, entries, common)
+		if err != nil {
+			return err
+		}
+	}
+
+	PktLineWritef(writer, "packfile\n")
+	if progress {
+		fmt.Fprintf(NewSidebandWriter(writer, 2), "Enumerating objects: %d, done.\n", len(entries))
+	}
+	err = obj.PackSend(repository, NewSidebandWriter(writer, 1), entries, bases)
+	if err != nil {
+		return err
+	}
+	return PktFlushWrite(writer)
+}
+
+// fetchServeTags adds the annotated tags that point at objects being sent,
+// which the client asked to be included so it can follow them.
+func fetchServeTags(repository *repo.Repository, entries []obj.WalkEntry, haves []string) ([]obj.WalkEntry, error) {
+	sending := make(map[string]bool)
+	for _, entry := range entries {
+		sending[entry.Sha] = true
+	}
+	for _, have := range haves {
+		sending[have] = true
+	}
+
+	refs, err := serverRefs(repository)
+	if err != nil {
+		return nil, err
+	}
+	for _, serverref := range refs {
+		if serverref.Peeled == "" || !strings.HasPrefix(serverref.Name, "refs/tags/") || sending[serverref.Sha] {
+			continue
+		}
+		object, err := obj.ObjectRead(repository, serverref.Sha)
+		if err != nil {
+			return nil, err
+		}
+		tag, ok := object.(*obj.Tag)
+		if !ok || len(tag.Kvlm["object"]) == 0 || !sending[tag.Kvlm["object"][0]] {
+			continue
+		}
+		entries = append(entries, obj.WalkEntry{Sha: serverref.Sha, Format: "tag"})
+		sending[serverref.Sha] = true
+	}
+	return entries, nil
+}
@@ -0,0 +1,211 @@
+package transport
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Jcho114/go-git/obj"
+	"github.com/Jcho114/go-git/repo"
+)
+
+// uploadPack carries protocol version 2 requests to a remote upload-pack.
+// Every request stands on its own, so the same conversation works whether
+// each one is a separate HTTP request or they share a single stream.
+type uploadPack interface {
+	request(body []byte) (io.ReadCloser, error)
+}
+
+const (
+	fetchHaveBatch = 32
+	fetchMaxInVain = 256
+)
+
+// capabilitiesRead reads a version 2 capability advertisement, which starts
+// with "version 2" and lists one capability per line up to a flush.
+func capabilitiesRead(reader *PktReader) (map[string]string, error) {
+	lines, kind, err := reader.Lines()
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) > 0 && strings.HasPrefix(lines[0], "# service=") {
+		lines, kind, err = reader.Lines()
+		if err != nil {
+			return nil, err
+		}
+	}
+	if kind != PktFlush || len(lines) == 0 || lines[0] != "version 2" {
+		return nil, fmt.Errorf("remote does not support protocol version 2")
+	}
+
+	res := make(map[string]string)
+	for _, line := range lines[1:] {
+		key, value, _ := strings.Cut(line, "=")
+		res[key] = value
+	}
+	return res, nil
+}
+
+func v2LsRefs(conn uploadPack, prefixes []string) ([]Ref, error) {
+	var body bytes.Buffer
+	PktLineWritef(&body, "command=ls-refs\n")
+	PktDelimWrite(&body)
+	PktLineWritef(&body, "peel\n")
+	PktLineWritef(&body, "symrefs\n")
+	for _, prefix := range prefixes {
+		PktLineWritef(&body, "ref-prefix %s\n", prefix)
+	}
+	PktFlushWrite(&body)
+
+	response, err := conn.request(body.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	defer response.Close()
+
+	lines, _, err := NewPktReader(response).Lines()
+	if err != nil {
+		return nil, err
+	}
+	res := []Ref{}
+	for _, line := range lines {
+		if message, ok := strings.CutPrefix(line, "ERR "); ok {
+			return nil, fmt.Errorf("remote error: %s", message)
+		}
+		fields := strings.Split(line, " ")
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("invalid ls-refs line '%s'", line)
+		}
+		remote := Ref{Sha: fields[0], Name: fields[1]}
+		for _, attribute := range fields[2:] {
+			if target, ok := strings.CutPrefix(attribute, "symref-target:"); ok {
+				remote.Target = target
+			}
+			if peeled, ok := strings.CutPrefix(attribute, "peeled:"); ok {
+				remote.Peeled = peeled
+			}
+		}
+		res = append(res, remote)
+	}
+	return res, nil
+}
+
+// v2Fetch negotiates which objects the remote has to send and stores the
+// pack it answers with. The remote keeps no state between requests, so
+// every round repeats the wants and the commits both sides have in common,
+// followed by a new batch of local commits. Negotiation ends when the remote
+// is ready to send a pack, or with "done" once there is nothing left to
+// offer or too many commits were offered in vain.
+func v2Fetch(conn uploadPack, repository *repo.Repository, wants []string, progress io.Writer) error {
+	n, err := newNegotiator(repository)
+	if err != nil {
+		return err
+	}
+
+	common := []string{}
+	invain := 0
+	for {
+		haves, err := n.next(fetchHaveBatch)
+		if err != nil {
+			return err
+		}
+		done := len(haves) == 0 || invain >= fetchMaxInVain
+
+		var body bytes.Buffer
+		PktLineWritef(&body, "command=fetch\n")
+		PktDelimWrite(&body)
+		PktLineWritef(&body, "thin-pack\n")
+		PktLineWritef(&body, "ofs-delta\n")
+		PktLineWritef(&body, "include-tag\n")
+		if progress == nil {
+			PktLineWritef(&body, "no-progress\n")
+		}
+		for _, want := range wants {
+			PktLineWritef(&body, "want %s\n", want)
+		}
+		for _, have := range append(common, haves...) {
+			PktLineWritef(&body, "have %s\n", have)
+		}
+		if done {
+			PktLineWritef(&body, "done\n")
+		}
+		PktFlushWrite(&body)
+
+		response, err := conn.request(body.Bytes())
+		if err != nil {
+			return err
+		}
+		acks, received, err := v2FetchResponse(NewPktReader(response), repository, progress)
+		response.Close()
+		if err != nil {
+			return err
+		}
+		if received {
+			return nil
+		}
+		if done {
+			return fmt.Errorf("remote did not send a pack")
+		}
+
+		invain += len(haves)
+		for _, ack := range acks {
+			if n.Common[ack] {
+				continue
+			}
+			common = append(common, ack)
+			invain = 0
+			err := n.ack(ack)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// v2FetchResponse reads the sections of a fetch response, returning the
+// commits the remote acknowledged and whether it sent a pack.
+func v2FetchResponse(reader *PktReader, repository *repo.Repository, progress io.Writer) ([]string, bool, error) {
+	acks := []string{}
+	for {
+		header, kind, err := reader.Next()
+		if err == io.EOF || (err == nil && kind != PktData) {
+			return acks, false, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		section := strings.TrimSuffix(string(header), "\n")
+		if message, ok := strings.CutPrefix(section, "ERR "); ok {
+			return nil, false, fmt.Errorf("remote error: %s", message)
+		}
+
+		if section == "packfile" {
+			var pack bytes.Buffer
+			err := reader.Sideband(&pack, progress)
+			if err != nil {
+				return nil, false, err
+			}
+			_, err = obj.PackReceive(repository, &pack)
+			if err != nil {
+				return nil, false, err
+			}
+			return acks, true, nil
+		}
+
+		lines, kind, err := reader.Lines()
+		if err != nil {
+			return nil, false, err
+		}
+		if section == "acknowledgments" {
+			for _, line := range lines {
+				if sha, ok := strings.CutPrefix(line, "ACK "); ok {
+					acks = append(acks, sha)
+				}
+			}
+		}
+		if kind != PktDelim {
+			return acks, false, nil
+		}
+	}
+}